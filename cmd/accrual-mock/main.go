@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/simulator"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/server"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/middleware"
+	flag "github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+type config struct {
+	RunAddress string `env:"RUN_ADDRESS"`
+	RulesFile  string `env:"RULES_FILE"`
+	LogLevel   string `env:"LOG_LEVEL"`
+}
+
+func parseConfig() *config {
+	config := &config{}
+	flag.StringVarP(&config.RunAddress, "address", "a", ":8081", "address of accrual system simulator")
+	flag.StringVarP(&config.RulesFile, "rules-file", "f", "", "path to JSON file with rules, default rules are used if empty")
+	flag.StringVarP(&config.LogLevel, "log-level", "l", "info", "log level")
+	flag.Parse()
+	if err := env.Parse(config); err != nil {
+		panic(err)
+	}
+
+	return config
+}
+
+func loadRules(path string) ([]simulator.Rule, error) {
+	if path == "" {
+		return simulator.DefaultRules(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return simulator.DecodeRules(file)
+}
+
+func main() {
+	config := parseConfig()
+	logger.Init("accrual-mock", config.LogLevel)
+	defer logger.Logger.Sync()
+	defer logger.RecoverAndPanic()
+	logger.Logger.Info(
+		"Starting",
+		zap.String("run_address", config.RunAddress),
+		zap.String("rules_file", config.RulesFile),
+		zap.String("log_level", config.LogLevel),
+	)
+
+	rules, err := loadRules(config.RulesFile)
+	if err != nil {
+		logger.Logger.Fatal("Can't load rules", zap.Error(err))
+	}
+
+	handler := middleware.ZapLogRequest(logger.Logger, "http-request")(simulator.NewHandler(rules...))
+	if err := server.New(config.RunAddress, handler).ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Logger.Fatal("Can't run simulator", zap.Error(err))
+	}
+}
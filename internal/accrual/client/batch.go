@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/semaphore"
+)
+
+const DefaultFanOutConcurrency = 4
+
+// AccrualResult is a result of a single order lookup in a batch
+type AccrualResult struct {
+	OrderID uint64
+	Accrual *responses.Accrual
+	Err     error
+}
+
+// BatchStrategy defines how accruals of several orders are fetched.
+// Results must be returned in the order of orderIDs
+type BatchStrategy interface {
+	GetAccruals(ctx context.Context, client *Client, orderIDs []uint64) []AccrualResult
+}
+
+// GetAccruals fetches accruals of several orders using configured batch strategy
+func (client *Client) GetAccruals(ctx context.Context, orderIDs []uint64) []AccrualResult {
+	return client.config.batchStrategy.GetAccruals(ctx, client, orderIDs)
+}
+
+type fanOut struct {
+	concurrency uint64
+}
+
+// FanOut fetches orders one by one with bounded concurrency over reused connections.
+// After rate limiting or circuit breaker rejection the rest orders are not requested and get the same error
+func FanOut(concurrency uint64) BatchStrategy {
+	if concurrency == 0 {
+		concurrency = DefaultFanOutConcurrency
+	}
+
+	return &fanOut{concurrency: concurrency}
+}
+
+func (strategy *fanOut) GetAccruals(ctx context.Context, client *Client, orderIDs []uint64) []AccrualResult {
+	results := make([]AccrualResult, len(orderIDs))
+	semaphore := semaphore.New(strategy.concurrency)
+	waitGroup := &sync.WaitGroup{}
+
+	var rejectedMutex sync.Mutex
+	var rejected error
+
+	for i, orderID := range orderIDs {
+		results[i].OrderID = orderID
+
+		if err := semaphore.Acquire(ctx, 1); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		rejectedMutex.Lock()
+		err := rejected
+		rejectedMutex.Unlock()
+		if err != nil {
+			results[i].Err = err
+			semaphore.Release(1)
+			continue
+		}
+
+		waitGroup.Add(1)
+		go func(result *AccrualResult) {
+			defer waitGroup.Done()
+			defer semaphore.Release(1)
+
+			result.Accrual, result.Err = client.GetAccrual(ctx, result.OrderID)
+			if isRejection(result.Err) {
+				rejectedMutex.Lock()
+				rejected = result.Err
+				rejectedMutex.Unlock()
+			}
+		}(&results[i])
+	}
+
+	waitGroup.Wait()
+
+	return results
+}
+
+type batchEndpoint struct {
+	path string
+}
+
+// BatchEndpoint fetches all orders by a single POST request to the accrual system batch endpoint.
+// The endpoint accepts JSON array of order numbers and responds with JSON array of accruals,
+// orders missing in the response are considered not found
+func BatchEndpoint(path string) BatchStrategy {
+	return &batchEndpoint{path: path}
+}
+
+func (strategy *batchEndpoint) GetAccruals(ctx context.Context, client *Client, orderIDs []uint64) []AccrualResult {
+	results := make([]AccrualResult, len(orderIDs))
+	for i, orderID := range orderIDs {
+		results[i].OrderID = orderID
+	}
+
+	numbers := make([]uint64String, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		numbers = append(numbers, uint64String(orderID))
+	}
+
+	response, err := client.doRequest(client.createRequest(ctx).
+		SetBody(numbers).
+		SetResult(&[]*responses.Accrual{}),
+		resty.MethodPost, strategy.path)
+	if err != nil {
+		for i := range results {
+			results[i].Err = err
+		}
+
+		return results
+	}
+
+	accruals := make(map[uint64]*responses.Accrual, len(orderIDs))
+	for _, accrual := range *response.Result().(*[]*responses.Accrual) {
+		if accrual != nil {
+			accruals[accrual.OrderID] = accrual
+		}
+	}
+
+	for i := range results {
+		if accrual, ok := accruals[results[i].OrderID]; ok {
+			if err := validateAccrual(results[i].OrderID, accrual); err != nil {
+				results[i].Err = err
+			} else {
+				results[i].Accrual = accrual
+			}
+		} else {
+			results[i].Err = ErrOrderNotFound
+		}
+	}
+
+	return results
+}
+
+// uint64String is encoded as JSON string, order numbers are strings in the accrual system API
+type uint64String uint64
+
+func (value uint64String) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatUint(uint64(value), 10) + `"`), nil
+}
+
+// isRejection reports whether accrual system will reject any next request for a while
+func isRejection(err error) bool {
+	return errors.As(err, &ErrTooManyRequests{}) || errors.As(err, &breaker.ErrOpen{})
+}
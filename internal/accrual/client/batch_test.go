@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOut_GetAccruals(t *testing.T) {
+	calls := 0
+	client := New("test", WithoutRetry(), WithoutBreaker(), WithBatchStrategy(FanOut(1)), withTransport(roundTripFunction(func(req *http.Request) (*http.Response, error) {
+		calls++
+		switch req.URL.Path {
+		case "/api/orders/1":
+			return createResponse(t, http.StatusOK, &responses.Accrual{OrderID: 1, Status: responses.AccrualStatusProcessed}), nil
+		case "/api/orders/2":
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		default:
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Header: map[string][]string{"Retry-After": {"60"}}}, nil
+		}
+	})))
+
+	results := client.GetAccruals(context.Background(), []uint64{1, 2, 3, 4})
+	require.Len(t, results, 4)
+
+	assert.EqualValues(t, 1, results[0].OrderID)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, responses.AccrualStatusProcessed, results[0].Accrual.Status)
+
+	assert.EqualValues(t, 2, results[1].OrderID)
+	require.ErrorIs(t, results[1].Err, ErrOrderNotFound)
+
+	// order 4 is not requested after 429
+	for _, result := range results[2:] {
+		require.ErrorAs(t, result.Err, &ErrTooManyRequests{})
+		assert.Nil(t, result.Accrual)
+	}
+	assert.EqualValues(t, 4, results[3].OrderID)
+	assert.Equal(t, 3, calls)
+}
+
+func TestBatchEndpoint_GetAccruals(t *testing.T) {
+	client := New("test", WithoutRetry(), WithoutCompress(), WithBatchStrategy(BatchEndpoint("api/orders/batch")), withTransport(roundTripFunction(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/api/orders/batch", req.URL.Path)
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `["1","2"]`, string(body))
+
+		return createResponse(t, http.StatusOK, []*responses.Accrual{
+			{OrderID: 1, Status: responses.AccrualStatusProcessing},
+		}), nil
+	})))
+
+	results := client.GetAccruals(context.Background(), []uint64{1, 2})
+	require.Len(t, results, 2)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, responses.AccrualStatusProcessing, results[0].Accrual.Status)
+	assert.EqualValues(t, 2, results[1].OrderID)
+	require.ErrorIs(t, results[1].Err, ErrOrderNotFound)
+}
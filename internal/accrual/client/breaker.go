@@ -0,0 +1,37 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
+	"go.uber.org/zap"
+)
+
+func newBreaker(config *breaker.Config) *breaker.Breaker {
+	if config.IsFailure == nil {
+		config.IsFailure = isFailure
+	}
+	if config.OnStateChange == nil {
+		config.OnStateChange = logStateChange
+	}
+
+	return breaker.New(config)
+}
+
+// isFailure reports whether error means that accrual system is unavailable.
+// Unknown orders, rate limiting and contract violations are answers of a working system
+func isFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrOrderNotFound) &&
+		!errors.As(err, &ErrTooManyRequests{}) &&
+		!isContractViolation(err)
+}
+
+func logStateChange(from, to breaker.State) {
+	logger.Logger.Warn(
+		"accrual circuit breaker state changed",
+		zap.Stringer("from", from),
+		zap.Stringer("to", to),
+	)
+}
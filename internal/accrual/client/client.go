@@ -12,8 +12,11 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/http/retryafter"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/retry"
+	"go.uber.org/zap"
 )
 
 type Client struct {
@@ -62,7 +65,12 @@ func (client *Client) GetAccrual(ctx context.Context, orderID uint64) (*response
 		return nil, err
 	}
 
-	return result.Result().(*responses.Accrual), nil
+	accrual := result.Result().(*responses.Accrual)
+	if err := validateAccrual(orderID, accrual); err != nil {
+		return nil, err
+	}
+
+	return accrual, nil
 }
 
 func (client *Client) createRequest(ctx context.Context) *resty.Request {
@@ -71,10 +79,15 @@ func (client *Client) createRequest(ctx context.Context) *resty.Request {
 
 func (client *Client) doRequest(request *resty.Request, method, url string) (*resty.Response, error) {
 	var result *resty.Response
-	do := func() error {
+	attempt := func() error {
 		var err error
 		result, err = request.Execute(method, url)
 		if err != nil {
+			if result != nil && result.StatusCode() == http.StatusOK {
+				// body can`t be decoded, e.g. negative accrual
+				return newErrInvalidResponse("%s", err)
+			}
+
 			return err
 		}
 
@@ -84,7 +97,10 @@ func (client *Client) doRequest(request *resty.Request, method, url string) (*re
 		case http.StatusNoContent:
 			return ErrOrderNotFound
 		case http.StatusTooManyRequests:
-			return newErrTooManyRequests(retryafter.Parse(result.Header().Get("Retry-After"), client.config.defaultRetryAfter))
+			err := newErrTooManyRequests(retryafter.Parse(result.Header().Get("Retry-After"), client.config.defaultRetryAfter))
+			client.learnQuota(result.String(), err.RetryAfterTime)
+
+			return err
 		case http.StatusInternalServerError:
 			return ErrInternalServerError
 		default:
@@ -92,14 +108,52 @@ func (client *Client) doRequest(request *resty.Request, method, url string) (*re
 		}
 	}
 
+	do := attempt
+	if client.config.breaker != nil {
+		do = func() error {
+			return client.config.breaker.Do(attempt)
+		}
+	}
+
+	if client.config.limiter != nil {
+		paced := do
+		do = func() error {
+			if err := client.config.limiter.Wait(request.Context()); err != nil {
+				return err
+			}
+
+			return paced()
+		}
+	}
+
 	var err error
 	if client.config.retry {
-		err = retry.Retry(time.Second, 5*time.Second, 4, 2, do, func(err error) bool {
+		// too many requests is not retried here, the retriever pauses all lookups until Retry-After
+		err = retry.RetryContext(request.Context(), func(ctx context.Context) error {
+			return do()
+		}, retry.WithFilter(func(err error) bool {
 			return !errors.As(err, &ErrUnexpectedStatus{}) &&
 				!errors.As(err, &ErrTooManyRequests{}) &&
+				!errors.As(err, &breaker.ErrOpen{}) &&
+				!isContractViolation(err) &&
 				!errors.Is(err, context.DeadlineExceeded) &&
 				!errors.Is(err, context.Canceled)
-		})
+		}), retry.WithOnRetry(func(attempt uint64, err error, delay time.Duration) {
+			logger.Logger.Debug(
+				"accrual system request failed, retrying",
+				zap.String("url", url),
+				zap.Uint64("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+		}), retry.WithOnGiveUp(func(attempts uint64, err error) {
+			logger.Logger.Warn(
+				"accrual system request failed, giving up",
+				zap.String("url", url),
+				zap.Uint64("attempts", attempts),
+				zap.Error(err),
+			)
+		}))
 	} else {
 		err = do()
 	}
@@ -12,13 +12,15 @@ import (
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/http/retryafter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestClient_GetAccrual(t *testing.T) {
-	accrual := 1.23
+	accrual := money.MustParse("1.23")
 	someErr := errors.New("some error")
 	tests := []struct {
 		name      string
@@ -150,6 +152,129 @@ func TestClient_GetAccrual(t *testing.T) {
 	}
 }
 
+func TestClient_GetAccrualContract(t *testing.T) {
+	accrual := money.MustParse("1.23")
+	tests := []struct {
+		name        string
+		body        any
+		wantUnknown bool
+	}{
+		{
+			name:        "unknown status",
+			body:        responses.Accrual{OrderID: 1, Status: "CANCELLED"},
+			wantUnknown: true,
+		},
+		{
+			name: "accrual for not processed order",
+			body: responses.Accrual{OrderID: 1, Status: responses.AccrualStatusProcessing, Accrual: &accrual},
+		},
+		{
+			name: "another order",
+			body: responses.Accrual{OrderID: 2, Status: responses.AccrualStatusProcessed, Accrual: &accrual},
+		},
+		{
+			name: "negative accrual",
+			body: map[string]any{"order": "1", "status": responses.AccrualStatusProcessed, "accrual": -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				calls++
+				return createResponse(t, http.StatusOK, tt.body), nil
+			})
+
+			response, err := client.GetAccrual(context.Background(), 1)
+			require.Nil(t, response)
+			if tt.wantUnknown {
+				require.ErrorAs(t, err, &ErrUnknownStatus{})
+			} else {
+				require.ErrorAs(t, err, &ErrInvalidResponse{})
+			}
+			assert.False(t, isFailure(err))
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestClient_GetAccrualBreaker(t *testing.T) {
+	calls := 0
+	client := New("test", WithoutRetry(), WithBreakerConfig(&breaker.Config{FailureThreshold: 2}), withTransport(roundTripFunction(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		}
+
+		return &http.Response{StatusCode: http.StatusInternalServerError}, nil
+	})))
+
+	for _, wantErr := range []error{ErrOrderNotFound, ErrInternalServerError, ErrInternalServerError} {
+		_, err := client.GetAccrual(context.Background(), 1)
+		require.ErrorIs(t, err, wantErr)
+	}
+
+	_, err := client.GetAccrual(context.Background(), 1)
+	require.ErrorAs(t, err, &breaker.ErrOpen{})
+	assert.Equal(t, 3, calls)
+}
+
+func TestClient_GetAccrualLearnQuota(t *testing.T) {
+	client := New("test", WithoutRetry(), WithoutBreaker(), withTransport(roundTripFunction(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header: map[string][]string{
+				"Retry-After":  {"0"},
+				"Content-Type": {"text/plain"},
+			},
+			Body: io.NopCloser(bytes.NewBufferString("No more than 120 requests per minute allowed")),
+		}, nil
+	})))
+
+	_, err := client.GetAccrual(context.Background(), 1)
+	require.ErrorAs(t, err, &ErrTooManyRequests{})
+	assert.EqualValues(t, 120, client.config.limiter.Limit(time.Minute))
+}
+
+func TestParseQuota(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   quota
+		wantOk bool
+	}{
+		{
+			name:   "per minute",
+			body:   "No more than 10 requests per minute allowed",
+			want:   quota{limit: 10, period: time.Minute},
+			wantOk: true,
+		},
+		{
+			name:   "per second",
+			body:   "no more than 1 request per second allowed\n",
+			want:   quota{limit: 1, period: time.Second},
+			wantOk: true,
+		},
+		{
+			name:   "zero",
+			body:   "No more than 0 requests per minute allowed",
+			wantOk: false,
+		},
+		{
+			name:   "unknown body",
+			body:   "slow down",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseQuota(tt.body)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 type roundTripFunction func(req *http.Request) (*http.Response, error)
 
 func (function roundTripFunction) RoundTrip(req *http.Request) (*http.Response, error) {
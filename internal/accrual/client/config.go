@@ -5,9 +5,13 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/tokenbucket"
 )
 
 const defaultRetryAfter = time.Second * 10
+const defaultMaxIdleConnsPerHost = 64
 
 type config struct {
 	baseURL           *url.URL
@@ -15,6 +19,11 @@ type config struct {
 
 	compress bool
 	retry    bool
+	breaker  *breaker.Breaker
+	// limiter paces requests across all goroutines, its quota is learned from 429 responses
+	limiter *tokenbucket.Bucket
+
+	batchStrategy BatchStrategy
 
 	transport http.RoundTripper
 }
@@ -30,7 +39,10 @@ func newConfig(address string, options ...ConfigOption) *config {
 		defaultRetryAfter: defaultRetryAfter,
 		compress:          true,
 		retry:             true,
-		transport:         http.DefaultTransport,
+		breaker:           newBreaker(&breaker.Config{}),
+		limiter:           newLimiter(0, time.Minute),
+		batchStrategy:     FanOut(DefaultFanOutConcurrency),
+		transport:         newTransport(),
 	}
 
 	if strings.Contains(address, "://") {
@@ -59,6 +71,37 @@ func WithoutRetry() ConfigOption {
 	}
 }
 
+func WithBreakerConfig(breakerConfig *breaker.Config) ConfigOption {
+	return func(config *config) {
+		config.breaker = newBreaker(breakerConfig)
+	}
+}
+
+func WithoutBreaker() ConfigOption {
+	return func(config *config) {
+		config.breaker = nil
+	}
+}
+
+// WithRateLimit sets initial quota of requests per period. It is still adapted to the quota from 429 responses
+func WithRateLimit(limit uint64, period time.Duration) ConfigOption {
+	return func(config *config) {
+		config.limiter = newLimiter(limit, period)
+	}
+}
+
+func WithoutRateLimit() ConfigOption {
+	return func(config *config) {
+		config.limiter = nil
+	}
+}
+
+func WithBatchStrategy(strategy BatchStrategy) ConfigOption {
+	return func(config *config) {
+		config.batchStrategy = strategy
+	}
+}
+
 func WithDefaultRetryAfter(retryAfter time.Duration) ConfigOption {
 	return func(config *config) {
 		config.defaultRetryAfter = retryAfter
@@ -70,3 +113,12 @@ func withTransport(transport http.RoundTripper) ConfigOption {
 		config.transport = transport
 	}
 }
+
+// newTransport keeps enough idle connections to reuse them for all fan-out requests to the accrual system
+func newTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	transport.ForceAttemptHTTP2 = true
+
+	return transport
+}
@@ -34,5 +34,29 @@ func newErrTooManyRequests(retryAfter time.Duration) ErrTooManyRequests {
 	}
 }
 
+// ErrUnknownStatus is returned when the accrual system answers with a status out of its contract
+type ErrUnknownStatus struct {
+	Status string
+}
+
+func (err ErrUnknownStatus) Error() string {
+	return fmt.Sprintf("unknown accrual status: %q", err.Status)
+}
+
+// ErrInvalidResponse is returned when the accrual system response breaks its contract
+type ErrInvalidResponse struct {
+	Reason string
+}
+
+func (err ErrInvalidResponse) Error() string {
+	return fmt.Sprintf("invalid accrual response: %s", err.Reason)
+}
+
+func newErrInvalidResponse(format string, args ...any) ErrInvalidResponse {
+	return ErrInvalidResponse{
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
 var ErrOrderNotFound = errors.New("order not found")
 var ErrInternalServerError = errors.New("internal server error")
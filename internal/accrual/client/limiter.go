@@ -0,0 +1,65 @@
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/tokenbucket"
+	"go.uber.org/zap"
+)
+
+// quotaRegexp matches accrual system 429 body: "No more than N requests per minute allowed"
+var quotaRegexp = regexp.MustCompile(`(?i)no more than (\d+) requests? per (second|minute|hour)`)
+
+type quota struct {
+	limit  uint64
+	period time.Duration
+}
+
+func parseQuota(body string) (quota, bool) {
+	matches := quotaRegexp.FindStringSubmatch(body)
+	if matches == nil {
+		return quota{}, false
+	}
+
+	limit, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil || limit == 0 {
+		return quota{}, false
+	}
+
+	period := time.Minute
+	switch strings.ToLower(matches[2]) {
+	case "second":
+		period = time.Second
+	case "hour":
+		period = time.Hour
+	}
+
+	return quota{limit: limit, period: period}, true
+}
+
+// learnQuota adapts limiter to the quota from 429 response and pauses it until Retry-After
+func (client *Client) learnQuota(body string, retryAfterTime time.Time) {
+	limiter := client.config.limiter
+	if limiter == nil {
+		return
+	}
+
+	if quota, ok := parseQuota(body); ok && limiter.Limit(quota.period) != quota.limit {
+		limiter.SetLimit(quota.limit, quota.period, 1)
+		logger.Logger.Info(
+			"accrual system quota learned",
+			zap.Uint64("limit", quota.limit),
+			zap.Duration("period", quota.period),
+		)
+	}
+
+	limiter.Pause(retryAfterTime)
+}
+
+func newLimiter(limit uint64, period time.Duration) *tokenbucket.Bucket {
+	return tokenbucket.New(limit, period, 1)
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+)
+
+var knownStatuses = map[string]struct{}{
+	responses.AccrualStatusRegistered: {},
+	responses.AccrualStatusProcessing: {},
+	responses.AccrualStatusInvalid:    {},
+	responses.AccrualStatusProcessed:  {},
+}
+
+// validateAccrual checks the response against the accrual system contract.
+// Negative accruals are rejected while decoding, since money.Amount is unsigned
+func validateAccrual(orderID uint64, accrual *responses.Accrual) error {
+	if accrual.OrderID != orderID {
+		return newErrInvalidResponse("order %d requested, but %d received", orderID, accrual.OrderID)
+	}
+	if _, ok := knownStatuses[accrual.Status]; !ok {
+		return ErrUnknownStatus{Status: accrual.Status}
+	}
+	if accrual.Accrual != nil && accrual.Status != responses.AccrualStatusProcessed {
+		return newErrInvalidResponse("order %d has accrual in status %s", orderID, accrual.Status)
+	}
+
+	return nil
+}
+
+// isContractViolation reports whether error means that the response is received, but can`t be used
+func isContractViolation(err error) bool {
+	return errors.As(err, &ErrUnknownStatus{}) || errors.As(err, &ErrInvalidResponse{})
+}
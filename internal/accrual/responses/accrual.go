@@ -1,5 +1,7 @@
 package responses
 
+import "github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+
 const (
 	AccrualStatusRegistered string = "REGISTERED"
 	AccrualStatusProcessing string = "PROCESSING"
@@ -8,7 +10,7 @@ const (
 )
 
 type Accrual struct {
-	OrderID uint64   `json:"order,string"`
-	Status  string   `json:"status"`
-	Accrual *float64 `json:"accrual"`
+	OrderID uint64        `json:"order,string"`
+	Status  string        `json:"status"`
+	Accrual *money.Amount `json:"accrual"`
 }
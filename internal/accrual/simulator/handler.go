@@ -0,0 +1,188 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/middleware"
+)
+
+// Handler simulates the accrual system: GET /api/orders/{number} answers according to the rule
+// with the longest matching prefix, POST /api/orders/batch answers for several orders at once
+type Handler struct {
+	router chi.Router
+	rules  []Rule
+	mutex  sync.Mutex
+	calls  map[string]uint64
+}
+
+func NewHandler(rules ...Rule) *Handler {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	handler := &Handler{
+		rules: rules,
+		calls: make(map[string]uint64),
+	}
+
+	router := chi.NewRouter()
+	router.Use(middleware.Decompress(&middleware.DecompressConfig{}))
+	router.Get("/api/orders/{number}", handler.getOrder)
+	router.Post("/api/orders/batch", handler.getOrders)
+	handler.router = router
+
+	return handler
+}
+
+func (handler *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	handler.router.ServeHTTP(writer, request)
+}
+
+// Calls returns count of requests of the order
+func (handler *Handler) Calls(number string) uint64 {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	return handler.calls[number]
+}
+
+func (handler *Handler) getOrder(writer http.ResponseWriter, request *http.Request) {
+	number := chi.URLParam(request, "number")
+	if _, err := strconv.ParseUint(number, 10, 64); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, latency := handler.next(number)
+	if !sleep(request, latency) {
+		return
+	}
+
+	if response.StatusCode != http.StatusOK {
+		writeError(writer, response)
+		return
+	}
+
+	writeJSON(writer, accrual(number, response))
+}
+
+func (handler *Handler) getOrders(writer http.ResponseWriter, request *http.Request) {
+	numbers := make([]string, 0)
+	if err := json.NewDecoder(request.Body).Decode(&numbers); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var latency time.Duration
+	accruals := make([]*responses.Accrual, 0, len(numbers))
+	for _, number := range numbers {
+		if _, err := strconv.ParseUint(number, 10, 64); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		response, orderLatency := handler.next(number)
+		latency = max(latency, orderLatency)
+
+		switch response.StatusCode {
+		case http.StatusOK:
+			accruals = append(accruals, accrual(number, response))
+		case http.StatusNoContent:
+			// not found orders are omitted
+		default:
+			// any error fails whole batch
+			if sleep(request, latency) {
+				writeError(writer, response)
+			}
+			return
+		}
+	}
+
+	if sleep(request, latency) {
+		writeJSON(writer, accruals)
+	}
+}
+
+// next counts the call and returns the response of the matching rule
+func (handler *Handler) next(number string) (Response, time.Duration) {
+	rule := handler.match(number)
+
+	handler.mutex.Lock()
+	call := handler.calls[number]
+	handler.calls[number]++
+	handler.mutex.Unlock()
+
+	return rule.Scenario.response(call), rule.Latency
+}
+
+func (handler *Handler) match(number string) Rule {
+	matched := Rule{Scenario: NotFound()}
+	matchedLength := -1
+	for _, rule := range handler.rules {
+		if len(rule.Prefix) > matchedLength && strings.HasPrefix(number, rule.Prefix) {
+			matched = rule
+			matchedLength = len(rule.Prefix)
+		}
+	}
+
+	return matched
+}
+
+// sleep returns false if request was cancelled while sleeping
+func sleep(request *http.Request, latency time.Duration) bool {
+	if latency <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+
+	select {
+	case <-request.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func accrual(number string, response Response) *responses.Accrual {
+	orderID, _ := strconv.ParseUint(number, 10, 64)
+
+	return &responses.Accrual{
+		OrderID: orderID,
+		Status:  response.Status,
+		Accrual: response.Accrual,
+	}
+}
+
+func writeError(writer http.ResponseWriter, response Response) {
+	if response.StatusCode == http.StatusTooManyRequests {
+		writer.Header().Set("Retry-After", strconv.FormatInt(int64(response.RetryAfter.Seconds()), 10))
+		writer.Header().Set("Content-Type", "text/plain")
+		writer.WriteHeader(response.StatusCode)
+		_, _ = fmt.Fprintf(writer, "No more than %d requests per minute allowed", response.RateLimit)
+
+		return
+	}
+
+	writer.WriteHeader(response.StatusCode)
+}
+
+func writeJSON(writer http.ResponseWriter, response any) {
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_, _ = writer.Write(jsonResponse)
+}
@@ -0,0 +1,135 @@
+package simulator
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/client"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, rules ...Rule) (*client.Client, *Handler) {
+	t.Helper()
+
+	handler := NewHandler(rules...)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return client.New(server.URL, client.WithoutRetry(), client.WithoutBreaker(), client.WithoutRateLimit()), handler
+}
+
+func TestHandler_Progression(t *testing.T) {
+	accrualClient, handler := newTestClient(t, Rule{Prefix: "1", Scenario: Progression(money.MustParse("12.5"), 2)})
+
+	statuses := make([]string, 0, 6)
+	for range 6 {
+		accrual, err := accrualClient.GetAccrual(context.Background(), 123)
+		require.NoError(t, err)
+		assert.EqualValues(t, 123, accrual.OrderID)
+		statuses = append(statuses, accrual.Status)
+		if accrual.Status == responses.AccrualStatusProcessed {
+			assert.Equal(t, money.MustParse("12.5"), *accrual.Accrual)
+		} else {
+			assert.Nil(t, accrual.Accrual)
+		}
+	}
+
+	assert.Equal(t, []string{
+		responses.AccrualStatusRegistered,
+		responses.AccrualStatusRegistered,
+		responses.AccrualStatusProcessing,
+		responses.AccrualStatusProcessing,
+		responses.AccrualStatusProcessed,
+		responses.AccrualStatusProcessed,
+	}, statuses)
+	assert.EqualValues(t, 6, handler.Calls("123"))
+}
+
+func TestHandler_Rules(t *testing.T) {
+	accrualClient, _ := newTestClient(t)
+
+	accrual, err := accrualClient.GetAccrual(context.Background(), 9001)
+	require.NoError(t, err)
+	assert.Equal(t, responses.AccrualStatusInvalid, accrual.Status)
+
+	_, err = accrualClient.GetAccrual(context.Background(), 8001)
+	require.ErrorIs(t, err, client.ErrOrderNotFound)
+
+	tooManyRequests := client.ErrTooManyRequests{}
+	_, err = accrualClient.GetAccrual(context.Background(), 7001)
+	require.ErrorAs(t, err, &tooManyRequests)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), tooManyRequests.RetryAfterTime, time.Second*2)
+
+	_, err = accrualClient.GetAccrual(context.Background(), 6001)
+	require.ErrorIs(t, err, client.ErrInternalServerError)
+
+	accrual, err = accrualClient.GetAccrual(context.Background(), 1001)
+	require.NoError(t, err)
+	assert.Equal(t, responses.AccrualStatusRegistered, accrual.Status)
+}
+
+func TestHandler_EmptyScenario(t *testing.T) {
+	accrualClient, _ := newTestClient(t, Rule{Scenario: Scenario{Steps: 2}})
+
+	for range 3 {
+		_, err := accrualClient.GetAccrual(context.Background(), 1)
+		require.ErrorIs(t, err, client.ErrOrderNotFound)
+	}
+}
+
+func TestHandler_Latency(t *testing.T) {
+	accrualClient, _ := newTestClient(t, Rule{Scenario: Invalid(), Latency: time.Millisecond * 50})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err := accrualClient.GetAccrual(ctx, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	start := time.Now()
+	_, err = accrualClient.GetAccrual(context.Background(), 1)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*50)
+}
+
+func TestHandler_Batch(t *testing.T) {
+	handler := NewHandler(
+		Rule{Prefix: "1", Scenario: Invalid()},
+		Rule{Prefix: "2", Scenario: NotFound()},
+		Rule{Prefix: "3", Scenario: InternalServerError()},
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	batchClient := client.New(server.URL, client.WithoutRetry(), client.WithoutBreaker(), client.WithoutRateLimit(), client.WithBatchStrategy(client.BatchEndpoint("api/orders/batch")))
+
+	results := batchClient.GetAccruals(context.Background(), []uint64{11, 21})
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, responses.AccrualStatusInvalid, results[0].Accrual.Status)
+	require.ErrorIs(t, results[1].Err, client.ErrOrderNotFound)
+
+	for _, result := range batchClient.GetAccruals(context.Background(), []uint64{11, 31}) {
+		require.ErrorIs(t, result.Err, client.ErrInternalServerError)
+	}
+}
+
+func TestDecodeRules(t *testing.T) {
+	rules, err := DecodeRules(strings.NewReader(`[
+		{"prefix": "5", "scenario": "progression", "accrual": 500, "steps": 2, "latency": "3s"},
+		{"prefix": "7", "scenario": "too-many-requests", "retry_after": "1m", "rate_limit": 10}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, Rule{Prefix: "5", Scenario: Progression(money.MustParse("500"), 2), Latency: time.Second * 3}, rules[0])
+	assert.Equal(t, Rule{Prefix: "7", Scenario: TooManyRequests(time.Minute, 10)}, rules[1])
+
+	_, err = DecodeRules(strings.NewReader(`[{"scenario": "unknown"}]`))
+	require.ErrorAs(t, err, &ErrUnknownScenario{})
+
+	_, err = DecodeRules(strings.NewReader(`[{"scenario": "invalid", "latency": "soon"}]`))
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+)
+
+// Rule applies scenario to orders whose number starts with Prefix. Empty prefix matches any order
+type Rule struct {
+	Prefix   string
+	Scenario Scenario
+	// Latency is added before every response
+	Latency time.Duration
+}
+
+type ErrUnknownScenario struct {
+	Name string
+}
+
+func (err ErrUnknownScenario) Error() string {
+	return fmt.Sprintf("unknown scenario: %s", err.Name)
+}
+
+// DefaultRules are used when no rules are configured
+func DefaultRules() []Rule {
+	return []Rule{
+		{Prefix: "9", Scenario: Invalid()},
+		{Prefix: "8", Scenario: NotFound()},
+		{Prefix: "7", Scenario: TooManyRequests(time.Minute, 60)},
+		{Prefix: "6", Scenario: InternalServerError()},
+		{Prefix: "5", Scenario: Progression(money.MustParse("500"), 1), Latency: time.Second * 3},
+		{Prefix: "", Scenario: Progression(money.MustParse("100"), 1)},
+	}
+}
+
+// jsonRule is a rule as it is described in JSON, e.g.
+// {"prefix": "5", "scenario": "progression", "accrual": 500, "steps": 2, "latency": "3s"}
+type jsonRule struct {
+	Prefix     string       `json:"prefix"`
+	Scenario   string       `json:"scenario"`
+	Accrual    money.Amount `json:"accrual"`
+	Steps      uint64       `json:"steps"`
+	RetryAfter string       `json:"retry_after"`
+	RateLimit  uint64       `json:"rate_limit"`
+	Latency    string       `json:"latency"`
+}
+
+// DecodeRules reads JSON array of rules
+func DecodeRules(reader io.Reader) ([]Rule, error) {
+	decoded := make([]jsonRule, 0)
+	if err := json.NewDecoder(reader).Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0, len(decoded))
+	for _, jsonRule := range decoded {
+		rule, err := jsonRule.rule()
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", jsonRule.Prefix, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (jsonRule jsonRule) rule() (Rule, error) {
+	latency, err := parseDuration(jsonRule.Latency)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rule := Rule{Prefix: jsonRule.Prefix, Latency: latency}
+	switch strings.ToLower(jsonRule.Scenario) {
+	case "progression":
+		rule.Scenario = Progression(jsonRule.Accrual, jsonRule.Steps)
+	case "invalid":
+		rule.Scenario = Invalid()
+	case "not-found":
+		rule.Scenario = NotFound()
+	case "too-many-requests":
+		retryAfter, err := parseDuration(jsonRule.RetryAfter)
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.Scenario = TooManyRequests(retryAfter, jsonRule.RateLimit)
+	case "internal-server-error":
+		rule.Scenario = InternalServerError()
+	default:
+		return Rule{}, ErrUnknownScenario{Name: jsonRule.Scenario}
+	}
+
+	return rule, nil
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,68 @@
+package simulator
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+)
+
+// Response is a single answer of the accrual system
+type Response struct {
+	// StatusCode is HTTP status, accrual body is written only for 200
+	StatusCode int
+	Status     string
+	Accrual    *money.Amount
+	// RetryAfter and RateLimit are used only for 429
+	RetryAfter time.Duration
+	RateLimit  uint64
+}
+
+// Scenario is a sequence of responses to consecutive requests of the same order.
+// Every response is repeated Steps times, the last one is repeated forever.
+// Scenario without responses answers as NotFound
+type Scenario struct {
+	Responses []Response
+	Steps     uint64
+}
+
+func (scenario Scenario) response(call uint64) Response {
+	if len(scenario.Responses) == 0 {
+		return Response{StatusCode: http.StatusNoContent}
+	}
+
+	steps := max(scenario.Steps, 1)
+	index := min(call/steps, uint64(len(scenario.Responses)-1))
+
+	return scenario.Responses[index]
+}
+
+// Progression moves order from REGISTERED through PROCESSING to PROCESSED with the specified accrual
+func Progression(accrual money.Amount, steps uint64) Scenario {
+	return Scenario{
+		Responses: []Response{
+			{StatusCode: http.StatusOK, Status: responses.AccrualStatusRegistered},
+			{StatusCode: http.StatusOK, Status: responses.AccrualStatusProcessing},
+			{StatusCode: http.StatusOK, Status: responses.AccrualStatusProcessed, Accrual: &accrual},
+		},
+		Steps: steps,
+	}
+}
+
+func Invalid() Scenario {
+	return Scenario{Responses: []Response{{StatusCode: http.StatusOK, Status: responses.AccrualStatusInvalid}}}
+}
+
+// NotFound answers 204 as for order not registered in the accrual system
+func NotFound() Scenario {
+	return Scenario{Responses: []Response{{StatusCode: http.StatusNoContent}}}
+}
+
+func TooManyRequests(retryAfter time.Duration, rateLimit uint64) Scenario {
+	return Scenario{Responses: []Response{{StatusCode: http.StatusTooManyRequests, RetryAfter: retryAfter, RateLimit: rateLimit}}}
+}
+
+func InternalServerError() Scenario {
+	return Scenario{Responses: []Response{{StatusCode: http.StatusInternalServerError}}}
+}
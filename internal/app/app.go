@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/client"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/config"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/admin"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/auth"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/balance"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/order"
@@ -21,14 +23,21 @@ import (
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/jwt"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	reloadProcessor "github.com/m1khal3v/gophermart-loyalty-service/internal/processor/reload"
 	retrieverProcessor "github.com/m1khal3v/gophermart-loyalty-service/internal/processor/retriever"
 	routerProcessor "github.com/m1khal3v/gophermart-loyalty-service/internal/processor/router"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/schedule"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/batch"
 	invalidProcessor "github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/invalid"
 	processedProcessor "github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/processed"
 	processingProcessor "github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/processing"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/repository"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/router"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/server"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/phc"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/lockout"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/middleware"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/pprof"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	"go.uber.org/zap"
@@ -38,12 +47,17 @@ import (
 
 type app struct {
 	config              *config.Config
+	db                  *sql.DB
 	server              *http.Server
+	reloadProcessor     *reloadProcessor.Processor
 	retrieverProcessor  *retrieverProcessor.Processor
 	routerProcessor     *routerProcessor.Processor
 	processingProcessor *processingProcessor.Processor
 	invalidProcessor    *invalidProcessor.Processor
 	processedProcessor  *processedProcessor.Processor
+	// queues are kept to report work left for the next start on shutdown
+	orderQueues   []*queue.Queue[uint64]
+	accrualQueues []*queue.Queue[*responses.Accrual]
 }
 
 // New function acts as the simplest configuration-based dependency injector
@@ -58,79 +72,200 @@ func New(config *config.Config) (*app, error) {
 	if err != nil {
 		return nil, err
 	}
+	db, err := gorm.DB()
+	if err != nil {
+		return nil, err
+	}
 	userRepository := repository.NewUserRepository(gorm)
 	withdrawalRepository := repository.NewWithdrawalRepository(gorm)
 	orderRepository := repository.NewOrderRepository(gorm)
 	userWithdrawalRepository := repository.NewUserWithdrawalRepository(gorm)
 	userOrderRepository := repository.NewUserOrderRepository(gorm)
+	deadLetterRepository := repository.NewDeadLetterRepository(gorm)
 
 	// Managers
-	userManager := manager.NewUserManager(userRepository, jwt)
+	userManager := manager.NewUserManager(userRepository, jwt, phc.DefaultPolicy, lockout.New(&lockout.Config{
+		Threshold:    config.LoginLockoutThreshold,
+		BaseDuration: &config.LoginLockoutDuration,
+		MaxDuration:  &config.LoginLockoutMax,
+	}))
 	withdrawalManager := manager.NewWithdrawalManager(withdrawalRepository)
 	orderManager := manager.NewOrderManager(orderRepository)
 	userWithdrawalManager := manager.NewUserWithdrawalManager(userWithdrawalRepository)
 	userOrderManager := manager.NewUserOrderManager(userOrderRepository)
+	deadLetterManager := manager.NewDeadLetterManager(deadLetterRepository)
 
 	// Queue
+	// order is already stored with not final status, so overflowed order is spilled to the database
+	// and loaded again by the reload processor once the queue has room
+	var reload *reloadProcessor.Processor
+	orderDeduplicator := queue.NewDeduplicator[uint64]()
+	orderQueueOptions := []queue.Option[uint64]{
+		queue.WithOverflowPolicy(queue.Spill(func(orderID uint64) error {
+			return reload.Spill(orderID)
+		})),
+		// order is queued once while it is pending in any lane or requested from the accrual system
+		queue.WithDeduplicator(orderDeduplicator, func(orderID uint64) uint64 {
+			return orderID
+		}),
+	}
+	// newly registered orders are looked up before the repeated ones, which still get their share
+	freshQueue := queue.New[uint64](10000, orderQueueOptions...)
+	retryQueue := queue.New[uint64](10000, orderQueueOptions...)
+	orderQueue := queue.NewPriority(
+		&queue.Lane[uint64]{Queue: freshQueue, Weight: config.OrderFreshWeight},
+		&queue.Lane[uint64]{Queue: retryQueue, Weight: config.OrderRetryWeight},
+	)
+	reload = reloadProcessor.NewProcessor(orderRepository, retryQueue, &reloadProcessor.Config{
+		Interval: &config.OrderReloadInterval,
+	})
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
-	unprocessedIDs, err := orderRepository.FindUnprocessedIDs(ctx)
-	if err != nil {
+	if err := reload.Load(ctx); err != nil {
 		return nil, err
 	}
-	orderQueue := queue.New[uint64](10000)
-	for unprocessedID := range unprocessedIDs {
-		orderQueue.Push(unprocessedID)
+	// accrual is leased while it is routed or updated and queued again if the lease is not released in time
+	accrualQueueOptions := []queue.Option[*responses.Accrual]{
+		queue.WithKey(func(accrual *responses.Accrual) uint64 {
+			return accrual.OrderID
+		}),
+		queue.WithVisibilityTimeout[*responses.Accrual](config.UpdateVisibilityTimeout),
 	}
-	routerQueue := queue.New[*responses.Accrual](10000)
-	invalidQueue := queue.New[*responses.Accrual](10000)
-	processingQueue := queue.New[*responses.Accrual](10000)
-	processedQueue := queue.New[*responses.Accrual](10000)
+	routerQueue := queue.New[*responses.Accrual](10000, accrualQueueOptions...)
+	invalidQueue := queue.New[*responses.Accrual](10000, accrualQueueOptions...)
+	processingQueue := queue.New[*responses.Accrual](10000, accrualQueueOptions...)
+	processedQueue := queue.New[*responses.Accrual](10000, accrualQueueOptions...)
+
+	// Polling schedule of not final orders
+	schedule := schedule.New(orderRepository, &schedule.Config{
+		Policies: map[string]*schedule.Policy{
+			responses.AccrualStatusRegistered: {
+				BaseDelay:  &config.PollRegisteredBaseDelay,
+				MaxDelay:   &config.PollRegisteredMaxDelay,
+				Multiplier: config.PollMultiplier,
+				Jitter:     &config.PollJitter,
+			},
+			responses.AccrualStatusProcessing: {
+				BaseDelay:  &config.PollProcessingBaseDelay,
+				MaxDelay:   &config.PollProcessingMaxDelay,
+				Multiplier: config.PollMultiplier,
+				Jitter:     &config.PollJitter,
+			},
+		},
+		Horizon: &config.PollHorizon,
+	})
+
+	// Processors
+	accrualRouter := routerProcessor.NewProcessor(retryQueue, routerQueue, processingQueue, invalidQueue, processedQueue, schedule, deadLetterManager, &routerProcessor.Config{
+		Concurrency: config.RouterConcurrency,
+	})
+	processing := processingProcessor.NewProcessor(retryQueue, processingQueue, orderManager, schedule, deadLetterManager, &batch.Config{
+		Concurrency: config.ProcessingConcurrency,
+		BatchSize:   config.UpdateBatchSize,
+		MaxAttempts: config.UpdateMaxAttempts,
+		Linger:      &config.UpdateLinger,
+	})
+	invalid := invalidProcessor.NewProcessor(invalidQueue, orderManager, deadLetterManager, &batch.Config{
+		Concurrency: config.InvalidConcurrency,
+		BatchSize:   config.UpdateBatchSize,
+		MaxAttempts: config.UpdateMaxAttempts,
+		Linger:      &config.UpdateLinger,
+	})
+	processed := processedProcessor.NewProcessor(processedQueue, userOrderManager, deadLetterManager, &batch.Config{
+		Concurrency: config.ProcessedConcurrency,
+		BatchSize:   config.UpdateBatchSize,
+		MaxAttempts: config.UpdateMaxAttempts,
+		Linger:      &config.UpdateLinger,
+	})
 
 	// Router
 	authRoutes := auth.NewContainer(userManager)
-	orderRoutes := order.NewContainer(orderManager, orderQueue)
+	orderRoutes := order.NewContainer(orderManager, freshQueue)
 	balanceRoutes := balance.NewContainer(userManager, userWithdrawalManager)
 	withdrawalRoutes := withdrawal.NewContainer(withdrawalManager)
-	router := router.New(config.AppEnv == "prod", authRoutes, orderRoutes, balanceRoutes, withdrawalRoutes, jwt)
+	adminRoutes := admin.NewContainer(deadLetterManager, schedule, freshQueue, map[string]admin.ConcurrencyLimiter{
+		"router":     accrualRouter,
+		"processing": processing,
+		"invalid":    invalid,
+		"processed":  processed,
+	})
+	router := router.New(
+		config.AppEnv == "prod",
+		authRoutes,
+		orderRoutes,
+		balanceRoutes,
+		withdrawalRoutes,
+		adminRoutes,
+		jwt,
+		userManager,
+		config.AdminToken,
+		&middleware.DecompressConfig{
+			MaxCompressedSize:   config.MaxRequestBodySize,
+			MaxDecompressedSize: config.MaxDecompressedBodySize,
+			MaxRatio:            config.MaxDecompressionRatio,
+		},
+	)
 
 	// Accrual
-	client := client.New(config.AccrualSystemAddress)
+	batchStrategy := client.FanOut(config.AccrualFanOutConcurrency)
+	if config.AccrualBatchEndpoint != "" {
+		batchStrategy = client.BatchEndpoint(config.AccrualBatchEndpoint)
+	}
+	client := client.New(
+		config.AccrualSystemAddress,
+		client.WithBreakerConfig(&breaker.Config{
+			FailureThreshold: config.AccrualBreakerThreshold,
+			OpenTimeout:      &config.AccrualBreakerTimeout,
+		}),
+		client.WithRateLimit(config.AccrualRateLimit, time.Minute),
+		client.WithBatchStrategy(batchStrategy),
+	)
 
 	return &app{
-		config: config,
-		server: server.New(config.RunAddress, router),
-		retrieverProcessor: retrieverProcessor.NewProcessor(client, orderQueue, routerQueue, &retrieverProcessor.Config{
-			Concurrency: config.RetrieverConcurrency,
-		}),
-		routerProcessor: routerProcessor.NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, &routerProcessor.Config{
-			Concurrency: config.RouterConcurrency,
-		}),
-		processingProcessor: processingProcessor.NewProcessor(orderQueue, processingQueue, orderManager, &processingProcessor.Config{
-			Concurrency: config.ProcessingConcurrency,
-			BatchSize:   config.UpdateBatchSize,
-		}),
-		invalidProcessor: invalidProcessor.NewProcessor(invalidQueue, orderManager, &invalidProcessor.Config{
-			Concurrency: config.InvalidConcurrency,
-			BatchSize:   config.UpdateBatchSize,
-		}),
-		processedProcessor: processedProcessor.NewProcessor(processedQueue, userOrderManager, &processedProcessor.Config{
-			Concurrency: config.ProcessedConcurrency,
-			BatchSize:   config.UpdateBatchSize,
+		config:          config,
+		db:              db,
+		server:          server.New(config.RunAddress, router),
+		orderQueues:     []*queue.Queue[uint64]{freshQueue, retryQueue},
+		accrualQueues:   []*queue.Queue[*responses.Accrual]{routerQueue, processingQueue, invalidQueue, processedQueue},
+		reloadProcessor: reload,
+		retrieverProcessor: retrieverProcessor.NewProcessor(client, deadLetterManager, schedule, orderQueue, retryQueue, routerQueue, &retrieverProcessor.Config{
+			Concurrency:         config.RetrieverConcurrency,
+			MinConcurrency:      config.RetrieverMinConcurrency,
+			LatencyThreshold:    &config.RetrieverLatencyThreshold,
+			BatchSize:           config.RetrieverBatchSize,
+			Linger:              &config.RetrieverLinger,
+			NotFoundMaxAttempts: config.RetrieverNotFoundMaxAttempts,
+			NotFoundMaxDuration: &config.RetrieverNotFoundMaxDuration,
 		}),
+		routerProcessor:     accrualRouter,
+		processingProcessor: processing,
+		invalidProcessor:    invalid,
+		processedProcessor:  processed,
 	}, nil
 }
 
 func (app *app) Run() {
-	ctx := context.Background()
-	suspendCtx, suspendCancel := signal.NotifyContext(ctx, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer cancel()
+
+	// error is already logged
+	_ = app.RunContext(ctx)
+}
+
+// RunContext runs the application until ctx is done or any of its goroutines fails.
+// Returns error of the failed goroutine, if any
+func (app *app) RunContext(ctx context.Context) error {
+	suspendCtx, suspendCancel := context.WithCancel(ctx)
 	defer suspendCancel()
+	// work in flight is cancelled only if it is not finished within shutdown timeout
+	workCtx, workCancel := context.WithCancel(context.Background())
+	defer workCancel()
 
-	errCtx, errCancel := context.WithCancelCause(ctx)
+	errCtx, errCancel := context.WithCancelCause(context.Background())
 	defer errCancel(nil)
 
 	var wg sync.WaitGroup
-	wg.Add(8)
+	wg.Add(9)
 	go func() {
 		defer wg.Done()
 		if err := app.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
@@ -139,31 +274,37 @@ func (app *app) Run() {
 	}()
 	go func() {
 		defer wg.Done()
-		if err := app.retrieverProcessor.Process(suspendCtx); !errors.Is(err, context.Canceled) {
+		if err := app.reloadProcessor.Process(suspendCtx); !errors.Is(err, context.Canceled) {
+			errCancel(fmt.Errorf("reload processor error: %w", err))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := app.retrieverProcessor.Process(suspendCtx, workCtx); !errors.Is(err, context.Canceled) {
 			errCancel(fmt.Errorf("retriever processor error: %w", err))
 		}
 	}()
 	go func() {
 		defer wg.Done()
-		if err := app.routerProcessor.Process(suspendCtx); !errors.Is(err, context.Canceled) {
+		if err := app.routerProcessor.Process(suspendCtx, workCtx); !errors.Is(err, context.Canceled) {
 			errCancel(fmt.Errorf("router processor error: %w", err))
 		}
 	}()
 	go func() {
 		defer wg.Done()
-		if err := app.processingProcessor.Process(suspendCtx); !errors.Is(err, context.Canceled) {
+		if err := app.processingProcessor.Process(suspendCtx, workCtx); !errors.Is(err, context.Canceled) {
 			errCancel(fmt.Errorf("processing processor error: %w", err))
 		}
 	}()
 	go func() {
 		defer wg.Done()
-		if err := app.invalidProcessor.Process(suspendCtx); !errors.Is(err, context.Canceled) {
+		if err := app.invalidProcessor.Process(suspendCtx, workCtx); !errors.Is(err, context.Canceled) {
 			errCancel(fmt.Errorf("invalid processor error: %w", err))
 		}
 	}()
 	go func() {
 		defer wg.Done()
-		if err := app.processedProcessor.Process(suspendCtx); !errors.Is(err, context.Canceled) {
+		if err := app.processedProcessor.Process(suspendCtx, workCtx); !errors.Is(err, context.Canceled) {
 			errCancel(fmt.Errorf("processed processor error: %w", err))
 		}
 	}()
@@ -200,6 +341,8 @@ func (app *app) Run() {
 
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), app.config.ShutdownTimeout)
 	defer cancel()
+	stopWork := context.AfterFunc(timeoutCtx, workCancel)
+	defer stopWork()
 
 	logger.Logger.Info("Trying to shutdown server gracefully...")
 	if err := app.server.Shutdown(timeoutCtx); err != nil {
@@ -210,6 +353,59 @@ func (app *app) Run() {
 
 	logger.Logger.Info("Waiting for all goroutines to finish...")
 	wg.Wait()
+
+	logger.Logger.Info("Flushing queued updates...")
+	app.flush(timeoutCtx)
+	app.logLeftovers()
+
+	if err := app.db.Close(); err != nil {
+		logger.Logger.Error("Failed to close database connection", zap.Error(err))
+	}
+
+	return context.Cause(errCtx)
+}
+
+// flush writes accruals left in the queues to the database,
+// routed accruals are flushed after the router, so they are not lost between the stages
+func (app *app) flush(ctx context.Context) {
+	if err := app.routerProcessor.Flush(ctx); err != nil {
+		logger.Logger.Error("Failed to flush router queue", zap.Error(err))
+	}
+
+	var wg sync.WaitGroup
+	flushers := map[string]func(ctx context.Context) error{
+		"processing": app.processingProcessor.Flush,
+		"invalid":    app.invalidProcessor.Flush,
+		"processed":  app.processedProcessor.Flush,
+	}
+	wg.Add(len(flushers))
+	for name, flush := range flushers {
+		go func() {
+			defer wg.Done()
+			if err := flush(ctx); err != nil {
+				logger.Logger.Error("Failed to flush queue", zap.String("queue", name), zap.Error(err))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// logLeftovers reports work which is not finished, not final orders are loaded from the database on the next start
+func (app *app) logLeftovers() {
+	orders := uint64(0)
+	for _, queue := range app.orderQueues {
+		orders += queue.Count() + queue.DelayedCount()
+	}
+	accruals := uint64(0)
+	for _, queue := range app.accrualQueues {
+		accruals += queue.Count() + queue.DelayedCount()
+	}
+
+	logger.Logger.Info(
+		"Work left for the next start",
+		zap.Uint64("orders", orders),
+		zap.Uint64("accruals", accruals),
+	)
 }
 
 func (app *app) hookSignal(ctx context.Context, target syscall.Signal, function func()) {
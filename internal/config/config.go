@@ -8,28 +8,60 @@ import (
 )
 
 type Config struct {
-	AppEnv                string        `env:"APP_ENV"`
-	AppSecret             string        `env:"APP_SECRET"`
-	RunAddress            string        `env:"RUN_ADDRESS"`
-	AccrualSystemAddress  string        `env:"ACCRUAL_SYSTEM_ADDRESS"`
-	DatabaseURI           string        `env:"DATABASE_URI"`
-	RetrieverConcurrency  uint64        `env:"RETRIEVER_CONCURRENCY"`
-	RouterConcurrency     uint64        `env:"ROUTER_CONCURRENCY"`
-	ProcessingConcurrency uint64        `env:"PROCESSING_CONCURRENCY"`
-	InvalidConcurrency    uint64        `env:"INVALID_CONCURRENCY"`
-	ProcessedConcurrency  uint64        `env:"PROCESSED_CONCURRENCY"`
-	UpdateBatchSize       uint64        `env:"UPDATE_BATCH_SIZE"`
-	LogLevel              string        `env:"LOG_LEVEL"`
-	CPUProfileFile        string        `env:"CPU_PROFILE_FILE"`
-	CPUProfileDuration    time.Duration `env:"CPU_PROFILE_DURATION"`
-	MemProfileFile        string        `env:"MEM_PROFILE_FILE"`
-	ShutdownTimeout       time.Duration `env:"SHUTDOWN_TIMEOUT"`
+	AppEnv                       string        `env:"APP_ENV"`
+	AppSecret                    string        `env:"APP_SECRET"`
+	AdminToken                   string        `env:"ADMIN_TOKEN"`
+	RunAddress                   string        `env:"RUN_ADDRESS"`
+	AccrualSystemAddress         string        `env:"ACCRUAL_SYSTEM_ADDRESS"`
+	DatabaseURI                  string        `env:"DATABASE_URI"`
+	RetrieverConcurrency         uint64        `env:"RETRIEVER_CONCURRENCY"`
+	RouterConcurrency            uint64        `env:"ROUTER_CONCURRENCY"`
+	ProcessingConcurrency        uint64        `env:"PROCESSING_CONCURRENCY"`
+	InvalidConcurrency           uint64        `env:"INVALID_CONCURRENCY"`
+	ProcessedConcurrency         uint64        `env:"PROCESSED_CONCURRENCY"`
+	UpdateBatchSize              uint64        `env:"UPDATE_BATCH_SIZE"`
+	UpdateMaxAttempts            uint64        `env:"UPDATE_MAX_ATTEMPTS"`
+	LogLevel                     string        `env:"LOG_LEVEL"`
+	CPUProfileFile               string        `env:"CPU_PROFILE_FILE"`
+	CPUProfileDuration           time.Duration `env:"CPU_PROFILE_DURATION"`
+	MemProfileFile               string        `env:"MEM_PROFILE_FILE"`
+	ShutdownTimeout              time.Duration `env:"SHUTDOWN_TIMEOUT"`
+	RetrieverMinConcurrency      uint64        `env:"RETRIEVER_MIN_CONCURRENCY"`
+	RetrieverLatencyThreshold    time.Duration `env:"RETRIEVER_LATENCY_THRESHOLD"`
+	AccrualBreakerThreshold      uint64        `env:"ACCRUAL_BREAKER_THRESHOLD"`
+	AccrualBreakerTimeout        time.Duration `env:"ACCRUAL_BREAKER_TIMEOUT"`
+	AccrualRateLimit             uint64        `env:"ACCRUAL_RATE_LIMIT"`
+	AccrualBatchEndpoint         string        `env:"ACCRUAL_BATCH_ENDPOINT"`
+	AccrualFanOutConcurrency     uint64        `env:"ACCRUAL_FAN_OUT_CONCURRENCY"`
+	RetrieverBatchSize           uint64        `env:"RETRIEVER_BATCH_SIZE"`
+	RetrieverNotFoundMaxAttempts uint64        `env:"RETRIEVER_NOT_FOUND_MAX_ATTEMPTS"`
+	RetrieverNotFoundMaxDuration time.Duration `env:"RETRIEVER_NOT_FOUND_MAX_DURATION"`
+	LoginLockoutThreshold        uint64        `env:"LOGIN_LOCKOUT_THRESHOLD"`
+	LoginLockoutDuration         time.Duration `env:"LOGIN_LOCKOUT_DURATION"`
+	LoginLockoutMax              time.Duration `env:"LOGIN_LOCKOUT_MAX"`
+	PollRegisteredBaseDelay      time.Duration `env:"POLL_REGISTERED_BASE_DELAY"`
+	PollRegisteredMaxDelay       time.Duration `env:"POLL_REGISTERED_MAX_DELAY"`
+	PollProcessingBaseDelay      time.Duration `env:"POLL_PROCESSING_BASE_DELAY"`
+	PollProcessingMaxDelay       time.Duration `env:"POLL_PROCESSING_MAX_DELAY"`
+	PollMultiplier               float64       `env:"POLL_MULTIPLIER"`
+	PollJitter                   float64       `env:"POLL_JITTER"`
+	PollHorizon                  time.Duration `env:"POLL_HORIZON"`
+	RetrieverLinger              time.Duration `env:"RETRIEVER_LINGER"`
+	UpdateLinger                 time.Duration `env:"UPDATE_LINGER"`
+	UpdateVisibilityTimeout      time.Duration `env:"UPDATE_VISIBILITY_TIMEOUT"`
+	OrderFreshWeight             uint64        `env:"ORDER_FRESH_WEIGHT"`
+	OrderRetryWeight             uint64        `env:"ORDER_RETRY_WEIGHT"`
+	OrderReloadInterval          time.Duration `env:"ORDER_RELOAD_INTERVAL"`
+	MaxRequestBodySize           uint64        `env:"MAX_REQUEST_BODY_SIZE"`
+	MaxDecompressedBodySize      uint64        `env:"MAX_DECOMPRESSED_BODY_SIZE"`
+	MaxDecompressionRatio        uint64        `env:"MAX_DECOMPRESSION_RATIO"`
 }
 
 func ParseConfig() *Config {
 	config := &Config{}
 	flag.StringVarP(&config.AppEnv, "env", "e", "dev", "app environment")
 	flag.StringVarP(&config.AppSecret, "app-secret", "s", "aPp$eCr3t", "app secret for jwt")
+	flag.StringVar(&config.AdminToken, "admin-token", "", "token for admin endpoints, admin endpoints are disabled if empty")
 	flag.StringVarP(&config.RunAddress, "address", "a", ":8080", "address of gophermart-loyalty-service server")
 	flag.StringVarP(&config.AccrualSystemAddress, "accrual-system-address", "r", "localhost:8081", "address of gophermart-accrual-service server")
 	flag.StringVarP(&config.DatabaseURI, "database-uri", "d", "", "database uri")
@@ -39,11 +71,41 @@ func ParseConfig() *Config {
 	flag.Uint64Var(&config.InvalidConcurrency, "invalid-concurrency", 10, "invalid concurrency")
 	flag.Uint64Var(&config.ProcessedConcurrency, "processed-concurrency", 10, "processed concurrency")
 	flag.Uint64Var(&config.UpdateBatchSize, "update-batch-size", 100, "update batch size")
+	flag.Uint64Var(&config.UpdateMaxAttempts, "update-max-attempts", 10, "failures of order update before it is moved to dead letters")
 	flag.StringVarP(&config.LogLevel, "log-level", "l", "info", "log level")
 	flag.StringVar(&config.CPUProfileFile, "cpu-profile-file", "cpu.pprof", "path to save CPU profile")
 	flag.DurationVar(&config.CPUProfileDuration, "cpu-profile-duration", time.Second*30, "duration to save CPU profile")
 	flag.StringVar(&config.MemProfileFile, "mem-profile-file", "mem.pprof", "path to save memory profile")
 	flag.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", time.Second*15, "shutdown timeout")
+	flag.Uint64Var(&config.RetrieverMinConcurrency, "retriever-min-concurrency", 1, "retriever min concurrency under accrual system overload")
+	flag.DurationVar(&config.RetrieverLatencyThreshold, "retriever-latency-threshold", time.Second*2, "accrual system latency that decreases retriever concurrency")
+	flag.Uint64Var(&config.AccrualBreakerThreshold, "accrual-breaker-threshold", 5, "consecutive accrual system failures before requests are suspended")
+	flag.DurationVar(&config.AccrualBreakerTimeout, "accrual-breaker-timeout", time.Second*10, "accrual system requests suspension duration")
+	flag.Uint64Var(&config.AccrualRateLimit, "accrual-rate-limit", 0, "accrual system requests per minute quota, learned from 429 responses if zero")
+	flag.StringVar(&config.AccrualBatchEndpoint, "accrual-batch-endpoint", "", "accrual system batch endpoint path, orders are requested one by one if empty")
+	flag.Uint64Var(&config.AccrualFanOutConcurrency, "accrual-fan-out-concurrency", 4, "concurrent requests per batch if accrual system batch endpoint is not set")
+	flag.Uint64Var(&config.RetrieverBatchSize, "retriever-batch-size", 10, "max count of orders requested by one retriever goroutine")
+	flag.Uint64Var(&config.RetrieverNotFoundMaxAttempts, "retriever-not-found-max-attempts", 200, "lookups of order unknown to accrual system before it is marked as invalid")
+	flag.DurationVar(&config.RetrieverNotFoundMaxDuration, "retriever-not-found-max-duration", time.Hour*24, "time since first lookup of order unknown to accrual system before it is marked as invalid")
+	flag.Uint64Var(&config.LoginLockoutThreshold, "login-lockout-threshold", 5, "failed login or password change attempts before lockout")
+	flag.DurationVar(&config.LoginLockoutDuration, "login-lockout-duration", time.Second, "first login lockout duration, doubled on every next failure")
+	flag.DurationVar(&config.LoginLockoutMax, "login-lockout-max", time.Minute*15, "max login lockout duration")
+	flag.DurationVar(&config.PollRegisteredBaseDelay, "poll-registered-base-delay", time.Second*10, "delay before the first repeated lookup of order in REGISTERED status")
+	flag.DurationVar(&config.PollRegisteredMaxDelay, "poll-registered-max-delay", time.Minute*10, "max delay between lookups of order in REGISTERED status")
+	flag.DurationVar(&config.PollProcessingBaseDelay, "poll-processing-base-delay", time.Second*30, "delay before the first repeated lookup of order in PROCESSING status")
+	flag.DurationVar(&config.PollProcessingMaxDelay, "poll-processing-max-delay", time.Minute*30, "max delay between lookups of order in PROCESSING status")
+	flag.Float64Var(&config.PollMultiplier, "poll-multiplier", 2, "multiplier of delay between lookups of not final order")
+	flag.Float64Var(&config.PollJitter, "poll-jitter", 0.2, "max random deviation of delay between lookups as fraction of it")
+	flag.DurationVar(&config.PollHorizon, "poll-horizon", time.Hour*24*7, "time since upload or replay of not final order after which it is moved to dead letters")
+	flag.DurationVar(&config.RetrieverLinger, "retriever-linger", time.Millisecond*10, "max time to wait for retriever batch to be filled")
+	flag.DurationVar(&config.UpdateLinger, "update-linger", time.Millisecond*50, "max time to wait for update batch to be filled")
+	flag.DurationVar(&config.UpdateVisibilityTimeout, "update-visibility-timeout", time.Minute*5, "max time of accrual routing or update after which the accrual is queued again")
+	flag.Uint64Var(&config.OrderFreshWeight, "order-fresh-weight", 4, "share of retriever lookups for newly registered orders")
+	flag.Uint64Var(&config.OrderRetryWeight, "order-retry-weight", 1, "share of retriever lookups for repeated and failed lookups")
+	flag.DurationVar(&config.OrderReloadInterval, "order-reload-interval", time.Second*30, "interval of checks that orders overflowed the queue can be loaded from the database")
+	flag.Uint64Var(&config.MaxRequestBodySize, "max-request-body-size", 1<<20, "max size of the compressed request body in bytes")
+	flag.Uint64Var(&config.MaxDecompressedBodySize, "max-decompressed-body-size", 4<<20, "max size of the decompressed or not compressed request body in bytes")
+	flag.Uint64Var(&config.MaxDecompressionRatio, "max-decompression-ratio", 100, "max ratio of the decompressed request body size to the compressed one")
 	flag.Parse()
 	if err := env.Parse(config); err != nil {
 		panic(err)
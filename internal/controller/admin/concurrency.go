@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/requests"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+	"go.uber.org/zap"
+)
+
+var errUnknownProcessor = errors.New("unknown processor")
+
+func (container *Container) Concurrency(writer http.ResponseWriter, request *http.Request) {
+	processors := make([]string, 0, len(container.limiters))
+	for processor := range container.limiters {
+		processors = append(processors, processor)
+	}
+	slices.Sort(processors)
+
+	response := make([]responses.Concurrency, 0, len(processors))
+	for _, processor := range processors {
+		response = append(response, newConcurrencyResponse(processor, container.limiters[processor]))
+	}
+
+	controller.WriteJSONResponse(http.StatusOK, response, writer)
+}
+
+// ResizeConcurrency changes processor concurrency at runtime.
+// Work in flight over the new concurrency is not interrupted, new work waits until it is finished
+func (container *Container) ResizeConcurrency(writer http.ResponseWriter, request *http.Request) {
+	processor := chi.URLParam(request, "processor")
+	limiter, ok := container.limiters[processor]
+	if !ok {
+		controller.WriteJSONErrorResponse(http.StatusNotFound, writer, "processor not found", errUnknownProcessor)
+		return
+	}
+
+	resizeRequest, ok := controller.DecodeAndValidateJSONRequest[requests.Concurrency](request, writer)
+	if !ok {
+		return
+	}
+
+	from := limiter.Concurrency()
+	limiter.Resize(resizeRequest.Concurrency)
+	logger.Logger.Info(
+		"processor concurrency changed",
+		zap.String("processor", processor),
+		zap.Uint64("from", from),
+		zap.Uint64("to", resizeRequest.Concurrency),
+	)
+
+	controller.WriteJSONResponse(http.StatusOK, newConcurrencyResponse(processor, limiter), writer)
+}
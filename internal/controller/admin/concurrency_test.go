@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_Concurrency(t *testing.T) {
+	SetUp(t)
+
+	router := Mock[ConcurrencyLimiter]()
+	WhenSingle(router.Concurrency()).ThenReturn(uint64(10))
+	WhenSingle(router.InFlight()).ThenReturn(uint64(3))
+	processed := Mock[ConcurrencyLimiter]()
+	WhenSingle(processed.Concurrency()).ThenReturn(uint64(5))
+	WhenSingle(processed.InFlight()).ThenReturn(uint64(120))
+
+	container := NewContainer(Mock[deadLetterManager](), Mock[scheduler](), queue.New[uint64](1), map[string]ConcurrencyLimiter{
+		"router":    router,
+		"processed": processed,
+	})
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/concurrency", nil)
+	writer := httptest.NewRecorder()
+	container.Concurrency(writer, request)
+
+	result := writer.Result()
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+
+	concurrency := make([]responses.Concurrency, 0)
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&concurrency))
+	assert.Equal(t, []responses.Concurrency{
+		{Processor: "processed", Concurrency: 5, InFlight: 120},
+		{Processor: "router", Concurrency: 10, InFlight: 3},
+	}, concurrency)
+}
+
+func TestContainer_ResizeConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		processor   string
+		contentType string
+		body        string
+		resized     bool
+		status      int
+		errResponse *responses.APIError
+	}{
+		{
+			name:        "resized",
+			processor:   "router",
+			contentType: "application/json",
+			body:        `{"concurrency":20}`,
+			resized:     true,
+			status:      http.StatusOK,
+		},
+		{
+			name:        "unknown processor",
+			processor:   "retriever",
+			contentType: "application/json",
+			body:        `{"concurrency":20}`,
+			status:      http.StatusNotFound,
+			errResponse: &responses.APIError{
+				Code:    http.StatusNotFound,
+				Message: "processor not found",
+			},
+		},
+		{
+			name:        "zero concurrency",
+			processor:   "router",
+			contentType: "application/json",
+			body:        `{"concurrency":0}`,
+			status:      http.StatusBadRequest,
+			errResponse: &responses.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request received",
+			},
+		},
+		{
+			name:        "too big concurrency",
+			processor:   "router",
+			contentType: "application/json",
+			body:        `{"concurrency":1001}`,
+			status:      http.StatusBadRequest,
+			errResponse: &responses.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request received",
+			},
+		},
+		{
+			name:        "invalid content type",
+			processor:   "router",
+			contentType: "text/plain",
+			body:        `{"concurrency":20}`,
+			status:      http.StatusBadRequest,
+			errResponse: &responses.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid Content-Type",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+
+			limiter := Mock[ConcurrencyLimiter]()
+			WhenSingle(limiter.Concurrency()).ThenReturn(uint64(20))
+			WhenSingle(limiter.InFlight()).ThenReturn(uint64(7))
+
+			container := NewContainer(Mock[deadLetterManager](), Mock[scheduler](), queue.New[uint64](1), map[string]ConcurrencyLimiter{
+				"router": limiter,
+			})
+			request := httptest.NewRequest(http.MethodPut, "/api/admin/concurrency/"+tt.processor, strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", tt.contentType)
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("processor", tt.processor)
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeContext))
+			writer := httptest.NewRecorder()
+			container.ResizeConcurrency(writer, request)
+
+			result := writer.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.status, result.StatusCode)
+
+			if tt.resized {
+				Verify(limiter, Once()).Resize(uint64(20))
+			} else {
+				Verify(limiter, Never()).Resize(Any[uint64]())
+			}
+
+			if tt.errResponse != nil {
+				errResponse := &responses.APIError{}
+				require.NoError(t, json.NewDecoder(result.Body).Decode(errResponse))
+				assert.Equal(t, tt.errResponse, errResponse)
+			} else {
+				concurrency := responses.Concurrency{}
+				require.NoError(t, json.NewDecoder(result.Body).Decode(&concurrency))
+				assert.Equal(t, responses.Concurrency{Processor: "router", Concurrency: 20, InFlight: 7}, concurrency)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+)
+
+type deadLetterManager interface {
+	FindAll(ctx context.Context) (*generator.Stream[*entity.DeadLetter], error)
+	Replay(ctx context.Context, id uint64) (*entity.DeadLetter, error)
+}
+
+type scheduler interface {
+	Restart(orderID uint64)
+}
+
+// ConcurrencyLimiter is a processor which concurrency may be changed at runtime
+type ConcurrencyLimiter interface {
+	Concurrency() uint64
+	InFlight() uint64
+	Resize(concurrency uint64)
+}
+
+type Container struct {
+	deadLetterManager deadLetterManager
+	scheduler         scheduler
+	orderQueue        *queue.Queue[uint64]
+	limiters          map[string]ConcurrencyLimiter
+}
+
+func NewContainer(
+	deadLetterManager deadLetterManager,
+	scheduler scheduler,
+	orderQueue *queue.Queue[uint64],
+	limiters map[string]ConcurrencyLimiter,
+) *Container {
+	return &Container{
+		deadLetterManager: deadLetterManager,
+		scheduler:         scheduler,
+		orderQueue:        orderQueue,
+		limiters:          limiters,
+	}
+}
+
+func newDeadLetterResponse(deadLetter *entity.DeadLetter) responses.DeadLetter {
+	return responses.DeadLetter{
+		ID:        deadLetter.ID,
+		Order:     deadLetter.OrderID,
+		Source:    deadLetter.Source,
+		Status:    deadLetter.Status,
+		Accrual:   deadLetter.Accrual,
+		Attempts:  deadLetter.Attempts,
+		Reason:    deadLetter.Reason,
+		CreatedAt: deadLetter.CreatedAt,
+	}
+}
+
+func newConcurrencyResponse(processor string, limiter ConcurrencyLimiter) responses.Concurrency {
+	return responses.Concurrency{
+		Processor:   processor,
+		Concurrency: limiter.Concurrency(),
+		InFlight:    limiter.InFlight(),
+	}
+}
@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"go.uber.org/zap"
+)
+
+func (container *Container) DeadLetters(writer http.ResponseWriter, request *http.Request) {
+	deadLetters, err := container.deadLetterManager.FindAll(request.Context())
+	if err != nil {
+		controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t get dead letters", err)
+		return
+	}
+
+	defer deadLetters.Close()
+
+	if err := controller.StreamJSONResponse(http.StatusOK, deadLetters, func(item *entity.DeadLetter) any {
+		return newDeadLetterResponse(item)
+	}, writer); err != nil {
+		controller.WriteStreamErrorResponse(writer, "can`t get dead letters", err)
+		return
+	}
+}
+
+// ReplayDeadLetter returns order of the dead letter to processing.
+// The order is requested from the accrual system again, so its actual status is processed.
+// Polling horizon of the order is counted from the replay
+func (container *Container) ReplayDeadLetter(writer http.ResponseWriter, request *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(request, "id"), 10, 64)
+	if err != nil {
+		controller.WriteJSONErrorResponse(http.StatusBadRequest, writer, "invalid dead letter id", err)
+		return
+	}
+
+	if container.orderQueue.Full() {
+		controller.Overloaded(writer, controller.OverloadedRetryAfter)
+		return
+	}
+
+	deadLetter, err := container.deadLetterManager.Replay(request.Context(), id)
+	if err != nil {
+		if errors.Is(err, manager.ErrDeadLetterNotFound) {
+			controller.WriteJSONErrorResponse(http.StatusNotFound, writer, "dead letter not found", err)
+		} else {
+			controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t replay dead letter", err)
+		}
+
+		return
+	}
+
+	container.scheduler.Restart(deadLetter.OrderID)
+	if err := container.orderQueue.TryPush(deadLetter.OrderID); err != nil {
+		// spilled order is queued again by the reload processor
+		logger.Logger.Warn("can`t queue replayed order", zap.Uint64("order_id", deadLetter.OrderID), zap.Error(err))
+	}
+	controller.WriteJSONResponse(http.StatusAccepted, newDeadLetterResponse(deadLetter), writer)
+}
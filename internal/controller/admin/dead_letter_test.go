@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_DeadLetters(t *testing.T) {
+	SetUp(t)
+
+	accrual := money.MustParse("5")
+	items := make([]*entity.DeadLetter, 0, 2)
+	items = append(items, &entity.DeadLetter{
+		ID:        1,
+		OrderID:   11,
+		Source:    entity.DeadLetterSourceRetriever,
+		Attempts:  1,
+		Reason:    "unknown accrual status: \"CANCELLED\"",
+		CreatedAt: time.Unix(1, 1).UTC(),
+	})
+	items = append(items, &entity.DeadLetter{
+		ID:        2,
+		OrderID:   22,
+		Source:    entity.DeadLetterSourceProcessed,
+		Status:    "PROCESSED",
+		Accrual:   &accrual,
+		Attempts:  10,
+		Reason:    "order not found",
+		CreatedAt: time.Unix(2, 2).UTC(),
+	})
+
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenDouble(deadLetterManager.FindAll(AnyContext())).ThenReturn(generator.NewStreamFromSlice(items, nil), nil).Verify(Once())
+
+	container := NewContainer(deadLetterManager, Mock[scheduler](), queue.New[uint64](1), nil)
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/dead-letters", nil)
+	writer := httptest.NewRecorder()
+	container.DeadLetters(writer, request)
+
+	result := writer.Result()
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+
+	deadLetters := make([]responses.DeadLetter, 0)
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&deadLetters))
+	assert.Equal(t, []responses.DeadLetter{
+		{
+			ID:        1,
+			Order:     11,
+			Source:    entity.DeadLetterSourceRetriever,
+			Attempts:  1,
+			Reason:    "unknown accrual status: \"CANCELLED\"",
+			CreatedAt: time.Unix(1, 1).UTC(),
+		},
+		{
+			ID:        2,
+			Order:     22,
+			Source:    entity.DeadLetterSourceProcessed,
+			Status:    "PROCESSED",
+			Accrual:   &accrual,
+			Attempts:  10,
+			Reason:    "order not found",
+			CreatedAt: time.Unix(2, 2).UTC(),
+		},
+	}, deadLetters)
+}
+
+func TestContainer_ReplayDeadLetter(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		manager     func() deadLetterManager
+		status      int
+		queued      uint64
+		errResponse *responses.APIError
+	}{
+		{
+			name: "replayed",
+			id:   "1",
+			manager: func() deadLetterManager {
+				deadLetterManager := Mock[deadLetterManager]()
+				WhenDouble(deadLetterManager.Replay(AnyContext(), Exact(uint64(1)))).
+					ThenReturn(&entity.DeadLetter{ID: 1, OrderID: 11}, nil).
+					Verify(Once())
+
+				return deadLetterManager
+			},
+			status: http.StatusAccepted,
+			queued: 1,
+		},
+		{
+			name: "invalid id",
+			id:   "abc",
+			manager: func() deadLetterManager {
+				return Mock[deadLetterManager]()
+			},
+			status: http.StatusBadRequest,
+			errResponse: &responses.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "invalid dead letter id",
+			},
+		},
+		{
+			name: "not found",
+			id:   "1",
+			manager: func() deadLetterManager {
+				deadLetterManager := Mock[deadLetterManager]()
+				WhenDouble(deadLetterManager.Replay(AnyContext(), Exact(uint64(1)))).
+					ThenReturn(nil, manager.ErrDeadLetterNotFound).
+					Verify(Once())
+
+				return deadLetterManager
+			},
+			status: http.StatusNotFound,
+			errResponse: &responses.APIError{
+				Code:    http.StatusNotFound,
+				Message: "dead letter not found",
+			},
+		},
+		{
+			name: "cant replay",
+			id:   "1",
+			manager: func() deadLetterManager {
+				deadLetterManager := Mock[deadLetterManager]()
+				WhenDouble(deadLetterManager.Replay(AnyContext(), Exact(uint64(1)))).
+					ThenReturn(nil, errors.New("some error")).
+					Verify(Once())
+
+				return deadLetterManager
+			},
+			status: http.StatusInternalServerError,
+			errResponse: &responses.APIError{
+				Code:    http.StatusInternalServerError,
+				Message: "can`t replay dead letter",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+
+			orderQueue := queue.New[uint64](1)
+			scheduler := Mock[scheduler]()
+			container := NewContainer(tt.manager(), scheduler, orderQueue, nil)
+			request := httptest.NewRequest(http.MethodPost, "/api/admin/dead-letters/"+tt.id+"/replay", nil)
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", tt.id)
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeContext))
+			writer := httptest.NewRecorder()
+			container.ReplayDeadLetter(writer, request)
+
+			result := writer.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.status, result.StatusCode)
+			assert.Equal(t, tt.queued, orderQueue.Count())
+
+			if tt.errResponse != nil {
+				errResponse := &responses.APIError{}
+				require.NoError(t, json.NewDecoder(result.Body).Decode(errResponse))
+				assert.Equal(t, tt.errResponse, errResponse)
+			} else {
+				deadLetter := responses.DeadLetter{}
+				require.NoError(t, json.NewDecoder(result.Body).Decode(&deadLetter))
+				assert.EqualValues(t, 11, deadLetter.Order)
+				orderID, ok := orderQueue.Pop()
+				require.True(t, ok)
+				assert.EqualValues(t, 11, orderID)
+				Verify(scheduler, Once()).Restart(Exact(uint64(11)))
+			}
+		})
+	}
+}
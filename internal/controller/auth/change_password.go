@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/context"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/requests"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+)
+
+func (container *Container) ChangePassword(writer http.ResponseWriter, request *http.Request) {
+	userID, ok := context.UserIDFromContext(request.Context())
+	if !ok {
+		controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t get request credentials", nil)
+		return
+	}
+
+	changePasswordRequest, ok := controller.DecodeAndValidateJSONRequest[requests.ChangePassword](request, writer)
+	if !ok {
+		return
+	}
+
+	token, err := container.manager.ChangePassword(request.Context(), userID, changePasswordRequest.OldPassword, changePasswordRequest.NewPassword)
+	if err != nil {
+		locked := manager.ErrLoginLocked{}
+		switch {
+		case errors.Is(err, manager.ErrInvalidCredentials):
+			controller.WriteJSONErrorResponse(http.StatusUnauthorized, writer, "invalid credentials", err)
+		case errors.As(err, &locked):
+			writeLoginLocked(writer, locked)
+		case errors.Is(err, manager.ErrUserNotFound):
+			controller.WriteJSONErrorResponse(http.StatusUnauthorized, writer, "user not found", err)
+		default:
+			controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "internal server error", err)
+		}
+
+		return
+	}
+
+	writer.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	controller.WriteJSONResponse(http.StatusOK, responses.Auth{
+		AccessToken: token,
+	}, writer)
+}
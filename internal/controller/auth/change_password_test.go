@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	userContext "github.com/m1khal3v/gophermart-loyalty-service/internal/context"
+	managers "github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/requests"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		requestString string
+		request       requests.ChangePassword
+		manager       func() userManager
+		status        int
+		token         string
+		retryAfter    string
+		response      *responses.Auth
+		errResponse   *responses.APIError
+	}{
+		{
+			name: "valid change",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			request: requests.ChangePassword{
+				OldPassword: "$uP3R$3cR3t",
+				NewPassword: "n3w_$uP3R$3cR3t",
+			},
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenDouble(manager.ChangePassword(
+					AnyContext(),
+					Exact(uint32(123)),
+					Exact("$uP3R$3cR3t"),
+					Exact("n3w_$uP3R$3cR3t"),
+				)).ThenReturn("t0k3n", nil).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusOK,
+			token:  "Bearer t0k3n",
+			response: &responses.Auth{
+				AccessToken: "t0k3n",
+			},
+		},
+		{
+			name: "cant get credentials",
+			ctx:  context.Background(),
+			request: requests.ChangePassword{
+				OldPassword: "$uP3R$3cR3t",
+				NewPassword: "n3w_$uP3R$3cR3t",
+			},
+			manager: func() userManager {
+				return Mock[userManager]()
+			},
+			status: http.StatusInternalServerError,
+			errResponse: &responses.APIError{
+				Code:    http.StatusInternalServerError,
+				Message: "can`t get request credentials",
+			},
+		},
+		{
+			name:          "short new password",
+			ctx:           userContext.WithUserID(context.Background(), 123),
+			requestString: `{"old_password": "$uP3R$3cR3t", "new_password": "short"}`,
+			manager: func() userManager {
+				return Mock[userManager]()
+			},
+			status: http.StatusBadRequest,
+			errResponse: &responses.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request received",
+			},
+		},
+		{
+			name: "invalid old password",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			request: requests.ChangePassword{
+				OldPassword: "$uP3R$3cR3t",
+				NewPassword: "n3w_$uP3R$3cR3t",
+			},
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenDouble(manager.ChangePassword(
+					AnyContext(),
+					Exact(uint32(123)),
+					Exact("$uP3R$3cR3t"),
+					Exact("n3w_$uP3R$3cR3t"),
+				)).ThenReturn("", managers.ErrInvalidCredentials).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusUnauthorized,
+			errResponse: &responses.APIError{
+				Code:    http.StatusUnauthorized,
+				Message: "invalid credentials",
+			},
+		},
+		{
+			name: "login locked",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			request: requests.ChangePassword{
+				OldPassword: "$uP3R$3cR3t",
+				NewPassword: "n3w_$uP3R$3cR3t",
+			},
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenDouble(manager.ChangePassword(
+					AnyContext(),
+					Exact(uint32(123)),
+					Exact("$uP3R$3cR3t"),
+					Exact("n3w_$uP3R$3cR3t"),
+				)).ThenReturn("", managers.ErrLoginLocked{RetryAfter: time.Second * 3}).
+					Verify(Once())
+
+				return manager
+			},
+			status:     http.StatusTooManyRequests,
+			retryAfter: "3",
+			errResponse: &responses.APIError{
+				Code:    http.StatusTooManyRequests,
+				Message: "too many login attempts",
+			},
+		},
+		{
+			name: "user not found",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			request: requests.ChangePassword{
+				OldPassword: "$uP3R$3cR3t",
+				NewPassword: "n3w_$uP3R$3cR3t",
+			},
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenDouble(manager.ChangePassword(
+					AnyContext(),
+					Exact(uint32(123)),
+					Exact("$uP3R$3cR3t"),
+					Exact("n3w_$uP3R$3cR3t"),
+				)).ThenReturn("", managers.ErrUserNotFound).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusUnauthorized,
+			errResponse: &responses.APIError{
+				Code:    http.StatusUnauthorized,
+				Message: "user not found",
+			},
+		},
+		{
+			name: "internal server error",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			request: requests.ChangePassword{
+				OldPassword: "$uP3R$3cR3t",
+				NewPassword: "n3w_$uP3R$3cR3t",
+			},
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenDouble(manager.ChangePassword(
+					AnyContext(),
+					Exact(uint32(123)),
+					Exact("$uP3R$3cR3t"),
+					Exact("n3w_$uP3R$3cR3t"),
+				)).ThenReturn("", errors.New("some error")).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusInternalServerError,
+			errResponse: &responses.APIError{
+				Code:    http.StatusInternalServerError,
+				Message: "internal server error",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+			manager := tt.manager()
+			container := NewContainer(manager)
+			recorder := httptest.NewRecorder()
+
+			var requestBody *bytes.Buffer
+			if tt.requestString != "" {
+				requestBody = bytes.NewBuffer([]byte(tt.requestString))
+			} else {
+				jsonRequest, err := json.Marshal(tt.request)
+				require.NoError(t, err)
+				requestBody = bytes.NewBuffer(jsonRequest)
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/password", requestBody).WithContext(tt.ctx)
+			request.Header.Set("Content-Type", "application/json")
+
+			container.ChangePassword(recorder, request)
+
+			require.Equal(t, tt.status, recorder.Code)
+			assert.Equal(t, tt.token, recorder.Header().Get("Authorization"))
+			assert.Equal(t, tt.retryAfter, recorder.Header().Get("Retry-After"))
+
+			if tt.response != nil {
+				response := &responses.Auth{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+				assert.Equal(t, tt.response, response)
+			}
+
+			if tt.errResponse != nil {
+				response := &responses.APIError{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+				assert.Equal(t, tt.errResponse, response)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 type userManager interface {
 	Register(ctx context.Context, login, password string) (string, error)
 	Authorize(ctx context.Context, login, password string) (string, error)
+	ChangePassword(ctx context.Context, id uint32, oldPassword, newPassword string) (string, error)
+	Delete(ctx context.Context, id uint32) error
 }
 
 type Container struct {
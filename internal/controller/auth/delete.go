@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/context"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+)
+
+func (container *Container) Delete(writer http.ResponseWriter, request *http.Request) {
+	userID, ok := context.UserIDFromContext(request.Context())
+	if !ok {
+		controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t get request credentials", nil)
+		return
+	}
+
+	if err := container.manager.Delete(request.Context(), userID); err != nil {
+		if errors.Is(err, manager.ErrUserNotFound) {
+			controller.WriteJSONErrorResponse(http.StatusUnauthorized, writer, "user not found", err)
+		} else {
+			controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t delete user", err)
+		}
+
+		return
+	}
+
+	controller.WriteJSONResponse(http.StatusOK, responses.Message{
+		Message: "user has been successfully deleted",
+	}, writer)
+}
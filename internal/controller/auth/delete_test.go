@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	userContext "github.com/m1khal3v/gophermart-loyalty-service/internal/context"
+	managers "github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_Delete(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		manager     func() userManager
+		status      int
+		response    *responses.Message
+		errResponse *responses.APIError
+	}{
+		{
+			name: "deleted",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenSingle(manager.Delete(
+					AnyContext(),
+					Exact(uint32(123)),
+				)).ThenReturn(nil).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusOK,
+			response: &responses.Message{
+				Message: "user has been successfully deleted",
+			},
+		},
+		{
+			name: "cant get credentials",
+			ctx:  context.Background(),
+			manager: func() userManager {
+				return Mock[userManager]()
+			},
+			status: http.StatusInternalServerError,
+			errResponse: &responses.APIError{
+				Code:    http.StatusInternalServerError,
+				Message: "can`t get request credentials",
+			},
+		},
+		{
+			name: "already deleted",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenSingle(manager.Delete(
+					AnyContext(),
+					Exact(uint32(123)),
+				)).ThenReturn(managers.ErrUserNotFound).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusUnauthorized,
+			errResponse: &responses.APIError{
+				Code:    http.StatusUnauthorized,
+				Message: "user not found",
+			},
+		},
+		{
+			name: "internal server error",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenSingle(manager.Delete(
+					AnyContext(),
+					Exact(uint32(123)),
+				)).ThenReturn(errors.New("some error")).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusInternalServerError,
+			errResponse: &responses.APIError{
+				Code:    http.StatusInternalServerError,
+				Message: "can`t delete user",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+			manager := tt.manager()
+			container := NewContainer(manager)
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodDelete, "/api/user", nil).WithContext(tt.ctx)
+
+			container.Delete(recorder, request)
+
+			require.Equal(t, tt.status, recorder.Code)
+
+			if tt.response != nil {
+				response := &responses.Message{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+				assert.Equal(t, tt.response, response)
+			}
+
+			if tt.errResponse != nil {
+				response := &responses.APIError{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+				assert.Equal(t, tt.errResponse, response)
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
@@ -19,9 +21,13 @@ func (container *Container) Login(writer http.ResponseWriter, request *http.Requ
 
 	token, err := container.manager.Authorize(request.Context(), registerRequest.Login, registerRequest.Password)
 	if err != nil {
-		if errors.Is(err, manager.ErrInvalidCredentials) {
+		locked := manager.ErrLoginLocked{}
+		switch {
+		case errors.Is(err, manager.ErrInvalidCredentials):
 			controller.WriteJSONErrorResponse(http.StatusUnauthorized, writer, "invalid credentials", err)
-		} else {
+		case errors.As(err, &locked):
+			writeLoginLocked(writer, locked)
+		default:
 			controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "internal server error", err)
 		}
 
@@ -33,3 +39,8 @@ func (container *Container) Login(writer http.ResponseWriter, request *http.Requ
 		AccessToken: token,
 	}, writer)
 }
+
+func writeLoginLocked(writer http.ResponseWriter, locked manager.ErrLoginLocked) {
+	writer.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(locked.RetryAfter.Seconds())), 10))
+	controller.WriteJSONErrorResponse(http.StatusTooManyRequests, writer, "too many login attempts", locked)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	managers "github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/requests"
@@ -25,6 +26,7 @@ func TestContainer_Login(t *testing.T) {
 		manager       func() userManager
 		status        int
 		token         string
+		retryAfter    string
 		response      *responses.Auth
 		errResponse   *responses.APIError
 	}{
@@ -144,6 +146,31 @@ func TestContainer_Login(t *testing.T) {
 				Message: "invalid credentials",
 			},
 		},
+		{
+			name:        "login locked",
+			contentType: "application/json",
+			request: requests.Login{
+				Login:    "ivan_ivanov",
+				Password: "$uP3R$3cR3t",
+			},
+			manager: func() userManager {
+				manager := Mock[userManager]()
+				WhenDouble(manager.Authorize(
+					AnyContext(),
+					Exact("ivan_ivanov"),
+					Exact("$uP3R$3cR3t"),
+				)).ThenReturn("", managers.ErrLoginLocked{RetryAfter: time.Millisecond * 1500}).
+					Verify(Once())
+
+				return manager
+			},
+			status:     http.StatusTooManyRequests,
+			retryAfter: "2",
+			errResponse: &responses.APIError{
+				Code:    http.StatusTooManyRequests,
+				Message: "too many login attempts",
+			},
+		},
 		{
 			name:        "internal server error",
 			contentType: "application/json",
@@ -192,6 +219,7 @@ func TestContainer_Login(t *testing.T) {
 
 			require.Equal(t, tt.status, recorder.Code)
 			assert.Equal(t, tt.token, recorder.Header().Get("Authorization"))
+			assert.Equal(t, tt.retryAfter, recorder.Header().Get("Retry-After"))
 
 			if tt.response != nil {
 				response := &responses.Auth{}
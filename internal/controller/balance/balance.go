@@ -22,7 +22,7 @@ func (container *Container) Balance(writer http.ResponseWriter, request *http.Re
 	}
 
 	controller.WriteJSONResponse(http.StatusOK, responses.Balance{
-		Current:   user.Balance.AsFloat(),
-		Withdrawn: user.Withdrawn.AsFloat(),
+		Current:   user.Balance,
+		Withdrawn: user.Withdrawn,
 	}, writer)
 }
@@ -35,8 +35,8 @@ func TestContainer_Balance(t *testing.T) {
 					AnyContext(),
 					Exact(uint32(123)),
 				)).ThenReturn(&entity.User{
-					Balance:   money.New(123.32),
-					Withdrawn: money.New(321.12),
+					Balance:   money.MustParse("123.32"),
+					Withdrawn: money.MustParse("321.12"),
 				}, nil).
 					Verify(Once())
 
@@ -44,8 +44,8 @@ func TestContainer_Balance(t *testing.T) {
 			},
 			status: http.StatusOK,
 			response: &responses.Balance{
-				Current:   123.32,
-				Withdrawn: 321.12,
+				Current:   money.MustParse("123.32"),
+				Withdrawn: money.MustParse("321.12"),
 			},
 		},
 		{
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 )
 
 type userManager interface {
@@ -11,7 +12,7 @@ type userManager interface {
 }
 
 type userWithdrawalManager interface {
-	Withdraw(ctx context.Context, orderID uint64, userID uint32, sum float64) error
+	Withdraw(ctx context.Context, orderID uint64, userID uint32, sum money.Amount) error
 }
 
 type Container struct {
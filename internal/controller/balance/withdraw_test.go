@@ -11,6 +11,7 @@ import (
 
 	userContext "github.com/m1khal3v/gophermart-loyalty-service/internal/context"
 	managers "github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/requests"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
 	_ "github.com/m1khal3v/gophermart-loyalty-service/pkg/validator"
@@ -37,7 +38,7 @@ func TestContainer_Withdraw(t *testing.T) {
 			contentType: "application/json",
 			request: requests.Withdraw{
 				Order: 1234566,
-				Sum:   123.321,
+				Sum:   money.MustParse("123.32"),
 			},
 			manager: func() userWithdrawalManager {
 				manager := Mock[userWithdrawalManager]()
@@ -45,7 +46,7 @@ func TestContainer_Withdraw(t *testing.T) {
 					AnyContext(),
 					Exact(uint64(1234566)),
 					Exact(uint32(123)),
-					Exact(123.321),
+					Exact(money.MustParse("123.32")),
 				)).ThenReturn(nil).
 					Verify(Once())
 
@@ -62,7 +63,7 @@ func TestContainer_Withdraw(t *testing.T) {
 			contentType: "application/json",
 			request: requests.Withdraw{
 				Order: 1234566,
-				Sum:   123.321,
+				Sum:   money.MustParse("123.32"),
 			},
 			manager: func() userWithdrawalManager {
 				return Mock[userWithdrawalManager]()
@@ -79,7 +80,7 @@ func TestContainer_Withdraw(t *testing.T) {
 			contentType: "invalid",
 			request: requests.Withdraw{
 				Order: 1234566,
-				Sum:   123.321,
+				Sum:   money.MustParse("123.32"),
 			},
 			manager: func() userWithdrawalManager {
 				return Mock[userWithdrawalManager]()
@@ -124,7 +125,7 @@ func TestContainer_Withdraw(t *testing.T) {
 			contentType: "application/json",
 			request: requests.Withdraw{
 				Order: 123456,
-				Sum:   123.321,
+				Sum:   money.MustParse("123.32"),
 			},
 			manager: func() userWithdrawalManager {
 				return Mock[userWithdrawalManager]()
@@ -153,20 +154,53 @@ func TestContainer_Withdraw(t *testing.T) {
 			},
 		},
 		{
-			name:        "invalid sum 2",
-			ctx:         userContext.WithUserID(context.Background(), 123),
-			contentType: "application/json",
-			request: requests.Withdraw{
-				Order: 1234566,
-				Sum:   -1.1,
+			name:          "invalid sum 2",
+			ctx:           userContext.WithUserID(context.Background(), 123),
+			contentType:   "application/json",
+			requestString: `{"order": "1234566", "sum": -1.1}`,
+			manager: func() userWithdrawalManager {
+				return Mock[userWithdrawalManager]()
 			},
+			status: http.StatusBadRequest,
+			errResponse: &responses.APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid json received",
+			},
+		},
+		{
+			name:          "invalid sum precision",
+			ctx:           userContext.WithUserID(context.Background(), 123),
+			contentType:   "application/json",
+			requestString: `{"order": "1234566", "sum": 123.321}`,
 			manager: func() userWithdrawalManager {
 				return Mock[userWithdrawalManager]()
 			},
 			status: http.StatusBadRequest,
 			errResponse: &responses.APIError{
 				Code:    http.StatusBadRequest,
-				Message: "Invalid request received",
+				Message: "Invalid json received",
+			},
+		},
+		{
+			name:          "valid string sum",
+			ctx:           userContext.WithUserID(context.Background(), 123),
+			contentType:   "application/json",
+			requestString: `{"order": "1234566", "sum": "0.29"}`,
+			manager: func() userWithdrawalManager {
+				manager := Mock[userWithdrawalManager]()
+				When(manager.Withdraw(
+					AnyContext(),
+					Exact(uint64(1234566)),
+					Exact(uint32(123)),
+					Exact(money.Amount(29)),
+				)).ThenReturn(nil).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusOK,
+			response: &responses.Message{
+				Message: "withdrawal successfully registered",
 			},
 		},
 		{
@@ -175,7 +209,7 @@ func TestContainer_Withdraw(t *testing.T) {
 			contentType: "application/json",
 			request: requests.Withdraw{
 				Order: 1234566,
-				Sum:   123.321,
+				Sum:   money.MustParse("123.32"),
 			},
 			manager: func() userWithdrawalManager {
 				manager := Mock[userWithdrawalManager]()
@@ -183,7 +217,7 @@ func TestContainer_Withdraw(t *testing.T) {
 					AnyContext(),
 					Exact(uint64(1234566)),
 					Exact(uint32(123)),
-					Exact(123.321),
+					Exact(money.MustParse("123.32")),
 				)).ThenReturn(managers.ErrInsufficientFunds).
 					Verify(Once())
 
@@ -201,7 +235,7 @@ func TestContainer_Withdraw(t *testing.T) {
 			contentType: "application/json",
 			request: requests.Withdraw{
 				Order: 1234566,
-				Sum:   123.321,
+				Sum:   money.MustParse("123.32"),
 			},
 			manager: func() userWithdrawalManager {
 				manager := Mock[userWithdrawalManager]()
@@ -209,7 +243,7 @@ func TestContainer_Withdraw(t *testing.T) {
 					AnyContext(),
 					Exact(uint64(1234566)),
 					Exact(uint32(123)),
-					Exact(123.321),
+					Exact(money.MustParse("123.32")),
 				)).ThenReturn(errors.New("some error")).
 					Verify(Once())
 
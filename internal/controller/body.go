@@ -0,0 +1,15 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/middleware"
+)
+
+// IsBodyTooLarge reports whether reading of the request body is stopped by a size limit
+func IsBodyTooLarge(err error) bool {
+	var maxBytesError *http.MaxBytesError
+
+	return errors.Is(err, middleware.ErrBodyTooLarge) || errors.As(err, &maxBytesError)
+}
@@ -2,14 +2,20 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
 	"go.uber.org/zap"
 )
 
+// ErrStreamInterrupted is returned if the stream is failed after the response status is sent
+var ErrStreamInterrupted = errors.New("stream is interrupted")
+
 func DecodeAndValidateJSONRequest[T any](request *http.Request, writer http.ResponseWriter) (*T, bool) {
 	if request.Header.Get("Content-Type") != "application/json" {
 		WriteJSONErrorResponse(http.StatusBadRequest, writer, "Invalid Content-Type", nil)
@@ -19,6 +25,11 @@ func DecodeAndValidateJSONRequest[T any](request *http.Request, writer http.Resp
 	target := new(T)
 
 	if err := json.NewDecoder(request.Body).Decode(target); err != nil {
+		if IsBodyTooLarge(err) {
+			WriteJSONErrorResponse(http.StatusRequestEntityTooLarge, writer, "Request body is too large", err)
+			return nil, false
+		}
+
 		WriteJSONErrorResponse(http.StatusBadRequest, writer, "Invalid json received", err)
 		return nil, false
 	}
@@ -46,15 +57,42 @@ func WriteJSONResponse(status int, response any, writer http.ResponseWriter) {
 	}
 }
 
-func StreamJSONResponse[T any](status int, stream <-chan T, transform func(item T) any, writer http.ResponseWriter) error {
+// StreamJSONResponse writes items of the stream as json array. The status is sent with the first item,
+// so the error of the empty stream can still be written as json error response
+func StreamJSONResponse[T any](
+	status int,
+	stream *generator.Stream[T],
+	transform func(item T) any,
+	writer http.ResponseWriter,
+) error {
+	item, ok := <-stream.Items()
+	if !ok {
+		if err := stream.Err(); err != nil {
+			return err
+		}
+	}
+
 	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(status)
+	if err := writeJSONStream(writer, stream, item, ok, transform); err != nil {
+		return fmt.Errorf("%w: %w", ErrStreamInterrupted, err)
+	}
+
+	return nil
+}
+
+func writeJSONStream[T any](
+	writer http.ResponseWriter,
+	stream *generator.Stream[T],
+	item T,
+	ok bool,
+	transform func(item T) any,
+) error {
 	if _, err := writer.Write([]byte("[")); err != nil {
 		return err
 	}
 
-	first := true
-	for item := range stream {
+	for first := true; ok; item, ok = <-stream.Items() {
 		if first {
 			first = false
 		} else {
@@ -73,11 +111,24 @@ func StreamJSONResponse[T any](status int, stream <-chan T, transform func(item
 		}
 	}
 
-	if _, err := writer.Write([]byte("]")); err != nil {
+	if err := stream.Err(); err != nil {
 		return err
 	}
 
-	return nil
+	_, err := writer.Write([]byte("]"))
+	return err
+}
+
+// WriteStreamErrorResponse writes json error response if nothing is sent yet,
+// otherwise the response is aborted, so the client does not receive truncated but valid json
+func WriteStreamErrorResponse(writer http.ResponseWriter, message string, responseError error) {
+	if !errors.Is(responseError, ErrStreamInterrupted) {
+		WriteJSONErrorResponse(http.StatusInternalServerError, writer, message, responseError)
+		return
+	}
+
+	logger.Logger.Error(message, zap.Error(responseError))
+	panic(http.ErrAbortHandler)
 }
 
 func WriteJSONErrorResponse(status int, writer http.ResponseWriter, message string, responseError error) {
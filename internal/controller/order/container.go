@@ -4,12 +4,13 @@ import (
 	"context"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 )
 
 type orderManager interface {
 	Register(ctx context.Context, id uint64, userID uint32) (*entity.Order, error)
-	FindByUser(ctx context.Context, userID uint32) (<-chan *entity.Order, error)
+	FindByUser(ctx context.Context, userID uint32) (*generator.Stream[*entity.Order], error)
 	HasUser(ctx context.Context, userID uint32) (bool, error)
 }
 
@@ -32,6 +32,8 @@ func (container *Container) List(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
+	defer orders.Close()
+
 	if err := controller.StreamJSONResponse(http.StatusOK, orders, func(item *entity.Order) any {
 		response := responses.Order{
 			Number:     item.ID,
@@ -39,13 +41,12 @@ func (container *Container) List(writer http.ResponseWriter, request *http.Reque
 			UploadedAt: item.CreatedAt,
 		}
 		if item.Status == entity.OrderStatusProcessed {
-			accrual := item.Accrual.AsFloat()
-			response.Accrual = &accrual
+			response.Accrual = &item.Accrual
 		}
 
 		return response
 	}, writer); err != nil {
-		controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t get user orders", err)
+		controller.WriteStreamErrorResponse(writer, "can`t get user orders", err)
 		return
 	}
 }
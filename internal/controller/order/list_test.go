@@ -11,6 +11,7 @@ import (
 
 	userContext "github.com/m1khal3v/gophermart-loyalty-service/internal/context"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
@@ -20,12 +21,13 @@ import (
 )
 
 func TestContainer_List(t *testing.T) {
-	accrual := 1.23
+	accrual := money.MustParse("1.23")
 	tests := []struct {
 		name        string
 		ctx         context.Context
 		manager     func() orderManager
 		status      int
+		abort       bool
 		response    []responses.Order
 		errResponse *responses.APIError
 	}{
@@ -33,7 +35,7 @@ func TestContainer_List(t *testing.T) {
 			name: "valid orders",
 			ctx:  userContext.WithUserID(context.Background(), 123),
 			manager: func() orderManager {
-				channel := make(chan *entity.Order, 4)
+				items := make([]*entity.Order, 0, 4)
 				for i := 1; i <= 4; i++ {
 					var status string
 					switch i {
@@ -53,11 +55,10 @@ func TestContainer_List(t *testing.T) {
 						CreatedAt: time.Unix(int64(i), int64(i)).UTC(),
 					}
 					if status == entity.OrderStatusProcessed {
-						order.Accrual = money.New(accrual)
+						order.Accrual = accrual
 					}
-					channel <- order
+					items = append(items, order)
 				}
-				close(channel)
 				manager := Mock[orderManager]()
 				WhenDouble(manager.HasUser(
 					AnyContext(),
@@ -67,7 +68,7 @@ func TestContainer_List(t *testing.T) {
 				WhenDouble(manager.FindByUser(
 					AnyContext(),
 					Exact(uint32(123)),
-				)).ThenReturn(channel, nil).
+				)).ThenReturn(generator.NewStreamFromSlice(items, nil), nil).
 					Verify(Once())
 
 				return manager
@@ -168,6 +169,53 @@ func TestContainer_List(t *testing.T) {
 				Message: "can`t get user orders",
 			},
 		},
+		{
+			name: "cant stream user orders",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			manager: func() orderManager {
+				manager := Mock[orderManager]()
+				WhenDouble(manager.HasUser(
+					AnyContext(),
+					Exact(uint32(123)),
+				)).ThenReturn(true, nil).
+					Verify(Once())
+				WhenDouble(manager.FindByUser(
+					AnyContext(),
+					Exact(uint32(123)),
+				)).ThenReturn(generator.NewStreamFromSlice[*entity.Order](nil, errors.New("some error")), nil).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusInternalServerError,
+			errResponse: &responses.APIError{
+				Code:    http.StatusInternalServerError,
+				Message: "can`t get user orders",
+			},
+		},
+		{
+			name: "stream interrupted",
+			ctx:  userContext.WithUserID(context.Background(), 123),
+			manager: func() orderManager {
+				manager := Mock[orderManager]()
+				WhenDouble(manager.HasUser(
+					AnyContext(),
+					Exact(uint32(123)),
+				)).ThenReturn(true, nil).
+					Verify(Once())
+				WhenDouble(manager.FindByUser(
+					AnyContext(),
+					Exact(uint32(123)),
+				)).ThenReturn(generator.NewStreamFromSlice([]*entity.Order{
+					{ID: 1, UserID: 123, Status: entity.OrderStatusNew},
+				}, errors.New("some error")), nil).
+					Verify(Once())
+
+				return manager
+			},
+			status: http.StatusOK,
+			abort:  true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -178,6 +226,16 @@ func TestContainer_List(t *testing.T) {
 
 			request := httptest.NewRequest(http.MethodGet, "/api/user/order", nil).WithContext(tt.ctx)
 
+			if tt.abort {
+				// truncated json must not look like a complete response
+				assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+					container.List(recorder, request)
+				})
+				assert.Equal(t, tt.status, recorder.Code)
+				assert.False(t, json.Valid(recorder.Body.Bytes()))
+				return
+			}
+
 			container.List(recorder, request)
 
 			require.Equal(t, tt.status, recorder.Code)
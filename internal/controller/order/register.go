@@ -8,9 +8,11 @@ import (
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/context"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/validator"
+	"go.uber.org/zap"
 )
 
 func (container *Container) Register(writer http.ResponseWriter, request *http.Request) {
@@ -27,6 +29,11 @@ func (container *Container) Register(writer http.ResponseWriter, request *http.R
 
 	id, err := io.ReadAll(request.Body)
 	if err != nil {
+		if controller.IsBodyTooLarge(err) {
+			controller.WriteJSONErrorResponse(http.StatusRequestEntityTooLarge, writer, "request body is too large", err)
+			return
+		}
+
 		controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t read request body", err)
 		return
 	}
@@ -42,6 +49,12 @@ func (container *Container) Register(writer http.ResponseWriter, request *http.R
 		return
 	}
 
+	// checked before registration, so the client can safely retry the request
+	if container.orderQueue.Full() {
+		controller.Overloaded(writer, controller.OverloadedRetryAfter)
+		return
+	}
+
 	order, err := container.orderManager.Register(request.Context(), uintID, userID)
 	if err != nil {
 		if errors.Is(err, manager.ErrOrderAlreadyRegisteredByCurrentUser) {
@@ -59,7 +72,10 @@ func (container *Container) Register(writer http.ResponseWriter, request *http.R
 		return
 	}
 
-	container.orderQueue.Push(order.ID)
+	if err := container.orderQueue.TryPush(order.ID); err != nil {
+		// order is stored with NEW status and is queued again after restart
+		logger.Logger.Warn("can`t queue registered order", zap.Uint64("order_id", order.ID), zap.Error(err))
+	}
 	controller.WriteJSONResponse(http.StatusAccepted, responses.Message{
 		Message: "order has been successfully registered for processing",
 	}, writer)
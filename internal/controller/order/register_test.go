@@ -13,6 +13,7 @@ import (
 	userContext "github.com/m1khal3v/gophermart-loyalty-service/internal/context"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
 	managers "github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/middleware"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
 	. "github.com/ovechkin-dm/mockio/mock"
@@ -27,6 +28,8 @@ func TestContainer_Register(t *testing.T) {
 		contentType     string
 		orderID         uint64
 		manager         func() orderManager
+		fullQueue       bool
+		bodyLimit       uint64
 		status          int
 		messageResponse *responses.Message
 		errResponse     *responses.APIError
@@ -174,19 +177,54 @@ func TestContainer_Register(t *testing.T) {
 				Message: "can`t get request credentials",
 			},
 		},
+		{
+			name:        "queue is full",
+			ctx:         userContext.WithUserID(context.Background(), 123),
+			contentType: "text/plain",
+			orderID:     1234566,
+			manager: func() orderManager {
+				return Mock[orderManager]()
+			},
+			fullQueue: true,
+			status:    http.StatusServiceUnavailable,
+			errResponse: &responses.APIError{
+				Code:    http.StatusServiceUnavailable,
+				Message: "service is overloaded, try again later",
+			},
+		},
+		{
+			name:        "body too large",
+			ctx:         userContext.WithUserID(context.Background(), 123),
+			contentType: "text/plain",
+			orderID:     1234566,
+			manager: func() orderManager {
+				return Mock[orderManager]()
+			},
+			bodyLimit: 4,
+			status:    http.StatusRequestEntityTooLarge,
+			errResponse: &responses.APIError{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: "request body is too large",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			SetUp(t)
 			manager := tt.manager()
 			queue := queue.New[uint64](1)
+			if tt.fullQueue {
+				queue.Push(0)
+			}
 			container := NewContainer(manager, queue)
 			recorder := httptest.NewRecorder()
 
 			request := httptest.NewRequest(http.MethodPost, "/api/user/order", bytes.NewBuffer([]byte(strconv.FormatUint(tt.orderID, 10)))).WithContext(tt.ctx)
 			request.Header.Set("Content-Type", tt.contentType)
 
-			container.Register(recorder, request)
+			middleware.Decompress(&middleware.DecompressConfig{
+				MaxDecompressedSize: tt.bodyLimit,
+			})(http.HandlerFunc(container.Register)).ServeHTTP(recorder, request)
 
 			require.Equal(t, tt.status, recorder.Code)
 
@@ -208,6 +246,11 @@ func TestContainer_Register(t *testing.T) {
 				assert.Equal(t, tt.orderID, orderID)
 			}
 
+			if tt.fullQueue {
+				assert.Equal(t, "10", recorder.Header().Get("Retry-After"))
+				queue.Pop()
+			}
+
 			_, ok := queue.Pop()
 			require.False(t, ok)
 		})
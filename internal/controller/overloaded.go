@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+)
+
+// OverloadedRetryAfter is suggested to clients when processing queues are full
+const OverloadedRetryAfter = time.Second * 10
+
+func Overloaded(writer http.ResponseWriter, retryAfter time.Duration) {
+	writer.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+	WriteJSONErrorResponse(http.StatusServiceUnavailable, writer, "service is overloaded, try again later", queue.ErrFull)
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 )
 
 type withdrawalManager interface {
-	FindByUser(ctx context.Context, userID uint32) (<-chan *entity.Withdrawal, error)
+	FindByUser(ctx context.Context, userID uint32) (*generator.Stream[*entity.Withdrawal], error)
 	HasUser(ctx context.Context, userID uint32) (bool, error)
 }
 
@@ -32,14 +32,16 @@ func (container *Container) List(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
+	defer withdrawals.Close()
+
 	if err := controller.StreamJSONResponse(http.StatusOK, withdrawals, func(item *entity.Withdrawal) any {
 		return responses.Withdrawal{
 			Order:       item.OrderID,
-			Sum:         item.Sum.AsFloat(),
+			Sum:         item.Sum,
 			ProcessedAt: item.CreatedAt,
 		}
 	}, writer); err != nil {
-		controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t get user withdrawals", err)
+		controller.WriteStreamErrorResponse(writer, "can`t get user withdrawals", err)
 		return
 	}
 }
@@ -11,6 +11,7 @@ import (
 
 	userContext "github.com/m1khal3v/gophermart-loyalty-service/internal/context"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
 	. "github.com/ovechkin-dm/mockio/mock"
@@ -31,16 +32,15 @@ func TestContainer_List(t *testing.T) {
 			name: "valid withdrawals",
 			ctx:  userContext.WithUserID(context.Background(), 123),
 			manager: func() withdrawalManager {
-				channel := make(chan *entity.Withdrawal, 4)
+				items := make([]*entity.Withdrawal, 0, 4)
 				for i := 1; i <= 4; i++ {
-					channel <- &entity.Withdrawal{
+					items = append(items, &entity.Withdrawal{
 						OrderID:   uint64(i),
 						UserID:    123,
-						Sum:       money.New(float64(i) * 1.11),
+						Sum:       money.Amount(i * 111),
 						CreatedAt: time.Unix(int64(i), int64(i)).UTC(),
-					}
+					})
 				}
-				close(channel)
 				manager := Mock[withdrawalManager]()
 				WhenDouble(manager.HasUser(
 					AnyContext(),
@@ -50,7 +50,7 @@ func TestContainer_List(t *testing.T) {
 				WhenDouble(manager.FindByUser(
 					AnyContext(),
 					Exact(uint32(123)),
-				)).ThenReturn(channel, nil).
+				)).ThenReturn(generator.NewStreamFromSlice(items, nil), nil).
 					Verify(Once())
 
 				return manager
@@ -59,22 +59,22 @@ func TestContainer_List(t *testing.T) {
 			response: []responses.Withdrawal{
 				{
 					Order:       1,
-					Sum:         1.11,
+					Sum:         money.MustParse("1.11"),
 					ProcessedAt: time.Unix(1, 1).UTC(),
 				},
 				{
 					Order:       2,
-					Sum:         2.22,
+					Sum:         money.MustParse("2.22"),
 					ProcessedAt: time.Unix(2, 2).UTC(),
 				},
 				{
 					Order:       3,
-					Sum:         3.33,
+					Sum:         money.MustParse("3.33"),
 					ProcessedAt: time.Unix(3, 3).UTC(),
 				},
 				{
 					Order:       4,
-					Sum:         4.44,
+					Sum:         money.MustParse("4.44"),
 					ProcessedAt: time.Unix(4, 4).UTC(),
 				},
 			},
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+)
+
+const (
+	DeadLetterSourceRetriever  string = "retriever"
+	DeadLetterSourceRouter     string = "router"
+	DeadLetterSourceProcessing string = "processing"
+	DeadLetterSourceInvalid    string = "invalid"
+	DeadLetterSourceProcessed  string = "processed"
+)
+
+// DeadLetter is an order excluded from processing because it can`t be processed automatically
+type DeadLetter struct {
+	ID      uint64 `gorm:"primaryKey;autoIncrement"`
+	OrderID uint64 `gorm:"not null;uniqueIndex:idx_dead_letter_order_id"`
+
+	// Source is the processor which gave up the order
+	Source string `gorm:"not null;size:16;default:'retriever'"`
+	// Status and Accrual are the last response of the accrual system, if any
+	Status   string        `gorm:"not null;size:16;default:''"`
+	Accrual  *money.Amount `gorm:"null"`
+	Attempts uint64        `gorm:"not null;default:1"`
+	Reason   string        `gorm:"not null;size:255"`
+
+	CreatedAt time.Time `gorm:"not null;autoCreateTime"`
+}
@@ -3,22 +3,27 @@ package entity
 import (
 	"time"
 
-	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/bcrypt"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/phc"
 )
 
 type User struct {
 	ID uint32 `gorm:"primaryKey;autoIncrement"`
 
-	Login    string      `gorm:"not null;size:32;uniqueIndex:idx_user_login"`
-	Password bcrypt.Hash `gorm:"not null"`
+	Login    string   `gorm:"not null;size:32;uniqueIndex:idx_user_login"`
+	Password phc.Hash `gorm:"not null;size:255"`
 
 	Balance   money.Amount `gorm:"not null;default:0"`
 	Withdrawn money.Amount `gorm:"not null;default:0"`
 
+	// TokenVersion is incremented to revoke all issued tokens
+	TokenVersion uint32 `gorm:"not null;default:0"`
+
 	Orders      []Order      `gorm:"foreignKey:UserID"`
 	Withdrawals []Withdrawal `gorm:"foreignKey:UserID"`
 
 	CreatedAt time.Time `gorm:"not null;autoCreateTime"`
 	UpdatedAt time.Time `gorm:"not null;autoUpdateTime"`
+	// DeletedAt is set when account is deleted. Rows are never removed to keep orders and withdrawals for audit
+	DeletedAt *time.Time
 }
@@ -15,6 +15,8 @@ type Claims struct {
 	jwt.RegisteredClaims
 
 	SubjectID uint32 `json:"sub_id"`
+	// Version is a subject token version, tokens with outdated version are revoked
+	Version uint32 `json:"ver"`
 }
 
 func (claims Claims) GetSubjectID() uint32 {
@@ -31,10 +33,11 @@ func New(secret string) *Container {
 	}
 }
 
-func (container *Container) Encode(subjectID uint32, subject string) (string, error) {
+func (container *Container) Encode(subjectID uint32, subject string, version uint32) (string, error) {
 	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS512, Claims{
 		SubjectID: subjectID,
+		Version:   version,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   subject,
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -23,7 +23,7 @@ func TestEncodeDecode(t *testing.T) {
 	require.NoError(t, err)
 	subjectString := fmt.Sprintf("%x", subject)
 
-	token, err := jwt.Encode(id, subjectString)
+	token, err := jwt.Encode(id, subjectString, 3)
 	require.NoError(t, err)
 
 	claims, err := jwt.Decode(token)
@@ -31,4 +31,5 @@ func TestEncodeDecode(t *testing.T) {
 
 	assert.Equal(t, id, claims.SubjectID)
 	assert.Equal(t, subjectString, claims.Subject)
+	assert.Equal(t, uint32(3), claims.Version)
 }
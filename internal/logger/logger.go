@@ -8,6 +8,9 @@ import (
 )
 
 var Logger = zap.NewNop()
+
+// Audit logs security related events (e.g. login lockouts)
+var Audit = zap.NewNop()
 var once sync.Once
 
 func Init(name, level string) {
@@ -25,6 +28,7 @@ func Init(name, level string) {
 		}
 
 		Logger = logger.Named(name)
+		Audit = Logger.Named("audit")
 	})
 }
 
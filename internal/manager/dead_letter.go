@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// maxReasonLength matches dead_letters.reason column size
+const maxReasonLength = 255
+
+type deadLetterRepository interface {
+	Upsert(ctx context.Context, deadLetter *entity.DeadLetter) error
+	FindOneByID(ctx context.Context, id uint64) (*entity.DeadLetter, error)
+	FindAll(ctx context.Context) (*generator.Stream[*entity.DeadLetter], error)
+	DeleteByID(ctx context.Context, id uint64) (bool, error)
+}
+
+type DeadLetterManager struct {
+	deadLetterRepository deadLetterRepository
+}
+
+func NewDeadLetterManager(deadLetterRepository deadLetterRepository) *DeadLetterManager {
+	return &DeadLetterManager{
+		deadLetterRepository: deadLetterRepository,
+	}
+}
+
+// Add stores order given up by the retriever
+func (manager *DeadLetterManager) Add(ctx context.Context, orderID uint64, reason string) error {
+	return manager.create(ctx, &entity.DeadLetter{
+		OrderID:  orderID,
+		Source:   entity.DeadLetterSourceRetriever,
+		Attempts: 1,
+		Reason:   reason,
+	})
+}
+
+// AddAccrual stores accrual given up by the status processor
+func (manager *DeadLetterManager) AddAccrual(ctx context.Context, source string, accrual *responses.Accrual, attempts uint64, reason string) error {
+	return manager.create(ctx, &entity.DeadLetter{
+		OrderID:  accrual.OrderID,
+		Source:   source,
+		Status:   accrual.Status,
+		Accrual:  accrual.Accrual,
+		Attempts: attempts,
+		Reason:   reason,
+	})
+}
+
+func (manager *DeadLetterManager) FindAll(ctx context.Context) (*generator.Stream[*entity.DeadLetter], error) {
+	return manager.deadLetterRepository.FindAll(ctx)
+}
+
+// Replay removes dead letter, so its order can be returned to processing
+func (manager *DeadLetterManager) Replay(ctx context.Context, id uint64) (*entity.DeadLetter, error) {
+	deadLetter, err := manager.deadLetterRepository.FindOneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter == nil {
+		return nil, ErrDeadLetterNotFound
+	}
+
+	deleted, err := manager.deadLetterRepository.DeleteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		// concurrently replayed
+		return nil, ErrDeadLetterNotFound
+	}
+
+	return deadLetter, nil
+}
+
+func (manager *DeadLetterManager) create(ctx context.Context, deadLetter *entity.DeadLetter) error {
+	// column size is in characters, so multi-byte characters are not split
+	if reason := []rune(deadLetter.Reason); len(reason) > maxReasonLength {
+		deadLetter.Reason = string(reason[:maxReasonLength])
+	}
+
+	return manager.deadLetterRepository.Upsert(ctx, deadLetter)
+}
@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterManager_Add(t *testing.T) {
+	someErr := errors.New("some error")
+	tests := []struct {
+		name       string
+		reason     string
+		wantReason string
+		err        error
+	}{
+		{
+			name:       "short reason",
+			reason:     "unknown accrual status",
+			wantReason: "unknown accrual status",
+		},
+		{
+			name:       "long reason",
+			reason:     strings.Repeat("a", maxReasonLength+10),
+			wantReason: strings.Repeat("a", maxReasonLength),
+		},
+		{
+			name:       "long multi-byte reason",
+			reason:     strings.Repeat("я", maxReasonLength+10),
+			wantReason: strings.Repeat("я", maxReasonLength),
+		},
+		{
+			name:       "error",
+			reason:     "reason",
+			wantReason: "reason",
+			err:        someErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+
+			repository := Mock[deadLetterRepository]()
+			WhenSingle(repository.Upsert(
+				AnyContext(),
+				Match(CreateMatcher[*entity.DeadLetter]("dead letter", func(args []any, deadLetter *entity.DeadLetter) bool {
+					return deadLetter.OrderID == 1 && deadLetter.Source == entity.DeadLetterSourceRetriever && deadLetter.Reason == tt.wantReason
+				})),
+			)).ThenReturn(tt.err).
+				Verify(Once())
+
+			manager := NewDeadLetterManager(repository)
+			err := manager.Add(context.Background(), 1, tt.reason)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetterManager_AddAccrual(t *testing.T) {
+	SetUp(t)
+
+	accrual := money.Amount(500)
+	repository := Mock[deadLetterRepository]()
+	WhenSingle(repository.Upsert(
+		AnyContext(),
+		Equal(&entity.DeadLetter{
+			OrderID:  1,
+			Source:   entity.DeadLetterSourceProcessed,
+			Status:   responses.AccrualStatusProcessed,
+			Accrual:  &accrual,
+			Attempts: 10,
+			Reason:   "order not found",
+		}),
+	)).ThenReturn(nil).
+		Verify(Once())
+
+	manager := NewDeadLetterManager(repository)
+	require.NoError(t, manager.AddAccrual(context.Background(), entity.DeadLetterSourceProcessed, &responses.Accrual{
+		OrderID: 1,
+		Status:  responses.AccrualStatusProcessed,
+		Accrual: &accrual,
+	}, 10, "order not found"))
+}
+
+func TestDeadLetterManager_Replay(t *testing.T) {
+	someErr := errors.New("some error")
+	deadLetter := &entity.DeadLetter{ID: 1, OrderID: 2}
+	tests := []struct {
+		name       string
+		found      *entity.DeadLetter
+		findErr    error
+		deleted    bool
+		deleteErr  error
+		wantDelete bool
+		want       *entity.DeadLetter
+		err        error
+	}{
+		{
+			name:       "replayed",
+			found:      deadLetter,
+			deleted:    true,
+			wantDelete: true,
+			want:       deadLetter,
+		},
+		{
+			name: "not found",
+			err:  ErrDeadLetterNotFound,
+		},
+		{
+			name:    "find error",
+			findErr: someErr,
+			err:     someErr,
+		},
+		{
+			name:       "concurrently replayed",
+			found:      deadLetter,
+			wantDelete: true,
+			err:        ErrDeadLetterNotFound,
+		},
+		{
+			name:       "delete error",
+			found:      deadLetter,
+			deleteErr:  someErr,
+			wantDelete: true,
+			err:        someErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+
+			repository := Mock[deadLetterRepository]()
+			WhenDouble(repository.FindOneByID(AnyContext(), Exact(uint64(1)))).
+				ThenReturn(tt.found, tt.findErr).
+				Verify(Once())
+			WhenDouble(repository.DeleteByID(AnyContext(), Exact(uint64(1)))).
+				ThenReturn(tt.deleted, tt.deleteErr)
+
+			manager := NewDeadLetterManager(repository)
+			replayed, err := manager.Replay(context.Background(), 1)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, replayed)
+
+			if tt.wantDelete {
+				Verify(repository, Once()).DeleteByID(AnyContext(), Exact(uint64(1)))
+			} else {
+				Verify(repository, Never()).DeleteByID(AnyContext(), Exact(uint64(1)))
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 )
 
 var ErrOrderAlreadyRegisteredByCurrentUser = errors.New("order already registered by current user")
@@ -13,7 +14,7 @@ var ErrOrderAlreadyRegisteredByAnotherUser = errors.New("order already registere
 type orderRepository interface {
 	CreateOrFind(ctx context.Context, order *entity.Order) (*entity.Order, bool, error)
 	FindOneByUserID(ctx context.Context, userID uint32) (*entity.Order, error)
-	FindByUserID(ctx context.Context, userID uint32) (<-chan *entity.Order, error)
+	FindByUserID(ctx context.Context, userID uint32) (*generator.Stream[*entity.Order], error)
 	UpdateStatus(ctx context.Context, ids []uint64, status string) error
 }
 
@@ -49,7 +50,7 @@ func (manager *OrderManager) Register(ctx context.Context, id uint64, userID uin
 	return nil, ErrOrderAlreadyRegisteredByAnotherUser
 }
 
-func (manager *OrderManager) FindByUser(ctx context.Context, userID uint32) (<-chan *entity.Order, error) {
+func (manager *OrderManager) FindByUser(ctx context.Context, userID uint32) (*generator.Stream[*entity.Order], error) {
 	return manager.orderRepository.FindByUserID(ctx, userID)
 }
 
@@ -3,37 +3,71 @@ package manager
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/jwt"
-	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/bcrypt"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/phc"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/lockout"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 var ErrLoginAlreadyExists = errors.New("login already exists")
 var ErrInvalidCredentials = errors.New("invalid credentials")
 var ErrUserNotFound = errors.New("user not found")
+var ErrTokenRevoked = errors.New("token revoked")
+
+type ErrLoginLocked struct {
+	RetryAfter time.Duration
+}
+
+func (err ErrLoginLocked) Error() string {
+	return fmt.Sprintf("login locked for %s", err.RetryAfter)
+}
 
 type userRepository interface {
 	Create(ctx context.Context, entity *entity.User) error
 	FindOneByLogin(ctx context.Context, login string) (*entity.User, error)
 	FindByID(ctx context.Context, id uint32) (*entity.User, error)
+	UpdatePassword(ctx context.Context, id uint32, current, replacement phc.Hash) (bool, error)
+	ChangePassword(ctx context.Context, id uint32, current, replacement phc.Hash) (bool, error)
+	Anonymize(ctx context.Context, id uint32, login string) (bool, error)
 }
 
 type UserManager struct {
 	userRepository userRepository
 	jwt            *jwt.Container
+	passwordPolicy phc.Policy
+	lockout        *lockout.Lockout
+	// dummyHash is compared with passwords of unknown logins, so they take as long as known ones
+	dummyHash phc.Hash
 }
 
-func NewUserManager(userRepository userRepository, jwt *jwt.Container) *UserManager {
+func NewUserManager(
+	userRepository userRepository,
+	jwt *jwt.Container,
+	passwordPolicy phc.Policy,
+	lockout *lockout.Lockout,
+) *UserManager {
+	dummyHash, err := phc.NewHash("dummy password", passwordPolicy)
+	if err != nil {
+		panic(err)
+	}
+
 	return &UserManager{
 		userRepository: userRepository,
 		jwt:            jwt,
+		passwordPolicy: passwordPolicy,
+		lockout:        lockout,
+		dummyHash:      dummyHash,
 	}
 }
 
 func (manager *UserManager) Register(ctx context.Context, login, password string) (string, error) {
-	hash, err := bcrypt.NewHash(password, bcrypt.RecommendedCost)
+	hash, err := phc.NewHash(password, manager.passwordPolicy)
 	if err != nil {
 		return "", err
 	}
@@ -51,7 +85,7 @@ func (manager *UserManager) Register(ctx context.Context, login, password string
 		return "", err
 	}
 
-	token, err := manager.jwt.Encode(user.ID, user.Login)
+	token, err := manager.jwt.Encode(user.ID, user.Login, user.TokenVersion)
 	if err != nil {
 		return "", err
 	}
@@ -60,19 +94,38 @@ func (manager *UserManager) Register(ctx context.Context, login, password string
 }
 
 func (manager *UserManager) Authorize(ctx context.Context, login, password string) (string, error) {
+	// attempt is counted before the password is compared, so parallel attempts can not bypass the lockout
+	allowed, locked := manager.lockout.Attempt(login)
+	if !allowed {
+		logger.Audit.Warn("login attempt rejected: login is locked", zap.String("login", login), zap.Duration("retry_after", locked))
+		return "", ErrLoginLocked{RetryAfter: locked}
+	}
+
 	user, err := manager.userRepository.FindOneByLogin(ctx, login)
 	if err != nil {
+		// storage failure is not a failed attempt, so an outage does not lock out valid users
+		manager.lockout.Release(login)
 		return "", err
 	}
 
-	if user == nil {
+	if user == nil || user.DeletedAt != nil {
+		_ = manager.dummyHash.CompareWithPassword(password)
+		manager.fail(login, locked)
+
 		return "", ErrInvalidCredentials
 	}
 	if err := user.Password.CompareWithPassword(password); err != nil {
+		manager.fail(login, locked)
+
 		return "", ErrInvalidCredentials
 	}
 
-	token, err := manager.jwt.Encode(user.ID, user.Login)
+	manager.lockout.Reset(login)
+	if user.Password.NeedsRehash(manager.passwordPolicy) {
+		manager.rehash(ctx, user, password)
+	}
+
+	token, err := manager.jwt.Encode(user.ID, user.Login, user.TokenVersion)
 	if err != nil {
 		return "", err
 	}
@@ -80,6 +133,28 @@ func (manager *UserManager) Authorize(ctx context.Context, login, password strin
 	return token, nil
 }
 
+func (manager *UserManager) fail(login string, locked time.Duration) {
+	if locked > 0 {
+		logger.Audit.Warn("login locked", zap.String("login", login), zap.Duration("duration", locked))
+	}
+}
+
+// rehash upgrades weak password hash. Failure is not critical: the user will be rehashed on the next login
+func (manager *UserManager) rehash(ctx context.Context, user *entity.User, password string) {
+	hash, err := phc.NewHash(password, manager.passwordPolicy)
+	if err != nil {
+		logger.Logger.Warn("can`t rehash password", zap.Uint32("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	if _, err := manager.userRepository.UpdatePassword(ctx, user.ID, user.Password, hash); err != nil {
+		logger.Logger.Warn("can`t update password", zap.Uint32("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	user.Password = hash
+}
+
 func (manager *UserManager) FindByID(ctx context.Context, id uint32) (*entity.User, error) {
 	user, err := manager.userRepository.FindByID(ctx, id)
 	if err != nil {
@@ -91,3 +166,71 @@ func (manager *UserManager) FindByID(ctx context.Context, id uint32) (*entity.Us
 
 	return user, nil
 }
+
+// ChangePassword sets new password and revokes all issued tokens. Returns a new token for the current session.
+// Current password is checked under the login lockout, so a stolen token can not be used to guess it
+func (manager *UserManager) ChangePassword(ctx context.Context, id uint32, oldPassword, newPassword string) (string, error) {
+	user, err := manager.FindByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if user.DeletedAt != nil {
+		return "", ErrUserNotFound
+	}
+
+	allowed, locked := manager.lockout.Attempt(user.Login)
+	if !allowed {
+		logger.Audit.Warn("password change rejected: login is locked", zap.String("login", user.Login), zap.Duration("retry_after", locked))
+		return "", ErrLoginLocked{RetryAfter: locked}
+	}
+	if err := user.Password.CompareWithPassword(oldPassword); err != nil {
+		manager.fail(user.Login, locked)
+
+		return "", ErrInvalidCredentials
+	}
+	manager.lockout.Reset(user.Login)
+
+	hash, err := phc.NewHash(newPassword, manager.passwordPolicy)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := manager.userRepository.ChangePassword(ctx, user.ID, user.Password, hash)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		// password was changed or account was deleted concurrently
+		return "", ErrInvalidCredentials
+	}
+
+	return manager.jwt.Encode(user.ID, user.Login, user.TokenVersion+1)
+}
+
+// Delete anonymizes user and revokes all issued tokens. Financial records are kept
+func (manager *UserManager) Delete(ctx context.Context, id uint32) error {
+	// ":" is not allowed in registered logins, so anonymized login never conflicts with a real one
+	ok, err := manager.userRepository.Anonymize(ctx, id, fmt.Sprintf("deleted:%d", id))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ValidateToken checks that token was not revoked by password change or account deletion.
+// User is looked up by primary key on every authorized request, so revocation takes effect immediately
+func (manager *UserManager) ValidateToken(ctx context.Context, claims *jwt.Claims) error {
+	user, err := manager.userRepository.FindByID(ctx, claims.SubjectID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.DeletedAt != nil || user.TokenVersion != claims.Version {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/repository"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 )
 
 type UserOrderManager struct {
@@ -16,11 +17,11 @@ func NewUserOrderManager(userOrderRepository *repository.UserOrderRepository) *U
 	}
 }
 
-func (manager *UserOrderManager) Accrue(ctx context.Context, orderID uint64, accrual float64) error {
+func (manager *UserOrderManager) Accrue(ctx context.Context, orderID uint64, accrual money.Amount) error {
 	return manager.userOrderRepository.Accrue(ctx, orderID, accrual)
 }
 
-func (manager *UserOrderManager) AccrueBatch(ctx context.Context, accruals map[uint64]float64) error {
+func (manager *UserOrderManager) AccrueBatch(ctx context.Context, accruals map[uint64]money.Amount) error {
 	return manager.userOrderRepository.Transaction(ctx, func(ctx context.Context, repository *repository.UserOrderRepository) error {
 		for orderID, accrual := range accruals {
 			if err := repository.Accrue(ctx, orderID, accrual); err != nil {
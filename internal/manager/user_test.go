@@ -5,16 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/jwt"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/bcrypt"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/phc"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/lockout"
 	. "github.com/ovechkin-dm/mockio/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
+var testPasswordPolicy = phc.Policy{
+	Algorithm: phc.Argon2id,
+	Argon2id: phc.Argon2idParams{
+		Memory:      64,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	},
+}
+
 func TestUserManager_Register(t *testing.T) {
 	someErr := errors.New("some error")
 	tests := []struct {
@@ -83,7 +97,7 @@ func TestUserManager_Register(t *testing.T) {
 			i++
 			repository := tt.repository()
 			jwt := jwt.New(fmt.Sprintf("secret_%d", i))
-			manager := NewUserManager(repository, jwt)
+			manager := NewUserManager(repository, jwt, testPasswordPolicy, lockout.New(&lockout.Config{}))
 
 			token, err := manager.Register(context.Background(), fmt.Sprintf("login_%d", i), fmt.Sprintf("password_%d", i))
 			if tt.wantErr != nil {
@@ -116,27 +130,90 @@ func TestUserManager_Authorize(t *testing.T) {
 			name: "ok",
 			repository: func() userRepository {
 				repository := Mock[userRepository]()
-				password, _ := bcrypt.NewHash("password_1", bcrypt.MinCost)
+				password, _ := phc.NewHash("password_1", testPasswordPolicy)
 				WhenDouble(repository.FindOneByLogin(
 					AnyContext(),
 					Exact("login_1"),
 				)).ThenReturn(&entity.User{
+					ID:       1,
 					Login:    "login_1",
 					Password: password,
 				}, nil).
 					Verify(Once())
+				WhenDouble(repository.UpdatePassword(
+					AnyContext(),
+					Any[uint32](),
+					Any[phc.Hash](),
+					Any[phc.Hash](),
+				)).ThenReturn(false, nil).
+					Verify(Never())
 
 				return repository
 			},
 			wantToken: true,
 		},
 		{
-			name: "user not found",
+			name: "ok with rehash",
 			repository: func() userRepository {
 				repository := Mock[userRepository]()
+				password, _ := phc.NewHash("password_2", phc.Policy{Algorithm: phc.Bcrypt, BcryptCost: bcrypt.MinCost})
 				WhenDouble(repository.FindOneByLogin(
 					AnyContext(),
 					Exact("login_2"),
+				)).ThenReturn(&entity.User{
+					ID:       2,
+					Login:    "login_2",
+					Password: password,
+				}, nil).
+					Verify(Once())
+				WhenDouble(repository.UpdatePassword(
+					AnyContext(),
+					Exact[uint32](2),
+					Exact(password),
+					Match(CreateMatcher("argon2id hash", func(allArgs []any, actual phc.Hash) bool {
+						return !actual.NeedsRehash(testPasswordPolicy) &&
+							actual.CompareWithPassword("password_2") == nil
+					})),
+				)).ThenReturn(true, nil).
+					Verify(Once())
+
+				return repository
+			},
+			wantToken: true,
+		},
+		{
+			name: "ok with failed rehash",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				password, _ := phc.NewHash("password_3", phc.Policy{Algorithm: phc.Bcrypt, BcryptCost: bcrypt.MinCost})
+				WhenDouble(repository.FindOneByLogin(
+					AnyContext(),
+					Exact("login_3"),
+				)).ThenReturn(&entity.User{
+					ID:       3,
+					Login:    "login_3",
+					Password: password,
+				}, nil).
+					Verify(Once())
+				WhenDouble(repository.UpdatePassword(
+					AnyContext(),
+					Exact[uint32](3),
+					Exact(password),
+					Any[phc.Hash](),
+				)).ThenReturn(false, someErr).
+					Verify(Once())
+
+				return repository
+			},
+			wantToken: true,
+		},
+		{
+			name: "user not found",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				WhenDouble(repository.FindOneByLogin(
+					AnyContext(),
+					Exact("login_4"),
 				)).ThenReturn(nil, nil).
 					Verify(Once())
 
@@ -148,15 +225,22 @@ func TestUserManager_Authorize(t *testing.T) {
 			name: "invalid password",
 			repository: func() userRepository {
 				repository := Mock[userRepository]()
-				password, _ := bcrypt.NewHash("password_invalid", bcrypt.MinCost)
+				password, _ := phc.NewHash("password_invalid", phc.Policy{Algorithm: phc.Bcrypt, BcryptCost: bcrypt.MinCost})
 				WhenDouble(repository.FindOneByLogin(
 					AnyContext(),
-					Exact("login_3"),
+					Exact("login_5"),
 				)).ThenReturn(&entity.User{
-					Login:    "login_3",
+					Login:    "login_5",
 					Password: password,
 				}, nil).
 					Verify(Once())
+				WhenDouble(repository.UpdatePassword(
+					AnyContext(),
+					Any[uint32](),
+					Any[phc.Hash](),
+					Any[phc.Hash](),
+				)).ThenReturn(false, nil).
+					Verify(Never())
 
 				return repository
 			},
@@ -168,7 +252,7 @@ func TestUserManager_Authorize(t *testing.T) {
 				repository := Mock[userRepository]()
 				WhenDouble(repository.FindOneByLogin(
 					AnyContext(),
-					Exact("login_4"),
+					Exact("login_6"),
 				)).ThenReturn(nil, someErr).
 					Verify(Once())
 
@@ -183,7 +267,7 @@ func TestUserManager_Authorize(t *testing.T) {
 			i++
 			repository := tt.repository()
 			jwt := jwt.New(fmt.Sprintf("secret_%d", i))
-			manager := NewUserManager(repository, jwt)
+			manager := NewUserManager(repository, jwt, testPasswordPolicy, lockout.New(&lockout.Config{}))
 
 			token, err := manager.Authorize(context.Background(), fmt.Sprintf("login_%d", i), fmt.Sprintf("password_%d", i))
 			if tt.wantErr != nil {
@@ -204,6 +288,74 @@ func TestUserManager_Authorize(t *testing.T) {
 	}
 }
 
+func TestUserManager_AuthorizeLockout(t *testing.T) {
+	SetUp(t)
+	password, err := phc.NewHash("password", testPasswordPolicy)
+	require.NoError(t, err)
+	now := time.Now()
+	user := &entity.User{ID: 1, Login: "login", Password: password}
+	repository := Mock[userRepository]()
+	WhenDouble(repository.FindOneByLogin(AnyContext(), Exact("unknown"))).
+		ThenReturn(nil, nil).
+		Verify(Times(2))
+	WhenDouble(repository.FindOneByLogin(AnyContext(), Exact("deleted:2"))).
+		ThenReturn(&entity.User{ID: 2, Login: "deleted:2", DeletedAt: &now}, nil).
+		Verify(Once())
+	WhenDouble(repository.FindOneByLogin(AnyContext(), Exact("login"))).
+		ThenReturn(user, nil).
+		Verify(Times(3))
+	duration := time.Minute
+	manager := NewUserManager(repository, jwt.New("secret"), testPasswordPolicy, lockout.New(&lockout.Config{
+		Threshold:    2,
+		BaseDuration: &duration,
+	}))
+	ctx := context.Background()
+
+	_, err = manager.Authorize(ctx, "unknown", "password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = manager.Authorize(ctx, "unknown", "password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = manager.Authorize(ctx, "unknown", "password")
+	locked := ErrLoginLocked{}
+	require.ErrorAs(t, err, &locked)
+	assert.InDelta(t, time.Minute, locked.RetryAfter, float64(time.Second))
+
+	_, err = manager.Authorize(ctx, "deleted:2", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = manager.Authorize(ctx, "login", "invalid")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = manager.Authorize(ctx, "login", "password")
+	require.NoError(t, err)
+	_, err = manager.Authorize(ctx, "login", "invalid")
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "successful login resets failures")
+}
+
+func TestUserManager_AuthorizeLockoutStorageError(t *testing.T) {
+	SetUp(t)
+	password, err := phc.NewHash("password", testPasswordPolicy)
+	require.NoError(t, err)
+	user := &entity.User{ID: 1, Login: "login", Password: password}
+	someErr := errors.New("database is down")
+	repository := Mock[userRepository]()
+	WhenDouble(repository.FindOneByLogin(AnyContext(), Exact("login"))).
+		ThenReturn(nil, someErr).
+		ThenReturn(nil, someErr).
+		ThenReturn(nil, someErr).
+		ThenReturn(user, nil)
+	manager := NewUserManager(repository, jwt.New("secret"), testPasswordPolicy, lockout.New(&lockout.Config{
+		Threshold: 2,
+	}))
+	ctx := context.Background()
+
+	for range 3 {
+		_, err = manager.Authorize(ctx, "login", "password")
+		require.ErrorIs(t, err, someErr)
+	}
+	_, err = manager.Authorize(ctx, "login", "password")
+	require.NoError(t, err)
+}
+
 func TestUserManager_FindByID(t *testing.T) {
 	someErr := errors.New("some error")
 	tests := []struct {
@@ -267,7 +419,7 @@ func TestUserManager_FindByID(t *testing.T) {
 			SetUp(t)
 			repository := tt.repository()
 			jwt := jwt.New(fmt.Sprintf("secret_%d", id))
-			manager := NewUserManager(repository, jwt)
+			manager := NewUserManager(repository, jwt, testPasswordPolicy, lockout.New(&lockout.Config{}))
 
 			got, err := manager.FindByID(context.Background(), uint32(id))
 
@@ -280,3 +432,258 @@ func TestUserManager_FindByID(t *testing.T) {
 		})
 	}
 }
+
+func TestUserManager_ChangePassword(t *testing.T) {
+	someErr := errors.New("some error")
+	password, err := phc.NewHash("old_password", testPasswordPolicy)
+	require.NoError(t, err)
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		oldPassword string
+		repository  func() userRepository
+		wantVersion uint32
+		wantErr     error
+	}{
+		{
+			name:        "ok",
+			oldPassword: "old_password",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+					ThenReturn(&entity.User{ID: 1, Login: "login_1", Password: password, TokenVersion: 4}, nil).
+					Verify(Once())
+				WhenDouble(repository.ChangePassword(
+					AnyContext(),
+					Exact[uint32](1),
+					Exact(password),
+					Match(CreateMatcher("new password", func(allArgs []any, actual phc.Hash) bool {
+						return actual.CompareWithPassword("new_password") == nil
+					})),
+				)).ThenReturn(true, nil).
+					Verify(Once())
+
+				return repository
+			},
+			wantVersion: 5,
+		},
+		{
+			name:        "invalid old password",
+			oldPassword: "invalid_password",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+					ThenReturn(&entity.User{ID: 1, Login: "login_1", Password: password}, nil).
+					Verify(Once())
+
+				return repository
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:        "changed concurrently",
+			oldPassword: "old_password",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+					ThenReturn(&entity.User{ID: 1, Login: "login_1", Password: password}, nil).
+					Verify(Once())
+				WhenDouble(repository.ChangePassword(AnyContext(), Exact[uint32](1), Exact(password), Any[phc.Hash]())).
+					ThenReturn(false, nil).
+					Verify(Once())
+
+				return repository
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:        "deleted user",
+			oldPassword: "old_password",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+					ThenReturn(&entity.User{ID: 1, Login: "deleted:1", DeletedAt: &now}, nil).
+					Verify(Once())
+
+				return repository
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:        "user not found",
+			oldPassword: "old_password",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+					ThenReturn(nil, nil).
+					Verify(Once())
+
+				return repository
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:        "db error",
+			oldPassword: "old_password",
+			repository: func() userRepository {
+				repository := Mock[userRepository]()
+				WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+					ThenReturn(&entity.User{ID: 1, Login: "login_1", Password: password}, nil).
+					Verify(Once())
+				WhenDouble(repository.ChangePassword(AnyContext(), Exact[uint32](1), Exact(password), Any[phc.Hash]())).
+					ThenReturn(false, someErr).
+					Verify(Once())
+
+				return repository
+			},
+			wantErr: someErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+			repository := tt.repository()
+			jwt := jwt.New("secret")
+			manager := NewUserManager(repository, jwt, testPasswordPolicy, lockout.New(&lockout.Config{}))
+
+			token, err := manager.ChangePassword(context.Background(), 1, tt.oldPassword, "new_password")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, token)
+				return
+			}
+
+			require.NoError(t, err)
+			claims, err := jwt.Decode(token)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), claims.SubjectID)
+			assert.Equal(t, tt.wantVersion, claims.Version)
+		})
+	}
+}
+
+func TestUserManager_ChangePasswordLockout(t *testing.T) {
+	SetUp(t)
+	password, err := phc.NewHash("old_password", testPasswordPolicy)
+	require.NoError(t, err)
+	repository := Mock[userRepository]()
+	WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+		ThenReturn(&entity.User{ID: 1, Login: "login_1", Password: password}, nil)
+	duration := time.Minute
+	manager := NewUserManager(repository, jwt.New("secret"), testPasswordPolicy, lockout.New(&lockout.Config{
+		Threshold:    2,
+		BaseDuration: &duration,
+	}))
+	ctx := context.Background()
+
+	_, err = manager.ChangePassword(ctx, 1, "invalid", "new_password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = manager.ChangePassword(ctx, 1, "invalid", "new_password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// correct password is not checked while the login is locked
+	_, err = manager.ChangePassword(ctx, 1, "old_password", "new_password")
+	locked := ErrLoginLocked{}
+	require.ErrorAs(t, err, &locked)
+	assert.InDelta(t, time.Minute, locked.RetryAfter, float64(time.Second))
+
+	// login shares the lockout
+	_, err = manager.Authorize(ctx, "login_1", "old_password")
+	require.ErrorAs(t, err, &locked)
+	Verify(repository, Never()).ChangePassword(AnyContext(), Any[uint32](), Any[phc.Hash](), Any[phc.Hash]())
+}
+
+func TestUserManager_Delete(t *testing.T) {
+	someErr := errors.New("some error")
+	tests := []struct {
+		name    string
+		ok      bool
+		err     error
+		wantErr error
+	}{
+		{
+			name: "ok",
+			ok:   true,
+		},
+		{
+			name:    "already deleted",
+			ok:      false,
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:    "db error",
+			err:     someErr,
+			wantErr: someErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+			repository := Mock[userRepository]()
+			WhenDouble(repository.Anonymize(AnyContext(), Exact[uint32](7), Exact("deleted:7"))).
+				ThenReturn(tt.ok, tt.err).
+				Verify(Once())
+			manager := NewUserManager(repository, jwt.New("secret"), testPasswordPolicy, lockout.New(&lockout.Config{}))
+
+			err := manager.Delete(context.Background(), 7)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserManager_ValidateToken(t *testing.T) {
+	someErr := errors.New("some error")
+	now := time.Now()
+	tests := []struct {
+		name    string
+		user    *entity.User
+		err     error
+		wantErr error
+	}{
+		{
+			name: "valid",
+			user: &entity.User{ID: 1, TokenVersion: 2},
+		},
+		{
+			name:    "outdated version",
+			user:    &entity.User{ID: 1, TokenVersion: 3},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name:    "deleted user",
+			user:    &entity.User{ID: 1, TokenVersion: 2, DeletedAt: &now},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name:    "user not found",
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name:    "db error",
+			err:     someErr,
+			wantErr: someErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+			repository := Mock[userRepository]()
+			WhenDouble(repository.FindByID(AnyContext(), Exact[uint32](1))).
+				ThenReturn(tt.user, tt.err).
+				Verify(Once())
+			manager := NewUserManager(repository, jwt.New("secret"), testPasswordPolicy, lockout.New(&lockout.Config{}))
+
+			err := manager.ValidateToken(context.Background(), &jwt.Claims{SubjectID: 1, Version: 2})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
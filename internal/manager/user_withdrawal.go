@@ -5,12 +5,13 @@ import (
 	"errors"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 )
 
 var ErrInsufficientFunds = errors.New("insufficient funds")
 
 type userWithdrawalRepository interface {
-	Withdraw(ctx context.Context, orderID uint64, userID uint32, sum float64) (*entity.Withdrawal, error)
+	Withdraw(ctx context.Context, orderID uint64, userID uint32, sum money.Amount) (*entity.Withdrawal, error)
 }
 
 type UserWithdrawalManager struct {
@@ -23,7 +24,7 @@ func NewUserWithdrawalManager(userWithdrawalRepository userWithdrawalRepository)
 	}
 }
 
-func (manager *UserWithdrawalManager) Withdraw(ctx context.Context, orderID uint64, userID uint32, sum float64) error {
+func (manager *UserWithdrawalManager) Withdraw(ctx context.Context, orderID uint64, userID uint32, sum money.Amount) error {
 	withdrawal, err := manager.userWithdrawalRepository.Withdraw(ctx, orderID, userID, sum)
 	if err != nil {
 		return err
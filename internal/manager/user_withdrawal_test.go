@@ -26,11 +26,11 @@ func TestUserWithdrawalManager_Withdraw(t *testing.T) {
 					AnyContext(),
 					Exact[uint64](1),
 					Exact[uint32](11),
-					Exact(1.11),
+					Exact(money.MustParse("1.11")),
 				)).ThenReturn(&entity.Withdrawal{
 					OrderID: 1,
 					UserID:  1,
-					Sum:     money.New(1.11),
+					Sum:     money.MustParse("1.11"),
 				}, nil).
 					Verify(Once())
 
@@ -45,7 +45,7 @@ func TestUserWithdrawalManager_Withdraw(t *testing.T) {
 					AnyContext(),
 					Exact[uint64](2),
 					Exact[uint32](22),
-					Exact(2.22),
+					Exact(money.MustParse("2.22")),
 				)).ThenReturn(nil, nil).
 					Verify(Once())
 
@@ -61,7 +61,7 @@ func TestUserWithdrawalManager_Withdraw(t *testing.T) {
 					AnyContext(),
 					Exact[uint64](3),
 					Exact[uint32](33),
-					Exact(3.33),
+					Exact(money.MustParse("3.33")),
 				)).ThenReturn(nil, someErr).
 					Verify(Once())
 
@@ -77,7 +77,7 @@ func TestUserWithdrawalManager_Withdraw(t *testing.T) {
 			repository := tt.repository()
 			manager := NewUserWithdrawalManager(repository)
 
-			err := manager.Withdraw(context.Background(), uint64(id), uint32(id)*11, float64(id)*1.11)
+			err := manager.Withdraw(context.Background(), uint64(id), uint32(id)*11, money.Amount(id*111))
 
 			if tt.wantErr != nil {
 				assert.ErrorAs(t, err, &tt.wantErr)
@@ -4,11 +4,12 @@ import (
 	"context"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 )
 
 type withdrawalRepository interface {
 	FindOneByUserID(ctx context.Context, userID uint32) (*entity.Withdrawal, error)
-	FindByUserID(ctx context.Context, userID uint32) (<-chan *entity.Withdrawal, error)
+	FindByUserID(ctx context.Context, userID uint32) (*generator.Stream[*entity.Withdrawal], error)
 }
 
 type WithdrawalManager struct {
@@ -21,7 +22,7 @@ func NewWithdrawalManager(withdrawalRepository withdrawalRepository) *Withdrawal
 	}
 }
 
-func (manager *WithdrawalManager) FindByUser(ctx context.Context, userID uint32) (<-chan *entity.Withdrawal, error) {
+func (manager *WithdrawalManager) FindByUser(ctx context.Context, userID uint32) (*generator.Stream[*entity.Withdrawal], error) {
 	return manager.withdrawalRepository.FindByUserID(ctx, userID)
 }
 
@@ -1,15 +1,23 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strings"
 
-	"github.com/m1khal3v/gophermart-loyalty-service/internal/context"
+	userContext "github.com/m1khal3v/gophermart-loyalty-service/internal/context"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/jwt"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
 )
 
-func ValidateAuthorizationToken(jwt *jwt.Container) func(next http.Handler) http.Handler {
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, claims *jwt.Claims) error
+}
+
+func ValidateAuthorizationToken(jwt *jwt.Container, validator TokenValidator) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 			token := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
@@ -19,7 +27,31 @@ func ValidateAuthorizationToken(jwt *jwt.Container) func(next http.Handler) http
 				return
 			}
 
-			request = request.WithContext(context.WithUserID(request.Context(), claims.SubjectID))
+			if err := validator.ValidateToken(request.Context(), claims); err != nil {
+				if errors.Is(err, manager.ErrTokenRevoked) {
+					controller.WriteJSONErrorResponse(http.StatusUnauthorized, writer, "token revoked", err)
+				} else {
+					controller.WriteJSONErrorResponse(http.StatusInternalServerError, writer, "can`t validate token", err)
+				}
+
+				return
+			}
+
+			request = request.WithContext(userContext.WithUserID(request.Context(), claims.SubjectID))
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// ValidateAdminToken allows request only if X-Admin-Token header matches the token
+func ValidateAdminToken(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(request.Header.Get("X-Admin-Token")), []byte(token)) != 1 {
+				controller.WriteJSONErrorResponse(http.StatusUnauthorized, writer, "invalid admin token", nil)
+				return
+			}
 
 			next.ServeHTTP(writer, request)
 		})
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"math/rand/v2"
 	"net/http"
@@ -9,17 +10,22 @@ import (
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/context"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/jwt"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/manager"
+	. "github.com/ovechkin-dm/mockio/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestValidateAuthorizationTokenOk(t *testing.T) {
-	jwt := jwt.New("secret")
+	SetUp(t)
+	container := jwt.New("secret")
 	userID := rand.Uint32N(1000) + 1
-	token, err := jwt.Encode(userID, fmt.Sprintf("user_%d", userID))
+	token, err := container.Encode(userID, fmt.Sprintf("user_%d", userID), 2)
 	require.NoError(t, err)
+	validator := Mock[TokenValidator]()
+	WhenSingle(validator.ValidateToken(AnyContext(), Any[*jwt.Claims]())).ThenReturn(nil).Verify(Once())
 
-	handler := ValidateAuthorizationToken(jwt)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+	handler := ValidateAuthorizationToken(container, validator)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		requestUserID, ok := context.UserIDFromContext(request.Context())
 		require.True(t, ok)
 		assert.Equal(t, userID, requestUserID)
@@ -33,10 +39,12 @@ func TestValidateAuthorizationTokenOk(t *testing.T) {
 }
 
 func TestValidateAuthorizationTokenUnauthorized(t *testing.T) {
-	jwt := jwt.New("secret")
+	SetUp(t)
+	container := jwt.New("secret")
 	call := false
+	validator := Mock[TokenValidator]()
 
-	handler := ValidateAuthorizationToken(jwt)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+	handler := ValidateAuthorizationToken(container, validator)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		call = true
 	}))
 	request := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -47,3 +55,93 @@ func TestValidateAuthorizationTokenUnauthorized(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, writer.Code)
 	assert.False(t, call)
 }
+
+func TestValidateAuthorizationTokenRevoked(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{
+			name:   "revoked",
+			err:    manager.ErrTokenRevoked,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "validation error",
+			err:    errors.New("some error"),
+			status: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+			container := jwt.New("secret")
+			userID := rand.Uint32N(1000) + 1
+			token, err := container.Encode(userID, fmt.Sprintf("user_%d", userID), 1)
+			require.NoError(t, err)
+			call := false
+			validator := Mock[TokenValidator]()
+			WhenSingle(validator.ValidateToken(
+				AnyContext(),
+				Match(CreateMatcher("token claims", func(allArgs []any, actual *jwt.Claims) bool {
+					return actual.SubjectID == userID && actual.Version == 1
+				})),
+			)).ThenReturn(tt.err).
+				Verify(Once())
+
+			handler := ValidateAuthorizationToken(container, validator)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				call = true
+			}))
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			writer := httptest.NewRecorder()
+
+			handler.ServeHTTP(writer, request)
+			assert.Equal(t, tt.status, writer.Code)
+			assert.False(t, call)
+		})
+	}
+}
+
+func TestValidateAdminToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		status int
+		call   bool
+	}{
+		{
+			name:   "valid token",
+			header: "admin",
+			status: http.StatusOK,
+			call:   true,
+		},
+		{
+			name:   "invalid token",
+			header: "invalid",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "no token",
+			status: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := false
+			handler := ValidateAdminToken("admin")(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				call = true
+			}))
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				request.Header.Set("X-Admin-Token", tt.header)
+			}
+			writer := httptest.NewRecorder()
+
+			handler.ServeHTTP(writer, request)
+			assert.Equal(t, tt.status, writer.Code)
+			assert.Equal(t, tt.call, call)
+		})
+	}
+}
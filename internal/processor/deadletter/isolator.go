@@ -0,0 +1,159 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"go.uber.org/zap"
+)
+
+const DefaultMaxAttempts = 10
+const DefaultFailedTaskDelay = time.Second * 10
+
+type deadLetterManager interface {
+	AddAccrual(ctx context.Context, source string, accrual *responses.Accrual, attempts uint64, reason string) error
+}
+
+// Isolator prevents one bad accrual from poisoning its whole batch: failed batch is bisected
+// until failing accruals are isolated, lease of every one of them is nacked
+// until it fails MaxAttempts times and is moved to dead letters.
+// Attempts are kept in memory only, so the counting starts again after restart
+type Isolator struct {
+	deadLetterManager deadLetterManager
+	mutex             sync.Mutex
+	attempts          map[uint64]uint64
+	config            *Config
+}
+
+type Config struct {
+	// Source is stored to dead letters, see entity.DeadLetterSource* constants
+	Source          string
+	MaxAttempts     uint64
+	FailedTaskDelay *time.Duration
+}
+
+func prepareConfig(config *Config) {
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.FailedTaskDelay == nil || *config.FailedTaskDelay < 0 {
+		defaultValue := DefaultFailedTaskDelay
+		config.FailedTaskDelay = &defaultValue
+	}
+}
+
+func New(deadLetterManager deadLetterManager, config *Config) *Isolator {
+	prepareConfig(config)
+	return &Isolator{
+		deadLetterManager: deadLetterManager,
+		attempts:          make(map[uint64]uint64),
+		config:            config,
+	}
+}
+
+// Process applies process to accruals of leases and returns joined errors of all failed parts.
+// Leases of updated and dead-lettered accruals are acked, the others are nacked with FailedTaskDelay
+func (isolator *Isolator) Process(
+	ctx context.Context,
+	leases []*queue.Lease[*responses.Accrual],
+	process func(ctx context.Context, accruals []*responses.Accrual) error,
+) error {
+	accruals := make([]*responses.Accrual, 0, len(leases))
+	for _, lease := range leases {
+		accruals = append(accruals, lease.Item())
+	}
+
+	err := process(ctx, accruals)
+	if err == nil {
+		isolator.forget(accruals)
+		isolator.release(leases, true)
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		// failure is caused by shutdown, not by accruals
+		isolator.release(leases, false)
+		return err
+	}
+
+	if len(leases) == 1 {
+		return isolator.fail(ctx, leases[0], err)
+	}
+
+	middle := len(leases) / 2
+
+	return errors.Join(
+		isolator.Process(ctx, leases[:middle], process),
+		isolator.Process(ctx, leases[middle:], process),
+	)
+}
+
+func (isolator *Isolator) fail(ctx context.Context, lease *queue.Lease[*responses.Accrual], err error) error {
+	accrual := lease.Item()
+	attempts := isolator.attempt(accrual.OrderID)
+	if attempts < isolator.config.MaxAttempts {
+		isolator.release([]*queue.Lease[*responses.Accrual]{lease}, false)
+		return err
+	}
+
+	if deadLetterErr := isolator.deadLetterManager.AddAccrual(ctx, isolator.config.Source, accrual, attempts, err.Error()); deadLetterErr != nil {
+		isolator.release([]*queue.Lease[*responses.Accrual]{lease}, false)
+		return errors.Join(err, deadLetterErr)
+	}
+
+	isolator.forget([]*responses.Accrual{accrual})
+	isolator.release([]*queue.Lease[*responses.Accrual]{lease}, true)
+	logger.Logger.Error(
+		"order moved to dead letters",
+		zap.Uint64("order_id", accrual.OrderID),
+		zap.String("source", isolator.config.Source),
+		zap.Uint64("attempts", attempts),
+		zap.Error(err),
+	)
+
+	return nil
+}
+
+// release acks or nacks leases. Lease released already has expired by the visibility timeout,
+// so its accrual is queued again and may be updated twice
+func (isolator *Isolator) release(leases []*queue.Lease[*responses.Accrual], ack bool) {
+	for _, lease := range leases {
+		var err error
+		if ack {
+			err = lease.Ack()
+		} else {
+			err = lease.Nack(*isolator.config.FailedTaskDelay)
+		}
+		if err != nil {
+			logger.Logger.Warn(
+				"can`t release accrual lease",
+				zap.Uint64("order_id", lease.Item().OrderID),
+				zap.String("source", isolator.config.Source),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func (isolator *Isolator) attempt(orderID uint64) uint64 {
+	isolator.mutex.Lock()
+	defer isolator.mutex.Unlock()
+
+	isolator.attempts[orderID]++
+
+	return isolator.attempts[orderID]
+}
+
+func (isolator *Isolator) forget(accruals []*responses.Accrual) {
+	isolator.mutex.Lock()
+	defer isolator.mutex.Unlock()
+
+	for _, accrual := range accruals {
+		delete(isolator.attempts, accrual.OrderID)
+	}
+}
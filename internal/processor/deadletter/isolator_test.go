@@ -0,0 +1,167 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAccruals(count uint64) []*responses.Accrual {
+	accruals := make([]*responses.Accrual, 0, count)
+	for i := range count {
+		accruals = append(accruals, &responses.Accrual{
+			OrderID: i + 1,
+			Status:  responses.AccrualStatusInvalid,
+		})
+	}
+
+	return accruals
+}
+
+// receive pushes accruals to the queue and leases them back
+func receive(t *testing.T, accrualQueue *queue.Queue[*responses.Accrual], accruals []*responses.Accrual) []*queue.Lease[*responses.Accrual] {
+	for _, accrual := range accruals {
+		require.NoError(t, accrualQueue.TryPush(accrual))
+	}
+
+	return accrualQueue.TryReceiveBatch(uint64(len(accruals)))
+}
+
+// failOn returns process function failing any batch containing the order and recording succeeded orders
+func failOn(orderID uint64, someErr error, succeeded *[]uint64) func(ctx context.Context, accruals []*responses.Accrual) error {
+	return func(ctx context.Context, accruals []*responses.Accrual) error {
+		if slices.ContainsFunc(accruals, func(accrual *responses.Accrual) bool {
+			return accrual.OrderID == orderID
+		}) {
+			return someErr
+		}
+
+		for _, accrual := range accruals {
+			*succeeded = append(*succeeded, accrual.OrderID)
+		}
+
+		return nil
+	}
+}
+
+func TestIsolator_ProcessOK(t *testing.T) {
+	SetUp(t)
+
+	accrualQueue := queue.New[*responses.Accrual](10)
+	deadLetterManager := Mock[deadLetterManager]()
+	isolator := New(deadLetterManager, &Config{})
+
+	succeeded := make([]uint64, 0)
+	require.NoError(t, isolator.Process(context.Background(), receive(t, accrualQueue, newAccruals(8)), failOn(0, nil, &succeeded)))
+	assert.Len(t, succeeded, 8)
+	assert.EqualValues(t, 0, accrualQueue.Count())
+	assert.EqualValues(t, 0, accrualQueue.InFlightCount())
+	assert.Empty(t, isolator.attempts)
+}
+
+func TestIsolator_ProcessPoison(t *testing.T) {
+	SetUp(t)
+
+	someErr := errors.New("some error")
+	accruals := newAccruals(8)
+	poison := accruals[4]
+
+	accrualQueue := queue.New[*responses.Accrual](10)
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenSingle(deadLetterManager.AddAccrual(
+		AnyContext(),
+		Exact(entity.DeadLetterSourceInvalid),
+		Exact(poison),
+		Exact(uint64(2)),
+		Exact(someErr.Error()),
+	)).ThenReturn(nil)
+
+	noDelay := time.Duration(0)
+	isolator := New(deadLetterManager, &Config{
+		Source:          entity.DeadLetterSourceInvalid,
+		MaxAttempts:     2,
+		FailedTaskDelay: &noDelay,
+	})
+
+	succeeded := make([]uint64, 0)
+	require.ErrorIs(t, isolator.Process(context.Background(), receive(t, accrualQueue, accruals), failOn(poison.OrderID, someErr, &succeeded)), someErr)
+	assert.ElementsMatch(t, []uint64{1, 2, 3, 4, 6, 7, 8}, succeeded)
+	require.EqualValues(t, 1, accrualQueue.Count())
+	assert.EqualValues(t, 0, accrualQueue.InFlightCount())
+	retried := accrualQueue.TryReceiveBatch(1)
+	require.Len(t, retried, 1)
+	assert.Same(t, poison, retried[0].Item())
+
+	require.NoError(t, isolator.Process(context.Background(), retried, failOn(poison.OrderID, someErr, &succeeded)))
+	assert.EqualValues(t, 0, accrualQueue.Count())
+	assert.EqualValues(t, 0, accrualQueue.InFlightCount())
+	assert.Empty(t, isolator.attempts)
+	Verify(deadLetterManager, Once()).AddAccrual(
+		AnyContext(),
+		Exact(entity.DeadLetterSourceInvalid),
+		Exact(poison),
+		Exact(uint64(2)),
+		Exact(someErr.Error()),
+	)
+}
+
+func TestIsolator_ProcessDeadLetterErr(t *testing.T) {
+	SetUp(t)
+
+	someErr := errors.New("some error")
+	deadLetterErr := errors.New("dead letter error")
+	accruals := newAccruals(1)
+
+	accrualQueue := queue.New[*responses.Accrual](10)
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenSingle(deadLetterManager.AddAccrual(
+		AnyContext(),
+		Any[string](),
+		Any[*responses.Accrual](),
+		Any[uint64](),
+		Any[string](),
+	)).ThenReturn(deadLetterErr)
+
+	noDelay := time.Duration(0)
+	isolator := New(deadLetterManager, &Config{
+		MaxAttempts:     1,
+		FailedTaskDelay: &noDelay,
+	})
+
+	succeeded := make([]uint64, 0)
+	err := isolator.Process(context.Background(), receive(t, accrualQueue, accruals), failOn(1, someErr, &succeeded))
+	require.ErrorIs(t, err, someErr)
+	require.ErrorIs(t, err, deadLetterErr)
+	assert.EqualValues(t, 1, accrualQueue.Count())
+}
+
+func TestIsolator_ProcessCanceled(t *testing.T) {
+	SetUp(t)
+
+	accrualQueue := queue.New[*responses.Accrual](10)
+	deadLetterManager := Mock[deadLetterManager]()
+	noDelay := time.Duration(0)
+	isolator := New(deadLetterManager, &Config{FailedTaskDelay: &noDelay})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := isolator.Process(ctx, receive(t, accrualQueue, newAccruals(8)), func(ctx context.Context, accruals []*responses.Accrual) error {
+		calls++
+		return ctx.Err()
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+	assert.EqualValues(t, 8, accrualQueue.Count())
+	assert.Empty(t, isolator.attempts)
+}
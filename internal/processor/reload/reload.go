@@ -0,0 +1,105 @@
+package reload
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"go.uber.org/zap"
+)
+
+const DefaultInterval = time.Second * 30
+
+type orderRepository interface {
+	FindUnprocessedIDs(ctx context.Context) (*generator.Stream[uint64], error)
+}
+
+// Processor returns spilled orders to the queue. Order is stored with not final status before it is queued,
+// so an order overflowed the queue is left in the database and loaded again once the queue has room
+type Processor struct {
+	orderRepository orderRepository
+	queue           *queue.Queue[uint64]
+	spilled         atomic.Bool
+	config          *Config
+}
+
+type Config struct {
+	// Interval between checks that spilled orders can be loaded
+	Interval *time.Duration
+}
+
+func prepareConfig(config *Config) {
+	if config.Interval == nil || *config.Interval <= 0 {
+		defaultValue := DefaultInterval
+		config.Interval = &defaultValue
+	}
+}
+
+func NewProcessor(orderRepository orderRepository, queue *queue.Queue[uint64], config *Config) *Processor {
+	prepareConfig(config)
+
+	return &Processor{
+		orderRepository: orderRepository,
+		queue:           queue,
+		config:          config,
+	}
+}
+
+// Spill is used as overflow policy of the order queues
+func (processor *Processor) Spill(orderID uint64) error {
+	processor.spilled.Store(true)
+	logger.Logger.Warn("order queue is full, order is left in the database until the queue has room", zap.Uint64("order_id", orderID))
+
+	return nil
+}
+
+// Load pushes not final orders to the queue until it is full.
+// Orders already queued or requested from the accrual system are skipped by the queue deduplicator,
+// orders scheduled for the next lookup are skipped to keep their backoff
+func (processor *Processor) Load(ctx context.Context) error {
+	orderIDs, err := processor.orderRepository.FindUnprocessedIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for orderID := range orderIDs.Items() {
+		if processor.queue.Full() {
+			processor.spilled.Store(true)
+			logger.Logger.Warn("order queue is full, remaining orders will be loaded later")
+			break
+		}
+		if processor.queue.Scheduled(orderID) {
+			continue
+		}
+		// overflow is passed to Spill
+		_ = processor.queue.TryPush(orderID)
+	}
+
+	return orderIDs.Close()
+}
+
+// Process loads spilled orders when at least half of the queue is free, so they are not loaded one by one
+func (processor *Processor) Process(ctx context.Context) error {
+	ticker := time.NewTicker(*processor.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if !processor.spilled.Load() || processor.queue.Count() > processor.queue.Cap()/2 {
+				continue
+			}
+
+			processor.spilled.Store(false)
+			if err := processor.Load(ctx); err != nil {
+				processor.spilled.Store(true)
+				logger.Logger.Warn("can`t load spilled orders", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderRepositoryStub struct {
+	ids   []uint64
+	err   error
+	calls atomic.Int32
+}
+
+func (stub *orderRepositoryStub) FindUnprocessedIDs(context.Context) (*generator.Stream[uint64], error) {
+	stub.calls.Add(1)
+	if stub.err != nil {
+		return nil, stub.err
+	}
+
+	return generator.NewStreamFromSlice(stub.ids, nil), nil
+}
+
+func newProcessor(repository *orderRepositoryStub, size uint64) (*Processor, *queue.Queue[uint64]) {
+	var processor *Processor
+	orderQueue := queue.New[uint64](
+		size,
+		queue.WithOverflowPolicy(queue.Spill(func(orderID uint64) error {
+			return processor.Spill(orderID)
+		})),
+		queue.WithDeduplicator(queue.NewDeduplicator[uint64](), func(orderID uint64) uint64 {
+			return orderID
+		}),
+	)
+	interval := time.Millisecond * 10
+	processor = NewProcessor(repository, orderQueue, &Config{Interval: &interval})
+
+	return processor, orderQueue
+}
+
+func TestProcessor_Load(t *testing.T) {
+	tests := []struct {
+		name        string
+		ids         []uint64
+		size        uint64
+		wantCount   uint64
+		wantSpilled bool
+	}{
+		{
+			name:      "all loaded",
+			ids:       []uint64{1, 2, 3},
+			size:      4,
+			wantCount: 3,
+		},
+		{
+			name:        "queue is full",
+			ids:         []uint64{1, 2, 3, 4, 5},
+			size:        2,
+			wantCount:   2,
+			wantSpilled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, orderQueue := newProcessor(&orderRepositoryStub{ids: tt.ids}, tt.size)
+
+			require.NoError(t, processor.Load(context.Background()))
+			assert.Equal(t, tt.wantCount, orderQueue.Count())
+			assert.Equal(t, tt.wantSpilled, processor.spilled.Load())
+		})
+	}
+}
+
+func TestProcessor_LoadScheduled(t *testing.T) {
+	processor, orderQueue := newProcessor(&orderRepositoryStub{ids: []uint64{1, 2, 3}}, 4)
+	orderQueue.PushDelayed(context.Background(), 2, time.Hour)
+
+	// 2 keeps waiting for its next lookup
+	require.NoError(t, processor.Load(context.Background()))
+	assert.EqualValues(t, 2, orderQueue.Count())
+	assert.EqualValues(t, 1, orderQueue.DelayedCount())
+	for _, want := range []uint64{1, 3} {
+		orderID, ok := orderQueue.Pop()
+		require.True(t, ok)
+		assert.Equal(t, want, orderID)
+	}
+}
+
+func TestProcessor_LoadError(t *testing.T) {
+	processor, _ := newProcessor(&orderRepositoryStub{err: errors.New("database is down")}, 2)
+
+	assert.EqualError(t, processor.Load(context.Background()), "database is down")
+}
+
+func TestProcessor_Process(t *testing.T) {
+	repository := &orderRepositoryStub{ids: []uint64{1, 2, 3, 4}}
+	processor, orderQueue := newProcessor(repository, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- processor.Process(ctx)
+	}()
+
+	// nothing is spilled yet
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, int32(0), repository.calls.Load())
+
+	for _, orderID := range []uint64{1, 2, 3, 4, 5} {
+		_ = orderQueue.TryPush(orderID)
+	}
+	assert.True(t, processor.spilled.Load())
+
+	// queue has no room yet
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, int32(0), repository.calls.Load())
+
+	for i := 0; i < 3; i++ {
+		orderID, ok := orderQueue.Pop()
+		require.True(t, ok)
+		orderQueue.Ack(orderID)
+	}
+	// 4 is left in the queue, 1, 2 and 3 are loaded again until the queue is full
+	assert.Eventually(t, func() bool {
+		return orderQueue.Count() == 4
+	}, time.Second, time.Millisecond*10)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, int32(1), repository.calls.Load())
+	assert.True(t, processor.spilled.Load())
+}
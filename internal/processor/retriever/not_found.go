@@ -0,0 +1,58 @@
+package retriever
+
+import (
+	"sync"
+	"time"
+)
+
+type notFoundState struct {
+	attempts  uint64
+	firstSeen time.Time
+}
+
+// notFoundTracker counts lookups of orders unknown to the accrual system.
+// State is kept in memory only, so the counting starts again after restart
+type notFoundTracker struct {
+	mutex       sync.Mutex
+	orders      map[uint64]*notFoundState
+	maxAttempts uint64
+	maxDuration time.Duration
+	now         func() time.Time
+}
+
+func newNotFoundTracker(maxAttempts uint64, maxDuration time.Duration) *notFoundTracker {
+	return &notFoundTracker{
+		orders:      make(map[uint64]*notFoundState),
+		maxAttempts: maxAttempts,
+		maxDuration: maxDuration,
+		now:         time.Now,
+	}
+}
+
+// giveUp registers one more not found lookup and reports whether the order should not be requested anymore
+func (tracker *notFoundTracker) giveUp(orderID uint64) bool {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	now := tracker.now()
+	state, ok := tracker.orders[orderID]
+	if !ok {
+		state = &notFoundState{firstSeen: now}
+		tracker.orders[orderID] = state
+	}
+	state.attempts++
+
+	if state.attempts >= tracker.maxAttempts || now.Sub(state.firstSeen) >= tracker.maxDuration {
+		delete(tracker.orders, orderID)
+		return true
+	}
+
+	return false
+}
+
+func (tracker *notFoundTracker) forget(orderID uint64) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	delete(tracker.orders, orderID)
+}
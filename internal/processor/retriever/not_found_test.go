@@ -0,0 +1,55 @@
+package retriever
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFoundTracker_giveUp(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts uint64
+		maxDuration time.Duration
+		step        time.Duration
+		want        []bool
+	}{
+		{
+			name:        "attempts",
+			maxAttempts: 3,
+			maxDuration: time.Hour,
+			want:        []bool{false, false, true, false},
+		},
+		{
+			name:        "duration",
+			maxAttempts: 100,
+			maxDuration: time.Minute,
+			step:        time.Second * 40,
+			want:        []bool{false, false, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			tracker := newNotFoundTracker(tt.maxAttempts, tt.maxDuration)
+			tracker.now = func() time.Time {
+				return now
+			}
+
+			for i, want := range tt.want {
+				assert.Equal(t, want, tracker.giveUp(1), "attempt %d", i+1)
+				now = now.Add(tt.step)
+			}
+		})
+	}
+}
+
+func TestNotFoundTracker_forget(t *testing.T) {
+	tracker := newNotFoundTracker(2, time.Hour)
+
+	assert.False(t, tracker.giveUp(1))
+	tracker.forget(1)
+	assert.False(t, tracker.giveUp(1))
+	assert.True(t, tracker.giveUp(1))
+}
@@ -4,126 +4,267 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/client"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/aimd"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
-	"github.com/m1khal3v/gophermart-loyalty-service/pkg/semaphore"
 	"go.uber.org/zap"
 )
 
 const DefaultConcurrency = 10
-const DefaultNoTasksDelay = time.Second * 5
+const DefaultMinConcurrency = 1
+const DefaultBatchSize = 10
+const DefaultLatencyThreshold = time.Second * 2
+const DefaultLinger = time.Millisecond * 10
 const DefaultFailedTaskDelay = time.Second * 10
+const DefaultNotFoundMaxAttempts = 200
+const DefaultNotFoundMaxDuration = time.Hour * 24
 
 type accrualClient interface {
-	GetAccrual(ctx context.Context, orderID uint64) (*responses.Accrual, error)
+	GetAccruals(ctx context.Context, orderIDs []uint64) []client.AccrualResult
+}
+
+type deadLetterManager interface {
+	Add(ctx context.Context, orderID uint64, reason string) error
+}
+
+type scheduler interface {
+	Next(ctx context.Context, orderID uint64, status string) (time.Duration, bool, error)
 }
 
 type Processor struct {
-	accrualClient accrualClient
-	orderQueue    *queue.Queue[uint64]
-	accrualQueue  *queue.Queue[*responses.Accrual]
-	waitFor       atomic.Pointer[time.Time]
-	config        *Config
+	accrualClient     accrualClient
+	deadLetterManager deadLetterManager
+	scheduler         scheduler
+	orderQueue        *queue.Priority[uint64]
+	// retryQueue is a lane of orderQueue for the orders requested again
+	retryQueue   *queue.Queue[uint64]
+	accrualQueue *queue.Queue[*responses.Accrual]
+	waitFor      atomic.Pointer[time.Time]
+	notFound     *notFoundTracker
+	config       *Config
 }
 
 type Config struct {
-	Concurrency     uint64
-	NoTasksDelay    *time.Duration
+	// Concurrency is max concurrency, actual one is adapted between MinConcurrency and Concurrency
+	// according to accrual system latency and errors
+	Concurrency      uint64
+	MinConcurrency   uint64
+	LatencyThreshold *time.Duration
+	// BatchSize is max count of orders requested by one goroutine at once
+	BatchSize uint64
+	// Linger is max time to wait for the batch to be filled after the first order
+	Linger          *time.Duration
 	FailedTaskDelay *time.Duration
+	// Order unknown to the accrual system is looked up with the backoff of REGISTERED order
+	// and is given up and marked as INVALID after NotFoundMaxAttempts lookups or NotFoundMaxDuration since the first one.
+	// Default NotFoundMaxAttempts is not reached within default NotFoundMaxDuration with the default backoff
+	NotFoundMaxAttempts uint64
+	NotFoundMaxDuration *time.Duration
 }
 
 func prepareConfig(config *Config) {
 	if config.Concurrency == 0 {
 		config.Concurrency = DefaultConcurrency
 	}
-	if config.NoTasksDelay == nil || *config.NoTasksDelay < 0 {
-		defaultValue := DefaultNoTasksDelay
-		config.NoTasksDelay = &defaultValue
+	if config.MinConcurrency == 0 {
+		config.MinConcurrency = DefaultMinConcurrency
+	}
+	if config.MinConcurrency > config.Concurrency {
+		config.MinConcurrency = config.Concurrency
+	}
+	if config.LatencyThreshold == nil || *config.LatencyThreshold <= 0 {
+		defaultValue := DefaultLatencyThreshold
+		config.LatencyThreshold = &defaultValue
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.Linger == nil || *config.Linger < 0 {
+		defaultValue := DefaultLinger
+		config.Linger = &defaultValue
 	}
 	if config.FailedTaskDelay == nil || *config.FailedTaskDelay < 0 {
 		defaultValue := DefaultFailedTaskDelay
 		config.FailedTaskDelay = &defaultValue
 	}
+	if config.NotFoundMaxAttempts == 0 {
+		config.NotFoundMaxAttempts = DefaultNotFoundMaxAttempts
+	}
+	if config.NotFoundMaxDuration == nil || *config.NotFoundMaxDuration <= 0 {
+		defaultValue := DefaultNotFoundMaxDuration
+		config.NotFoundMaxDuration = &defaultValue
+	}
 }
 
 func NewProcessor(
 	accrualClient accrualClient,
-	orderQueue *queue.Queue[uint64],
+	deadLetterManager deadLetterManager,
+	scheduler scheduler,
+	orderQueue *queue.Priority[uint64],
+	retryQueue *queue.Queue[uint64],
 	accrualQueue *queue.Queue[*responses.Accrual],
 	config *Config,
 ) *Processor {
 	prepareConfig(config)
 	return &Processor{
-		accrualClient: accrualClient,
-		orderQueue:    orderQueue,
-		accrualQueue:  accrualQueue,
-		config:        config,
+		accrualClient:     accrualClient,
+		deadLetterManager: deadLetterManager,
+		scheduler:         scheduler,
+		orderQueue:        orderQueue,
+		retryQueue:        retryQueue,
+		accrualQueue:      accrualQueue,
+		notFound:          newNotFoundTracker(config.NotFoundMaxAttempts, *config.NotFoundMaxDuration),
+		config:            config,
 	}
 }
 
-func (processor *Processor) Process(ctx context.Context) error {
-	semaphore := semaphore.New(processor.config.Concurrency)
+// Process takes orders from the queue until ctx is done, workCtx cancels lookups in flight.
+// Process returns after all lookups in flight are finished
+func (processor *Processor) Process(ctx, workCtx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	limiter := aimd.New(&aimd.Config{
+		Min:              processor.config.MinConcurrency,
+		Max:              processor.config.Concurrency,
+		LatencyThreshold: processor.config.LatencyThreshold,
+		OnChange: func(from, to uint64) {
+			logger.Logger.Info("retriever concurrency changed", zap.Uint64("from", from), zap.Uint64("to", to))
+		},
+	})
 
 	for {
-		if err := semaphore.Acquire(ctx); err != nil {
+		if err := limiter.Acquire(ctx); err != nil {
+			return err
+		}
+
+		orderIDs, err := processor.orderQueue.PopBatchWait(ctx, processor.config.BatchSize, *processor.config.Linger)
+		if err != nil {
+			limiter.Discard()
 			return err
 		}
 
 		if err := processor.waitIfNeed(ctx); err != nil {
+			processor.pushBack(orderIDs)
+			limiter.Discard()
 			return err
 		}
 
-		orderID, ok := processor.orderQueue.Pop()
-		if !ok {
-			// this case should never happen
-			logger.Logger.Error("order queue is empty, but should not")
-			semaphore.Release()
-		} else {
-			go func(orderID uint64) {
-				defer semaphore.Release()
-				if err := processor.processOrder(ctx, orderID); err != nil {
-					logger.Logger.Warn("can`t retrieve accrual", zap.Error(err))
-				}
-			}(orderID)
+		wg.Add(1)
+		go func(orderIDs []uint64) {
+			defer wg.Done()
+			start := time.Now()
+			overloaded := processor.processBatch(workCtx, orderIDs)
+			limiter.Release(time.Since(start), overloaded)
+		}(orderIDs)
+	}
+}
+
+// pushBack returns not processed orders to the queue without blocking,
+// orders that do not fit stay unprocessed in the database and are queued again after restart
+func (processor *Processor) pushBack(orderIDs []uint64) {
+	for _, orderID := range orderIDs {
+		processor.retryQueue.Ack(orderID)
+		if err := processor.retryQueue.TryPush(orderID); err != nil {
+			logger.Logger.Warn("can`t return order to the queue", zap.Uint64("order_id", orderID), zap.Error(err))
+		}
+	}
+}
+
+// processBatch returns true if any order lookup signals accrual system overload
+func (processor *Processor) processBatch(ctx context.Context, orderIDs []uint64) bool {
+	overloaded := false
+	results := processor.accrualClient.GetAccruals(ctx, orderIDs)
+	// orders are not in flight anymore, so the ones to be requested again may be queued
+	for _, orderID := range orderIDs {
+		processor.retryQueue.Ack(orderID)
+	}
+
+	for _, result := range results {
+		if err := processor.processResult(ctx, result); err != nil {
+			logger.Logger.Warn("can`t retrieve accrual", zap.Error(err))
+			overloaded = overloaded || isOverloaded(err)
 		}
 	}
+
+	return overloaded
 }
 
-func (processor *Processor) processOrder(ctx context.Context, orderID uint64) error {
-	accrual, err := processor.accrualClient.GetAccrual(ctx, orderID)
+func (processor *Processor) processResult(ctx context.Context, result client.AccrualResult) error {
+	orderID, accrual, err := result.OrderID, result.Accrual, result.Err
+	if !errors.Is(err, client.ErrOrderNotFound) {
+		processor.notFound.forget(orderID)
+	}
+
 	if err != nil {
-		target := client.ErrTooManyRequests{}
-		if errors.As(err, &target) {
-			processor.setWaitFor(target.RetryAfterTime)
-			processor.orderQueue.Push(orderID)
-		} else {
-			processor.orderQueue.PushDelayed(ctx, orderID, *processor.config.FailedTaskDelay)
+		tooManyRequests := client.ErrTooManyRequests{}
+		breakerOpen := breaker.ErrOpen{}
+		switch {
+		case errors.Is(err, client.ErrOrderNotFound) && processor.notFound.giveUp(orderID):
+			logger.Logger.Warn("order is unknown to accrual system, giving up", zap.Uint64("order_id", orderID))
+			return processor.accrualQueue.PushContext(ctx, &responses.Accrual{
+				OrderID: orderID,
+				Status:  responses.AccrualStatusInvalid,
+			})
+		case errors.Is(err, client.ErrOrderNotFound):
+			processor.retryQueue.PushDelayed(ctx, orderID, processor.notFoundDelay(ctx, orderID))
+		case errors.As(err, &client.ErrUnknownStatus{}) || errors.As(err, &client.ErrInvalidResponse{}):
+			if deadLetterErr := processor.deadLetterManager.Add(ctx, orderID, err.Error()); deadLetterErr != nil {
+				processor.retryQueue.PushDelayed(ctx, orderID, *processor.config.FailedTaskDelay)
+
+				return fmt.Errorf("accrual %d: %w", orderID, errors.Join(err, deadLetterErr))
+			}
+			logger.Logger.Error("order moved to dead letters", zap.Uint64("order_id", orderID), zap.Error(err))
+
+			return nil
+		case errors.As(err, &tooManyRequests):
+			processor.setWaitFor(tooManyRequests.RetryAfterTime)
+			processor.retryQueue.PushContext(ctx, orderID)
+		case errors.As(err, &breakerOpen):
+			processor.setWaitFor(breakerOpen.RetryAfterTime)
+			processor.retryQueue.PushContext(ctx, orderID)
+		default:
+			processor.retryQueue.PushDelayed(ctx, orderID, *processor.config.FailedTaskDelay)
 		}
 
 		return fmt.Errorf("accrual %d: %w", orderID, err)
 	}
 
-	processor.accrualQueue.Push(accrual)
-
-	return nil
+	return processor.accrualQueue.PushContext(ctx, accrual)
 }
 
-// Lock-free waitIfNeed
-func (processor *Processor) waitIfNeed(ctx context.Context) error {
-	for processor.orderQueue.Count() == 0 {
-		select {
-		case <-ctx.Done():
-			return context.Cause(ctx)
-		case <-time.After(*processor.config.NoTasksDelay):
-			continue
-		}
+// notFoundDelay returns delay before the next lookup of order unknown to the accrual system,
+// it is not registered there yet, so it is polled as REGISTERED one
+func (processor *Processor) notFoundDelay(ctx context.Context, orderID uint64) time.Duration {
+	delay, ok, err := processor.scheduler.Next(ctx, orderID, responses.AccrualStatusRegistered)
+	if err != nil {
+		logger.Logger.Warn("can`t schedule order", zap.Uint64("order_id", orderID), zap.Error(err))
+	}
+	if err != nil || !ok {
+		return *processor.config.FailedTaskDelay
 	}
 
+	return delay
+}
+
+// isOverloaded reports whether error is a signal to decrease concurrency
+func isOverloaded(err error) bool {
+	return err != nil &&
+		!errors.Is(err, client.ErrOrderNotFound) &&
+		!errors.As(err, &client.ErrUnknownStatus{}) &&
+		!errors.As(err, &client.ErrInvalidResponse{}) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// Lock-free waitIfNeed waits until accrual system allows requests again
+func (processor *Processor) waitIfNeed(ctx context.Context) error {
 	for {
 		waitFor := processor.waitFor.Load()
 		if waitFor == nil {
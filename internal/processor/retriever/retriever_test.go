@@ -9,17 +9,21 @@ import (
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/client"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/schedule"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/breaker"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	. "github.com/ovechkin-dm/mockio/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestProcessor_processOrderOK(t *testing.T) {
+func TestProcessor_processBatchOK(t *testing.T) {
 	SetUp(t)
 
 	orderID := rand.Uint64N(1000) + 100
-	accrual := rand.Float64() + 100
+	accrual := money.Amount(rand.Uint64N(10000) + 100)
 	response := &responses.Accrual{
 		OrderID: orderID,
 		Status:  "TEST_STATUS",
@@ -27,21 +31,21 @@ func TestProcessor_processOrderOK(t *testing.T) {
 	}
 
 	accrualClient := Mock[accrualClient]()
-	WhenDouble(accrualClient.GetAccrual(
+	WhenSingle(accrualClient.GetAccruals(
 		AnyContext(),
-		Exact(orderID),
-	)).ThenReturn(response, nil)
+		Equal([]uint64{orderID}),
+	)).ThenReturn([]client.AccrualResult{{OrderID: orderID, Accrual: response}})
 	orderQueue := queue.New[uint64](1)
 	accrualQueue := queue.New[*responses.Accrual](1)
 
-	processor := NewProcessor(accrualClient, orderQueue, accrualQueue, &Config{})
-	err := processor.processOrder(context.Background(), orderID)
-	Verify(accrualClient, Once()).GetAccrual(
+	processor := NewProcessor(accrualClient, Mock[deadLetterManager](), Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{})
+	overloaded := processor.processBatch(context.Background(), []uint64{orderID})
+	Verify(accrualClient, Once()).GetAccruals(
 		AnyContext(),
-		Exact(orderID),
+		Equal([]uint64{orderID}),
 	)
 
-	require.NoError(t, err)
+	assert.False(t, overloaded)
 	assert.EqualValues(t, 0, orderQueue.Count())
 	assert.EqualValues(t, 1, accrualQueue.Count())
 	retrieved, ok := accrualQueue.Pop()
@@ -50,30 +54,81 @@ func TestProcessor_processOrderOK(t *testing.T) {
 	assert.Nil(t, processor.waitFor.Load())
 }
 
-func TestProcessor_processOrderErr(t *testing.T) {
+func TestProcessor_processBatchOverloaded(t *testing.T) {
+	SetUp(t)
+
+	orderIDs := []uint64{1, 2, 3}
+	accrual := &responses.Accrual{OrderID: 1, Status: "TEST_STATUS"}
+
+	accrualClient := Mock[accrualClient]()
+	WhenSingle(accrualClient.GetAccruals(
+		AnyContext(),
+		Equal(orderIDs),
+	)).ThenReturn([]client.AccrualResult{
+		{OrderID: 1, Accrual: accrual},
+		{OrderID: 2, Err: client.ErrOrderNotFound},
+		{OrderID: 3, Err: client.ErrInternalServerError},
+	})
+	orderQueue := queue.New[uint64](3)
+	accrualQueue := queue.New[*responses.Accrual](3)
+
+	noDelay := time.Duration(0)
+	processor := NewProcessor(accrualClient, Mock[deadLetterManager](), Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{
+		FailedTaskDelay: &noDelay,
+	})
+	overloaded := processor.processBatch(context.Background(), orderIDs)
+
+	assert.True(t, overloaded)
+	assert.EqualValues(t, 2, orderQueue.Count())
+	assert.EqualValues(t, 1, accrualQueue.Count())
+}
+
+func TestProcessor_processBatchAck(t *testing.T) {
 	SetUp(t)
 
 	orderID := rand.Uint64N(1000) + 100
-	someErr := errors.New("some error")
 
 	accrualClient := Mock[accrualClient]()
-	WhenDouble(accrualClient.GetAccrual(
+	WhenSingle(accrualClient.GetAccruals(
 		AnyContext(),
-		Exact(orderID),
-	)).ThenReturn(nil, someErr)
+		Equal([]uint64{orderID}),
+	)).ThenReturn([]client.AccrualResult{{OrderID: orderID, Err: client.ErrInternalServerError}})
+	deduplicator := queue.NewDeduplicator[uint64]()
+	orderQueue := queue.New(1, queue.WithDeduplicator(deduplicator, func(orderID uint64) uint64 {
+		return orderID
+	}))
+	accrualQueue := queue.New[*responses.Accrual](1)
+	priority := queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue})
+
+	orderQueue.Push(orderID)
+	orderIDs := priority.PopBatch(1)
+	require.Equal(t, []uint64{orderID}, orderIDs)
+
+	noDelay := time.Duration(0)
+	processor := NewProcessor(accrualClient, Mock[deadLetterManager](), Mock[scheduler](), priority, orderQueue, accrualQueue, &Config{
+		FailedTaskDelay: &noDelay,
+	})
+	processor.processBatch(context.Background(), orderIDs)
+
+	assert.EqualValues(t, 1, orderQueue.Count())
+	assert.True(t, deduplicator.Has(orderID))
+}
+
+func TestProcessor_processResultErr(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+	someErr := errors.New("some error")
+
 	orderQueue := queue.New[uint64](1)
 	accrualQueue := queue.New[*responses.Accrual](1)
 
 	noDelay := time.Duration(0)
-	processor := NewProcessor(accrualClient, orderQueue, accrualQueue, &Config{
+	processor := NewProcessor(Mock[accrualClient](), Mock[deadLetterManager](), Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{
 		FailedTaskDelay: &noDelay,
 	})
 
-	err := processor.processOrder(context.Background(), orderID)
-	Verify(accrualClient, Once()).GetAccrual(
-		AnyContext(),
-		Exact(orderID),
-	)
+	err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: someErr})
 
 	require.ErrorIs(t, err, someErr)
 	assert.EqualValues(t, 1, orderQueue.Count())
@@ -84,30 +139,21 @@ func TestProcessor_processOrderErr(t *testing.T) {
 	assert.Nil(t, processor.waitFor.Load())
 }
 
-func TestProcessor_processOrderTooManyRequests(t *testing.T) {
+func TestProcessor_processResultTooManyRequests(t *testing.T) {
 	SetUp(t)
 
 	orderID := rand.Uint64N(1000) + 100
 	someErr := client.ErrTooManyRequests{RetryAfterTime: time.Now().Add(time.Hour)}
 
-	accrualClient := Mock[accrualClient]()
-	WhenDouble(accrualClient.GetAccrual(
-		AnyContext(),
-		Exact(orderID),
-	)).ThenReturn(nil, someErr)
 	orderQueue := queue.New[uint64](1)
 	accrualQueue := queue.New[*responses.Accrual](1)
 
 	noDelay := time.Duration(0)
-	processor := NewProcessor(accrualClient, orderQueue, accrualQueue, &Config{
+	processor := NewProcessor(Mock[accrualClient](), Mock[deadLetterManager](), Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{
 		FailedTaskDelay: &noDelay,
 	})
 
-	err := processor.processOrder(context.Background(), orderID)
-	Verify(accrualClient, Once()).GetAccrual(
-		AnyContext(),
-		Exact(orderID),
-	)
+	err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: someErr})
 
 	require.ErrorIs(t, err, someErr)
 	assert.EqualValues(t, 1, orderQueue.Count())
@@ -117,3 +163,215 @@ func TestProcessor_processOrderTooManyRequests(t *testing.T) {
 	assert.Equal(t, orderID, retrieved)
 	assert.NotNil(t, processor.waitFor.Load())
 }
+
+func TestProcessor_processResultBreakerOpen(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+	someErr := breaker.ErrOpen{RetryAfterTime: time.Now().Add(time.Hour)}
+
+	orderQueue := queue.New[uint64](1)
+	accrualQueue := queue.New[*responses.Accrual](1)
+
+	processor := NewProcessor(Mock[accrualClient](), Mock[deadLetterManager](), Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{})
+
+	err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: someErr})
+
+	require.ErrorIs(t, err, someErr)
+	assert.EqualValues(t, 1, orderQueue.Count())
+	assert.EqualValues(t, 0, accrualQueue.Count())
+	assert.NotNil(t, processor.waitFor.Load())
+}
+
+func TestProcessor_processResultNotFoundGiveUp(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+
+	orderQueue := queue.New[uint64](1)
+	accrualQueue := queue.New[*responses.Accrual](1)
+
+	noDelay := time.Duration(0)
+	processor := NewProcessor(Mock[accrualClient](), Mock[deadLetterManager](), Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{
+		FailedTaskDelay:     &noDelay,
+		NotFoundMaxAttempts: 2,
+	})
+
+	err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: client.ErrOrderNotFound})
+	require.ErrorIs(t, err, client.ErrOrderNotFound)
+	assert.EqualValues(t, 1, orderQueue.Count())
+	assert.EqualValues(t, 0, accrualQueue.Count())
+
+	err = processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: client.ErrOrderNotFound})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, orderQueue.Count())
+	accrual, ok := accrualQueue.Pop()
+	require.True(t, ok)
+	assert.Equal(t, &responses.Accrual{OrderID: orderID, Status: responses.AccrualStatusInvalid}, accrual)
+}
+
+func TestProcessor_processResultNotFoundBackoff(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+
+	orderQueue := queue.New[uint64](1)
+	accrualQueue := queue.New[*responses.Accrual](1)
+
+	scheduler := Mock[scheduler]()
+	When(scheduler.Next(AnyContext(), Exact(orderID), Exact(responses.AccrualStatusRegistered))).ThenReturn(time.Hour, true, nil)
+	processor := NewProcessor(Mock[accrualClient](), Mock[deadLetterManager](), scheduler, queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{})
+
+	err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: client.ErrOrderNotFound})
+	require.ErrorIs(t, err, client.ErrOrderNotFound)
+	assert.EqualValues(t, 0, orderQueue.Count())
+	due, ok := orderQueue.NextDue()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), due, time.Second)
+}
+
+type orderRepositoryStub struct{}
+
+func (orderRepositoryStub) FindByID(context.Context, uint64) (*entity.Order, error) {
+	return nil, nil
+}
+
+func TestProcessor_processResultNotFoundMaxDuration(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+
+	orderQueue := queue.New[uint64](1, queue.WithKey(func(orderID uint64) uint64 {
+		return orderID
+	}))
+	accrualQueue := queue.New[*responses.Accrual](1)
+
+	processor := NewProcessor(Mock[accrualClient](), Mock[deadLetterManager](), schedule.New(orderRepositoryStub{}, &schedule.Config{}), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{})
+	start := time.Now()
+	now := start
+	processor.notFound.now = func() time.Time {
+		return now
+	}
+
+	// default backoff reaches NotFoundMaxDuration before NotFoundMaxAttempts
+	lookups := uint64(0)
+	for {
+		lookups++
+		err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: client.ErrOrderNotFound})
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, client.ErrOrderNotFound)
+
+		due, ok := orderQueue.NextDue()
+		require.True(t, ok)
+		require.True(t, orderQueue.CancelDelayed(orderID))
+		now = now.Add(time.Until(due))
+	}
+
+	assert.Less(t, lookups, uint64(DefaultNotFoundMaxAttempts))
+	assert.GreaterOrEqual(t, now.Sub(start), DefaultNotFoundMaxDuration)
+	accrual, ok := accrualQueue.Pop()
+	require.True(t, ok)
+	assert.Equal(t, &responses.Accrual{OrderID: orderID, Status: responses.AccrualStatusInvalid}, accrual)
+}
+
+func TestProcessor_processResultUnknownStatus(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+	someErr := client.ErrUnknownStatus{Status: "CANCELLED"}
+
+	orderQueue := queue.New[uint64](1)
+	accrualQueue := queue.New[*responses.Accrual](1)
+
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenSingle(deadLetterManager.Add(AnyContext(), Exact(orderID), Exact(someErr.Error()))).ThenReturn(nil)
+
+	processor := NewProcessor(Mock[accrualClient](), deadLetterManager, Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{})
+
+	err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: someErr})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, orderQueue.Count())
+	assert.EqualValues(t, 0, accrualQueue.Count())
+	Verify(deadLetterManager, Once()).Add(AnyContext(), Exact(orderID), Exact(someErr.Error()))
+}
+
+func TestProcessor_processResultDeadLetterErr(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+	someErr := client.ErrInvalidResponse{Reason: "order mismatch"}
+	deadLetterErr := errors.New("dead letter error")
+
+	orderQueue := queue.New[uint64](1)
+	accrualQueue := queue.New[*responses.Accrual](1)
+
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenSingle(deadLetterManager.Add(AnyContext(), Exact(orderID), Exact(someErr.Error()))).ThenReturn(deadLetterErr)
+
+	noDelay := time.Duration(0)
+	processor := NewProcessor(Mock[accrualClient](), deadLetterManager, Mock[scheduler](), queue.NewPriority(&queue.Lane[uint64]{Queue: orderQueue}), orderQueue, accrualQueue, &Config{
+		FailedTaskDelay: &noDelay,
+	})
+
+	err := processor.processResult(context.Background(), client.AccrualResult{OrderID: orderID, Err: someErr})
+
+	require.ErrorIs(t, err, deadLetterErr)
+	assert.EqualValues(t, 1, orderQueue.Count())
+	assert.EqualValues(t, 0, accrualQueue.Count())
+}
+
+func TestIsOverloaded(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "no error",
+			want: false,
+		},
+		{
+			name: "not found",
+			err:  client.ErrOrderNotFound,
+			want: false,
+		},
+		{
+			name: "canceled",
+			err:  context.Canceled,
+			want: false,
+		},
+		{
+			name: "unknown status",
+			err:  client.ErrUnknownStatus{Status: "CANCELLED"},
+			want: false,
+		},
+		{
+			name: "invalid response",
+			err:  client.ErrInvalidResponse{Reason: "order mismatch"},
+			want: false,
+		},
+		{
+			name: "too many requests",
+			err:  client.ErrTooManyRequests{},
+			want: true,
+		},
+		{
+			name: "breaker open",
+			err:  breaker.ErrOpen{},
+			want: true,
+		},
+		{
+			name: "other",
+			err:  errors.New("some error"),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isOverloaded(tt.err))
+		})
+	}
+}
@@ -2,44 +2,56 @@ package router
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/schedule"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/semaphore"
+	"go.uber.org/zap"
 )
 
 const DefaultConcurrency = 10
-const DefaultNoTasksDelay = time.Second * 5
-const DefaultNoChangesDelay = time.Minute
+const DefaultFailedTaskDelay = time.Second * 10
+
+type scheduler interface {
+	Next(ctx context.Context, orderID uint64, status string) (time.Duration, bool, error)
+	Forget(orderID uint64)
+}
+
+type deadLetterManager interface {
+	AddAccrual(ctx context.Context, source string, accrual *responses.Accrual, attempts uint64, reason string) error
+}
 
 type Processor struct {
-	orderQueue      *queue.Queue[uint64]
-	routerQueue     *queue.Queue[*responses.Accrual]
-	processingQueue *queue.Queue[*responses.Accrual]
-	invalidQueue    *queue.Queue[*responses.Accrual]
-	processedQueue  *queue.Queue[*responses.Accrual]
-	config          *Config
+	orderQueue        *queue.Queue[uint64]
+	routerQueue       *queue.Queue[*responses.Accrual]
+	processingQueue   *queue.Queue[*responses.Accrual]
+	invalidQueue      *queue.Queue[*responses.Accrual]
+	processedQueue    *queue.Queue[*responses.Accrual]
+	scheduler         scheduler
+	deadLetterManager deadLetterManager
+	// semaphore limits accruals routed at once, it may be resized at runtime
+	semaphore *semaphore.Semaphore
+	config    *Config
 }
 
 type Config struct {
-	Concurrency    uint64
-	NoTasksDelay   *time.Duration
-	NoChangesDelay *time.Duration
+	Concurrency     uint64
+	FailedTaskDelay *time.Duration
 }
 
 func prepareConfig(config *Config) {
 	if config.Concurrency == 0 {
 		config.Concurrency = DefaultConcurrency
 	}
-	if config.NoTasksDelay == nil || *config.NoTasksDelay < 0 {
-		defaultValue := DefaultNoTasksDelay
-		config.NoTasksDelay = &defaultValue
-	}
-	if config.NoChangesDelay == nil || *config.NoChangesDelay < 0 {
-		defaultValue := DefaultNoChangesDelay
-		config.NoChangesDelay = &defaultValue
+	if config.FailedTaskDelay == nil || *config.FailedTaskDelay < 0 {
+		defaultValue := DefaultFailedTaskDelay
+		config.FailedTaskDelay = &defaultValue
 	}
 }
 
@@ -49,71 +61,119 @@ func NewProcessor(
 	processingQueue *queue.Queue[*responses.Accrual],
 	invalidQueue *queue.Queue[*responses.Accrual],
 	processedQueue *queue.Queue[*responses.Accrual],
+	scheduler scheduler,
+	deadLetterManager deadLetterManager,
 	config *Config,
 ) *Processor {
 	prepareConfig(config)
 	return &Processor{
-		orderQueue:      orderQueue,
-		routerQueue:     routerQueue,
-		processingQueue: processingQueue,
-		invalidQueue:    invalidQueue,
-		processedQueue:  processedQueue,
-		config:          config,
+		orderQueue:        orderQueue,
+		routerQueue:       routerQueue,
+		processingQueue:   processingQueue,
+		invalidQueue:      invalidQueue,
+		processedQueue:    processedQueue,
+		scheduler:         scheduler,
+		deadLetterManager: deadLetterManager,
+		semaphore:         semaphore.New(config.Concurrency),
+		config:            config,
 	}
 }
 
-func (processor *Processor) Process(ctx context.Context) error {
-	semaphore := semaphore.New(processor.config.Concurrency)
+func (processor *Processor) Concurrency() uint64 {
+	return processor.semaphore.Size()
+}
+
+// InFlight returns count of accruals being routed
+func (processor *Processor) InFlight() uint64 {
+	return processor.semaphore.Current()
+}
+
+func (processor *Processor) Resize(concurrency uint64) {
+	processor.semaphore.Resize(concurrency)
+}
+
+// Process takes accruals from the queue until ctx is done, workCtx cancels routing in flight.
+// Process returns after all accruals in flight are routed
+func (processor *Processor) Process(ctx, workCtx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
 
 	for {
-		if err := semaphore.Acquire(ctx); err != nil {
+		if err := processor.semaphore.Acquire(ctx, 1); err != nil {
 			return err
 		}
 
-		if err := processor.waitIfNeed(ctx); err != nil {
+		lease, err := processor.routerQueue.Receive(ctx)
+		if err != nil {
+			processor.semaphore.Release(1)
 			return err
 		}
 
-		accrual, ok := processor.routerQueue.Pop()
-		if !ok {
-			// this case should never happen
-			logger.Logger.Error("router queue is empty, but should not")
-			semaphore.Release()
-		} else {
-			go func(accrual *responses.Accrual) {
-				defer semaphore.Release()
-				processor.processAccrual(ctx, accrual)
-			}(accrual)
+		wg.Add(1)
+		go func(lease *queue.Lease[*responses.Accrual]) {
+			defer wg.Done()
+			defer processor.semaphore.Release(1)
+			if err := processor.processAccrual(workCtx, lease.Item()); err != nil {
+				logger.Logger.Warn("can`t route accrual", zap.Uint64("order_id", lease.Item().OrderID), zap.Error(err))
+				release(lease, false)
+				return
+			}
+
+			release(lease, true)
+		}(lease)
+	}
+}
+
+// Flush synchronously routes accruals left in the queue until it is empty or ctx is done
+func (processor *Processor) Flush(ctx context.Context) error {
+	for {
+		leases := processor.routerQueue.TryReceiveBatch(1)
+		if len(leases) == 0 {
+			return nil
 		}
+
+		if err := processor.processAccrual(ctx, leases[0].Item()); err != nil {
+			release(leases[0], false)
+			return err
+		}
+		release(leases[0], true)
 	}
 }
 
-func (processor *Processor) processAccrual(ctx context.Context, accrual *responses.Accrual) {
+// release acks routed accrual and returns not routed one to the queue.
+// Lease released already has expired by the visibility timeout, so its accrual may be routed twice
+func release(lease *queue.Lease[*responses.Accrual], routed bool) {
+	var err error
+	if routed {
+		err = lease.Ack()
+	} else {
+		err = lease.Nack(0)
+	}
+	if err != nil {
+		logger.Logger.Warn("can`t release accrual lease", zap.Uint64("order_id", lease.Item().OrderID), zap.Error(err))
+	}
+}
+
+// processAccrual blocks until downstream queue has space, so a slow processor throttles the router.
+// Accrual is returned to the router queue if it can`t be routed
+func (processor *Processor) processAccrual(ctx context.Context, accrual *responses.Accrual) error {
 	switch accrual.Status {
 	case responses.AccrualStatusRegistered:
-		processor.orderQueue.PushDelayed(ctx, accrual.OrderID, *processor.config.NoChangesDelay)
+		schedule.Reschedule(ctx, accrual, processor.scheduler, processor.orderQueue, processor.deadLetterManager, entity.DeadLetterSourceRouter, *processor.config.FailedTaskDelay)
 	case responses.AccrualStatusProcessing:
-		processor.processingQueue.Push(accrual)
+		return processor.processingQueue.PushContext(ctx, accrual)
 	case responses.AccrualStatusInvalid:
-		processor.invalidQueue.Push(accrual)
+		processor.scheduler.Forget(accrual.OrderID)
+		return processor.invalidQueue.PushContext(ctx, accrual)
 	case responses.AccrualStatusProcessed:
+		processor.scheduler.Forget(accrual.OrderID)
 		if accrual.Accrual == nil {
-			sum := float64(0)
-			accrual.Accrual = &sum
+			accrual.Accrual = new(money.Amount)
 		}
 
-		processor.processedQueue.Push(accrual)
-	}
-}
-
-func (processor *Processor) waitIfNeed(ctx context.Context) error {
-	for processor.routerQueue.Count() == 0 {
-		select {
-		case <-ctx.Done():
-			return context.Cause(ctx)
-		case <-time.After(*processor.config.NoTasksDelay):
-			continue
-		}
+		return processor.processedQueue.PushContext(ctx, accrual)
+	default:
+		logger.Logger.Error("unknown accrual status", zap.Uint64("order_id", accrual.OrderID), zap.String("status", accrual.Status))
 	}
 
 	return nil
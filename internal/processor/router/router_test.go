@@ -7,12 +7,17 @@ import (
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	. "github.com/ovechkin-dm/mockio/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestProcessor_processAccrualRegistered(t *testing.T) {
+	SetUp(t)
+
 	orderID := rand.Uint64N(1000) + 100
 	response := &responses.Accrual{
 		OrderID: orderID,
@@ -24,12 +29,10 @@ func TestProcessor_processAccrualRegistered(t *testing.T) {
 	invalidQueue := queue.New[*responses.Accrual](1)
 	processingQueue := queue.New[*responses.Accrual](1)
 	processedQueue := queue.New[*responses.Accrual](1)
-
-	noDelay := time.Duration(0)
-	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, &Config{
-		NoChangesDelay: &noDelay,
-	})
-	processor.processAccrual(context.Background(), response)
+	scheduler := Mock[scheduler]()
+	When(scheduler.Next(AnyContext(), Exact(orderID), Exact(responses.AccrualStatusRegistered))).ThenReturn(time.Duration(0), true, nil)
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, scheduler, Mock[deadLetterManager](), &Config{})
+	require.NoError(t, processor.processAccrual(context.Background(), response))
 
 	assert.EqualValues(t, 1, orderQueue.Count())
 	assert.EqualValues(t, 0, routerQueue.Count())
@@ -42,7 +45,51 @@ func TestProcessor_processAccrualRegistered(t *testing.T) {
 	assert.Equal(t, response.OrderID, retrieved)
 }
 
+func TestProcessor_processAccrualRegisteredHorizon(t *testing.T) {
+	SetUp(t)
+
+	orderID := rand.Uint64N(1000) + 100
+	response := &responses.Accrual{
+		OrderID: orderID,
+		Status:  responses.AccrualStatusRegistered,
+	}
+
+	orderQueue := queue.New[uint64](1)
+	routerQueue := queue.New[*responses.Accrual](1)
+	invalidQueue := queue.New[*responses.Accrual](1)
+	processingQueue := queue.New[*responses.Accrual](1)
+	processedQueue := queue.New[*responses.Accrual](1)
+	scheduler := Mock[scheduler]()
+	When(scheduler.Next(AnyContext(), Exact(orderID), Exact(responses.AccrualStatusRegistered))).ThenReturn(time.Duration(0), false, nil)
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenSingle(deadLetterManager.AddAccrual(
+		AnyContext(),
+		Exact(entity.DeadLetterSourceRouter),
+		Exact(response),
+		Exact(uint64(1)),
+		Exact("polling horizon exceeded"),
+	)).ThenReturn(nil)
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, scheduler, deadLetterManager, &Config{})
+	require.NoError(t, processor.processAccrual(context.Background(), response))
+
+	assert.EqualValues(t, 0, orderQueue.Count())
+	assert.EqualValues(t, 0, routerQueue.Count())
+	assert.EqualValues(t, 0, processingQueue.Count())
+	assert.EqualValues(t, 0, invalidQueue.Count())
+	assert.EqualValues(t, 0, processedQueue.Count())
+	Verify(deadLetterManager, Once()).AddAccrual(
+		AnyContext(),
+		Exact(entity.DeadLetterSourceRouter),
+		Exact(response),
+		Exact(uint64(1)),
+		Exact("polling horizon exceeded"),
+	)
+	Verify(scheduler, Once()).Forget(Exact(orderID))
+}
+
 func TestProcessor_processAccrualProcessing(t *testing.T) {
+	SetUp(t)
+
 	orderID := rand.Uint64N(1000) + 100
 	response := &responses.Accrual{
 		OrderID: orderID,
@@ -54,12 +101,9 @@ func TestProcessor_processAccrualProcessing(t *testing.T) {
 	invalidQueue := queue.New[*responses.Accrual](1)
 	processingQueue := queue.New[*responses.Accrual](1)
 	processedQueue := queue.New[*responses.Accrual](1)
-
-	noDelay := time.Duration(0)
-	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, &Config{
-		NoChangesDelay: &noDelay,
-	})
-	processor.processAccrual(context.Background(), response)
+	scheduler := Mock[scheduler]()
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, scheduler, Mock[deadLetterManager](), &Config{})
+	require.NoError(t, processor.processAccrual(context.Background(), response))
 
 	assert.EqualValues(t, 0, orderQueue.Count())
 	assert.EqualValues(t, 0, routerQueue.Count())
@@ -72,7 +116,28 @@ func TestProcessor_processAccrualProcessing(t *testing.T) {
 	assert.Equal(t, response, retrieved)
 }
 
+func TestProcessor_processAccrualCanceled(t *testing.T) {
+	SetUp(t)
+
+	orderQueue := queue.New[uint64](1)
+	routerQueue := queue.New[*responses.Accrual](1)
+	invalidQueue := queue.New[*responses.Accrual](1)
+	processingQueue := queue.New[*responses.Accrual](1)
+	processingQueue.Push(&responses.Accrual{OrderID: 1, Status: responses.AccrualStatusProcessing})
+	processedQueue := queue.New[*responses.Accrual](1)
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, Mock[scheduler](), Mock[deadLetterManager](), &Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := processor.processAccrual(ctx, &responses.Accrual{OrderID: 2, Status: responses.AccrualStatusProcessing})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.EqualValues(t, 1, processingQueue.Count())
+}
+
 func TestProcessor_processAccrualInvalid(t *testing.T) {
+	SetUp(t)
+
 	orderID := rand.Uint64N(1000) + 100
 	response := &responses.Accrual{
 		OrderID: orderID,
@@ -84,12 +149,9 @@ func TestProcessor_processAccrualInvalid(t *testing.T) {
 	invalidQueue := queue.New[*responses.Accrual](1)
 	processingQueue := queue.New[*responses.Accrual](1)
 	processedQueue := queue.New[*responses.Accrual](1)
-
-	noDelay := time.Duration(0)
-	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, &Config{
-		NoChangesDelay: &noDelay,
-	})
-	processor.processAccrual(context.Background(), response)
+	scheduler := Mock[scheduler]()
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, scheduler, Mock[deadLetterManager](), &Config{})
+	require.NoError(t, processor.processAccrual(context.Background(), response))
 
 	assert.EqualValues(t, 0, orderQueue.Count())
 	assert.EqualValues(t, 0, routerQueue.Count())
@@ -100,11 +162,14 @@ func TestProcessor_processAccrualInvalid(t *testing.T) {
 	retrieved, ok := invalidQueue.Pop()
 	require.True(t, ok)
 	assert.Equal(t, response, retrieved)
+	Verify(scheduler, Once()).Forget(Exact(orderID))
 }
 
 func TestProcessor_processAccrualProcessedOK(t *testing.T) {
+	SetUp(t)
+
 	orderID := rand.Uint64N(1000) + 100
-	accrual := rand.Float64() + 100
+	accrual := money.Amount(rand.Uint64N(10000) + 100)
 	response := &responses.Accrual{
 		OrderID: orderID,
 		Status:  responses.AccrualStatusProcessed,
@@ -116,12 +181,9 @@ func TestProcessor_processAccrualProcessedOK(t *testing.T) {
 	invalidQueue := queue.New[*responses.Accrual](1)
 	processingQueue := queue.New[*responses.Accrual](1)
 	processedQueue := queue.New[*responses.Accrual](1)
-
-	noDelay := time.Duration(0)
-	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, &Config{
-		NoChangesDelay: &noDelay,
-	})
-	processor.processAccrual(context.Background(), response)
+	scheduler := Mock[scheduler]()
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, scheduler, Mock[deadLetterManager](), &Config{})
+	require.NoError(t, processor.processAccrual(context.Background(), response))
 
 	assert.EqualValues(t, 0, orderQueue.Count())
 	assert.EqualValues(t, 0, routerQueue.Count())
@@ -135,6 +197,8 @@ func TestProcessor_processAccrualProcessedOK(t *testing.T) {
 }
 
 func TestProcessor_processAccrualProcessedZero(t *testing.T) {
+	SetUp(t)
+
 	orderID := rand.Uint64N(1000) + 100
 	response := &responses.Accrual{
 		OrderID: orderID,
@@ -146,9 +210,9 @@ func TestProcessor_processAccrualProcessedZero(t *testing.T) {
 	invalidQueue := queue.New[*responses.Accrual](1)
 	processingQueue := queue.New[*responses.Accrual](1)
 	processedQueue := queue.New[*responses.Accrual](1)
-
-	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, &Config{})
-	processor.processAccrual(context.Background(), response)
+	scheduler := Mock[scheduler]()
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, scheduler, Mock[deadLetterManager](), &Config{})
+	require.NoError(t, processor.processAccrual(context.Background(), response))
 
 	assert.EqualValues(t, 0, orderQueue.Count())
 	assert.EqualValues(t, 0, routerQueue.Count())
@@ -160,5 +224,72 @@ func TestProcessor_processAccrualProcessedZero(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, response.OrderID, retrieved.OrderID)
 	assert.Equal(t, response.Status, retrieved.Status)
-	assert.Equal(t, 0.0, *retrieved.Accrual)
+	assert.Equal(t, money.Amount(0), *retrieved.Accrual)
+}
+
+func TestProcessor_Flush(t *testing.T) {
+	SetUp(t)
+
+	orderQueue := queue.New[uint64](1)
+	routerQueue := queue.New[*responses.Accrual](2)
+	routerQueue.PushBatch([]*responses.Accrual{
+		{OrderID: 1, Status: responses.AccrualStatusProcessing},
+		{OrderID: 2, Status: responses.AccrualStatusInvalid},
+	})
+	invalidQueue := queue.New[*responses.Accrual](1)
+	processingQueue := queue.New[*responses.Accrual](1)
+	processedQueue := queue.New[*responses.Accrual](1)
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, Mock[scheduler](), Mock[deadLetterManager](), &Config{})
+
+	require.NoError(t, processor.Flush(context.Background()))
+	assert.EqualValues(t, 0, routerQueue.Count())
+	assert.EqualValues(t, 1, processingQueue.Count())
+	assert.EqualValues(t, 1, invalidQueue.Count())
+}
+
+func TestProcessor_FlushCanceled(t *testing.T) {
+	SetUp(t)
+
+	orderQueue := queue.New[uint64](1)
+	routerQueue := queue.New[*responses.Accrual](1)
+	routerQueue.Push(&responses.Accrual{OrderID: 1, Status: responses.AccrualStatusProcessing})
+	invalidQueue := queue.New[*responses.Accrual](1)
+	processingQueue := queue.New[*responses.Accrual](1)
+	processingQueue.Push(&responses.Accrual{OrderID: 2, Status: responses.AccrualStatusProcessing})
+	processedQueue := queue.New[*responses.Accrual](1)
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, Mock[scheduler](), Mock[deadLetterManager](), &Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.ErrorIs(t, processor.Flush(ctx), context.Canceled)
+	assert.EqualValues(t, 1, routerQueue.Count())
+}
+
+func TestProcessor_Process(t *testing.T) {
+	SetUp(t)
+
+	orderQueue := queue.New[uint64](1)
+	routerQueue := queue.New[*responses.Accrual](1)
+	invalidQueue := queue.New[*responses.Accrual](1)
+	processingQueue := queue.New[*responses.Accrual](1)
+	processedQueue := queue.New[*responses.Accrual](1)
+	processor := NewProcessor(orderQueue, routerQueue, processingQueue, invalidQueue, processedQueue, Mock[scheduler](), Mock[deadLetterManager](), &Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- processor.Process(ctx, context.Background())
+	}()
+
+	// accrual is routed without polling delay
+	routerQueue.Push(&responses.Accrual{OrderID: 1, Status: responses.AccrualStatusProcessing})
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	retrieved, err := processingQueue.PopWait(waitCtx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, retrieved.OrderID)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
 }
@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"go.uber.org/zap"
+)
+
+type Scheduler interface {
+	Next(ctx context.Context, orderID uint64, status string) (time.Duration, bool, error)
+	Forget(orderID uint64)
+}
+
+type DeadLetterManager interface {
+	AddAccrual(ctx context.Context, source string, accrual *responses.Accrual, attempts uint64, reason string) error
+}
+
+// Reschedule returns not final order to the order queue according to the schedule
+// or moves it to dead letters of the source if polling horizon is exceeded.
+// Order is returned after failedTaskDelay if the schedule or dead letters are not available
+func Reschedule(
+	ctx context.Context,
+	accrual *responses.Accrual,
+	scheduler Scheduler,
+	orderQueue *queue.Queue[uint64],
+	deadLetterManager DeadLetterManager,
+	source string,
+	failedTaskDelay time.Duration,
+) {
+	delay, ok, err := scheduler.Next(ctx, accrual.OrderID, accrual.Status)
+	if err != nil {
+		logger.Logger.Warn("can`t schedule order", zap.Uint64("order_id", accrual.OrderID), zap.Error(err))
+		orderQueue.PushDelayed(ctx, accrual.OrderID, failedTaskDelay)
+		return
+	}
+	if ok {
+		orderQueue.PushDelayed(ctx, accrual.OrderID, delay)
+		return
+	}
+
+	if err := deadLetterManager.AddAccrual(ctx, source, accrual, 1, "polling horizon exceeded"); err != nil {
+		logger.Logger.Error("can`t move order to dead letters", zap.Uint64("order_id", accrual.OrderID), zap.Error(err))
+		orderQueue.PushDelayed(ctx, accrual.OrderID, failedTaskDelay)
+		return
+	}
+
+	scheduler.Forget(accrual.OrderID)
+	logger.Logger.Error("order moved to dead letters", zap.Uint64("order_id", accrual.OrderID), zap.String("status", accrual.Status))
+}
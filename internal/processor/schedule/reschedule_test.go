@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReschedule(t *testing.T) {
+	accrual := &responses.Accrual{OrderID: 1, Status: responses.AccrualStatusRegistered}
+	tests := []struct {
+		name        string
+		next        []any
+		deadLetter  error
+		delayed     uint64
+		deadLetters int
+		forgotten   int
+	}{
+		{
+			name:    "scheduled",
+			next:    []any{time.Minute, true, nil},
+			delayed: 1,
+		},
+		{
+			name:    "schedule error",
+			next:    []any{time.Duration(0), false, errors.New("database is down")},
+			delayed: 1,
+		},
+		{
+			name:        "horizon exceeded",
+			next:        []any{time.Duration(0), false, nil},
+			deadLetters: 1,
+			forgotten:   1,
+		},
+		{
+			name:        "dead letter error",
+			next:        []any{time.Duration(0), false, nil},
+			deadLetter:  errors.New("database is down"),
+			delayed:     1,
+			deadLetters: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+
+			scheduler := Mock[Scheduler]()
+			When(scheduler.Next(AnyContext(), Exact(uint64(1)), Exact(responses.AccrualStatusRegistered))).ThenReturn(tt.next...)
+			deadLetterManager := Mock[DeadLetterManager]()
+			WhenSingle(deadLetterManager.AddAccrual(
+				AnyContext(),
+				Exact(entity.DeadLetterSourceRouter),
+				Exact(accrual),
+				Exact(uint64(1)),
+				Exact("polling horizon exceeded"),
+			)).ThenReturn(tt.deadLetter)
+			orderQueue := queue.New[uint64](1)
+
+			Reschedule(context.Background(), accrual, scheduler, orderQueue, deadLetterManager, entity.DeadLetterSourceRouter, time.Minute)
+
+			assert.Equal(t, tt.delayed, orderQueue.DelayedCount())
+			Verify(deadLetterManager, Times(tt.deadLetters)).AddAccrual(AnyContext(), Any[string](), Any[*responses.Accrual](), Any[uint64](), Any[string]())
+			Verify(scheduler, Times(tt.forgotten)).Forget(Exact(uint64(1)))
+		})
+	}
+}
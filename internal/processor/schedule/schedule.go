@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+)
+
+const DefaultBaseDelay = time.Second * 10
+const DefaultMaxDelay = time.Minute * 10
+const DefaultMultiplier = 2
+const DefaultAgeFactor = 0.1
+const DefaultJitter = 0.2
+const DefaultHorizon = time.Hour * 24 * 7
+
+// Schedule calculates delay before the next lookup of not final order.
+// Delay grows exponentially with lookups in the same status: BaseDelay, BaseDelay*Multiplier ...
+// and is at least AgeFactor of the order age, but not more than MaxDelay.
+// Orders pending longer than Horizon should not be requested anymore.
+// Order age is counted from its upload time, which is loaded on the first lookup,
+// so restart does not reset the horizon. Other state is kept in memory only
+type Schedule struct {
+	mutex           sync.Mutex
+	orders          map[uint64]*order
+	orderRepository orderRepository
+	now             func() time.Time
+	random          func() float64
+	config          *Config
+}
+
+type orderRepository interface {
+	FindByID(ctx context.Context, id uint64) (*entity.Order, error)
+}
+
+type Config struct {
+	// Policies by accrual status (REGISTERED, PROCESSING), DefaultPolicy is used for missing ones
+	Policies map[string]*Policy
+	Horizon  *time.Duration
+}
+
+type Policy struct {
+	BaseDelay  *time.Duration
+	MaxDelay   *time.Duration
+	Multiplier float64
+	// AgeFactor is minimal delay as fraction of the order age
+	AgeFactor *float64
+	// Jitter is max random deviation of the delay as fraction of it
+	Jitter *float64
+}
+
+type order struct {
+	status   string
+	attempts uint64
+	// since is upload time of the order or time of its replay
+	since time.Time
+}
+
+func preparePolicy(policy *Policy) {
+	if policy.BaseDelay == nil || *policy.BaseDelay <= 0 {
+		defaultValue := DefaultBaseDelay
+		policy.BaseDelay = &defaultValue
+	}
+	if policy.MaxDelay == nil || *policy.MaxDelay < *policy.BaseDelay {
+		defaultValue := max(DefaultMaxDelay, *policy.BaseDelay)
+		policy.MaxDelay = &defaultValue
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = DefaultMultiplier
+	}
+	if policy.AgeFactor == nil || *policy.AgeFactor < 0 {
+		defaultValue := DefaultAgeFactor
+		policy.AgeFactor = &defaultValue
+	}
+	if policy.Jitter == nil || *policy.Jitter < 0 || *policy.Jitter >= 1 {
+		defaultValue := DefaultJitter
+		policy.Jitter = &defaultValue
+	}
+}
+
+func prepareConfig(config *Config) {
+	if config.Policies == nil {
+		config.Policies = make(map[string]*Policy)
+	}
+	for _, status := range []string{responses.AccrualStatusRegistered, responses.AccrualStatusProcessing} {
+		if config.Policies[status] == nil {
+			config.Policies[status] = &Policy{}
+		}
+	}
+	for _, policy := range config.Policies {
+		preparePolicy(policy)
+	}
+	if config.Horizon == nil || *config.Horizon <= 0 {
+		defaultValue := DefaultHorizon
+		config.Horizon = &defaultValue
+	}
+}
+
+func New(orderRepository orderRepository, config *Config) *Schedule {
+	prepareConfig(config)
+
+	return &Schedule{
+		orders:          make(map[uint64]*order),
+		orderRepository: orderRepository,
+		now:             time.Now,
+		random:          rand.Float64,
+		config:          config,
+	}
+}
+
+// Next registers one more lookup of the order in the status and returns delay before the next one.
+// Returns false if the order exceeded polling horizon, the order should be given up and forgotten in this case
+func (schedule *Schedule) Next(ctx context.Context, orderID uint64, status string) (time.Duration, bool, error) {
+	since, err := schedule.since(ctx, orderID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	schedule.mutex.Lock()
+	defer schedule.mutex.Unlock()
+
+	now := schedule.now()
+	state, ok := schedule.orders[orderID]
+	if !ok {
+		state = &order{since: since}
+		schedule.orders[orderID] = state
+	}
+	if state.status != status {
+		state.status = status
+		state.attempts = 0
+	}
+
+	age := now.Sub(state.since)
+	if age >= *schedule.config.Horizon {
+		return 0, false, nil
+	}
+
+	policy, ok := schedule.config.Policies[status]
+	if !ok {
+		policy = schedule.config.Policies[responses.AccrualStatusRegistered]
+	}
+	delay := schedule.delay(policy, state.attempts, age)
+	state.attempts++
+
+	return delay, true, nil
+}
+
+// Restart counts the order age from now, e.g. when the order given up is replayed
+func (schedule *Schedule) Restart(orderID uint64) {
+	schedule.mutex.Lock()
+	defer schedule.mutex.Unlock()
+
+	schedule.orders[orderID] = &order{since: schedule.now()}
+}
+
+// Forget removes the order state, e.g. when the order reached final status
+func (schedule *Schedule) Forget(orderID uint64) {
+	schedule.mutex.Lock()
+	defer schedule.mutex.Unlock()
+
+	delete(schedule.orders, orderID)
+}
+
+// since returns time the order age is counted from, upload time is loaded for the order seen first time
+func (schedule *Schedule) since(ctx context.Context, orderID uint64) (time.Time, error) {
+	schedule.mutex.Lock()
+	state, ok := schedule.orders[orderID]
+	var since time.Time
+	if ok {
+		since = state.since
+	}
+	schedule.mutex.Unlock()
+	if ok {
+		return since, nil
+	}
+
+	order, err := schedule.orderRepository.FindByID(ctx, orderID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if order == nil {
+		// order is not stored, so it is counted from the first lookup
+		return schedule.now(), nil
+	}
+
+	return order.CreatedAt, nil
+}
+
+func (schedule *Schedule) delay(policy *Policy, attempts uint64, age time.Duration) time.Duration {
+	delay := float64(*policy.BaseDelay) * math.Pow(policy.Multiplier, float64(attempts))
+	delay = max(delay, *policy.AgeFactor*float64(age))
+	delay = min(delay, float64(*policy.MaxDelay))
+	// random deviation in [-Jitter, +Jitter)
+	delay *= 1 + *policy.Jitter*(2*schedule.random()-1)
+
+	return time.Duration(delay)
+}
@@ -0,0 +1,204 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func uploadedAt(createdAt time.Time) orderRepository {
+	orderRepository := Mock[orderRepository]()
+	WhenDouble(orderRepository.FindByID(AnyContext(), Any[uint64]())).ThenReturn(&entity.Order{ID: 1, CreatedAt: createdAt}, nil)
+
+	return orderRepository
+}
+
+func TestSchedule_Next(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		status []string
+		step   time.Duration
+		random float64
+		want   []time.Duration
+	}{
+		{
+			name:   "exponential",
+			policy: &Policy{BaseDelay: ptr(time.Second), MaxDelay: ptr(time.Minute), AgeFactor: ptr(0.0), Jitter: ptr(0.0)},
+			status: []string{"REGISTERED", "REGISTERED", "REGISTERED", "REGISTERED"},
+			want:   []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 8},
+		},
+		{
+			name:   "max delay",
+			policy: &Policy{BaseDelay: ptr(time.Second * 20), MaxDelay: ptr(time.Minute), AgeFactor: ptr(0.0), Jitter: ptr(0.0)},
+			status: []string{"REGISTERED", "REGISTERED", "REGISTERED"},
+			want:   []time.Duration{time.Second * 20, time.Second * 40, time.Minute},
+		},
+		{
+			name:   "status change resets attempts",
+			policy: &Policy{BaseDelay: ptr(time.Second), MaxDelay: ptr(time.Minute), AgeFactor: ptr(0.0), Jitter: ptr(0.0)},
+			status: []string{"REGISTERED", "REGISTERED", "PROCESSING", "PROCESSING"},
+			want:   []time.Duration{time.Second, time.Second * 2, time.Second, time.Second * 2},
+		},
+		{
+			name:   "age",
+			policy: &Policy{BaseDelay: ptr(time.Second), MaxDelay: ptr(time.Hour), Multiplier: 1, AgeFactor: ptr(0.5), Jitter: ptr(0.0)},
+			status: []string{"REGISTERED", "REGISTERED", "REGISTERED"},
+			step:   time.Minute,
+			want:   []time.Duration{time.Second, time.Second * 30, time.Minute},
+		},
+		{
+			name:   "jitter",
+			policy: &Policy{BaseDelay: ptr(time.Second * 10), MaxDelay: ptr(time.Minute), AgeFactor: ptr(0.0), Jitter: ptr(0.2)},
+			status: []string{"REGISTERED", "REGISTERED"},
+			random: 1,
+			want:   []time.Duration{time.Second * 12, time.Second * 24},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+
+			now := time.Now()
+			schedule := New(uploadedAt(now), &Config{Policies: map[string]*Policy{
+				responses.AccrualStatusRegistered: tt.policy,
+				responses.AccrualStatusProcessing: tt.policy,
+			}})
+			schedule.now = func() time.Time {
+				return now
+			}
+			schedule.random = func() float64 {
+				return tt.random
+			}
+
+			for i, status := range tt.status {
+				delay, ok, err := schedule.Next(context.Background(), 1, status)
+				require.NoError(t, err)
+				assert.True(t, ok)
+				assert.InDelta(t, tt.want[i], delay, float64(time.Millisecond), "lookup %d", i+1)
+				now = now.Add(tt.step)
+			}
+		})
+	}
+}
+
+func TestSchedule_NextHorizon(t *testing.T) {
+	SetUp(t)
+
+	now := time.Now()
+	schedule := New(uploadedAt(now.Add(-time.Minute*30)), &Config{Horizon: ptr(time.Hour)})
+	schedule.now = func() time.Time {
+		return now
+	}
+
+	_, ok, err := schedule.Next(context.Background(), 1, responses.AccrualStatusRegistered)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute * 30)
+	_, ok, err = schedule.Next(context.Background(), 1, responses.AccrualStatusProcessing)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// age is counted from the upload time, not from the first lookup
+	schedule.Forget(1)
+	_, ok, err = schedule.Next(context.Background(), 1, responses.AccrualStatusProcessing)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	schedule.Restart(1)
+	_, ok, err = schedule.Next(context.Background(), 1, responses.AccrualStatusProcessing)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSchedule_NextUploadedAt(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository func() orderRepository
+		wantErr    bool
+		wantSince  time.Duration
+	}{
+		{
+			name: "loaded once",
+			repository: func() orderRepository {
+				orderRepository := Mock[orderRepository]()
+				WhenDouble(orderRepository.FindByID(AnyContext(), Exact(uint64(1)))).
+					ThenReturn(&entity.Order{ID: 1, CreatedAt: time.Unix(1000, 0)}, nil).
+					Verify(Once())
+
+				return orderRepository
+			},
+			wantSince: time.Hour,
+		},
+		{
+			name: "not stored",
+			repository: func() orderRepository {
+				orderRepository := Mock[orderRepository]()
+				WhenDouble(orderRepository.FindByID(AnyContext(), Exact(uint64(1)))).
+					ThenReturn(nil, nil).
+					Verify(Once())
+
+				return orderRepository
+			},
+		},
+		{
+			name: "error",
+			repository: func() orderRepository {
+				orderRepository := Mock[orderRepository]()
+				WhenDouble(orderRepository.FindByID(AnyContext(), Exact(uint64(1)))).
+					ThenReturn(nil, errors.New("database is down"))
+
+				return orderRepository
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUp(t)
+
+			now := time.Unix(1000, 0).Add(time.Hour)
+			schedule := New(tt.repository(), &Config{})
+			schedule.now = func() time.Time {
+				return now
+			}
+
+			for range 2 {
+				_, ok, err := schedule.Next(context.Background(), 1, responses.AccrualStatusRegistered)
+				if tt.wantErr {
+					require.EqualError(t, err, "database is down")
+					assert.Empty(t, schedule.orders)
+					continue
+				}
+
+				require.NoError(t, err)
+				assert.True(t, ok)
+				assert.Equal(t, now.Add(-tt.wantSince), schedule.orders[1].since)
+			}
+		})
+	}
+}
+
+func TestSchedule_Forget(t *testing.T) {
+	SetUp(t)
+
+	schedule := New(uploadedAt(time.Now()), &Config{})
+
+	_, ok, err := schedule.Next(context.Background(), 1, responses.AccrualStatusRegistered)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	schedule.Forget(1)
+	assert.Empty(t, schedule.orders)
+}
@@ -0,0 +1,148 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/deadletter"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/semaphore"
+	"go.uber.org/zap"
+)
+
+const DefaultConcurrency = 10
+const DefaultBatchSize = 100
+const DefaultLinger = time.Millisecond * 50
+const DefaultFailedTaskDelay = time.Second * 10
+
+type deadLetterManager interface {
+	AddAccrual(ctx context.Context, source string, accrual *responses.Accrual, attempts uint64, reason string) error
+}
+
+// Runner takes accruals from the queue in batches and updates them concurrently, it is embedded by status processors.
+// Failed accruals are isolated and moved to dead letters of the source
+type Runner struct {
+	queue    *queue.Queue[*responses.Accrual]
+	isolator *deadletter.Isolator
+	update   func(ctx context.Context, accruals []*responses.Accrual) error
+	// semaphore limits accruals updated at once, it may be resized at runtime
+	semaphore *semaphore.Semaphore
+	config    *Config
+}
+
+type Config struct {
+	Concurrency uint64
+	BatchSize   uint64
+	// Linger is max time to wait for the batch to be filled after the first accrual
+	Linger          *time.Duration
+	FailedTaskDelay *time.Duration
+	// MaxAttempts is count of failures after which accrual is moved to dead letters
+	MaxAttempts uint64
+}
+
+func prepareConfig(config *Config) {
+	if config.Concurrency == 0 {
+		config.Concurrency = DefaultConcurrency
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.Linger == nil || *config.Linger < 0 {
+		defaultValue := DefaultLinger
+		config.Linger = &defaultValue
+	}
+	if config.FailedTaskDelay == nil || *config.FailedTaskDelay < 0 {
+		defaultValue := DefaultFailedTaskDelay
+		config.FailedTaskDelay = &defaultValue
+	}
+}
+
+func New(
+	queue *queue.Queue[*responses.Accrual],
+	deadLetterManager deadLetterManager,
+	source string,
+	update func(ctx context.Context, accruals []*responses.Accrual) error,
+	config *Config,
+) *Runner {
+	prepareConfig(config)
+	return &Runner{
+		queue: queue,
+		isolator: deadletter.New(deadLetterManager, &deadletter.Config{
+			Source:          source,
+			MaxAttempts:     config.MaxAttempts,
+			FailedTaskDelay: config.FailedTaskDelay,
+		}),
+		update:    update,
+		semaphore: semaphore.New(config.Concurrency * config.BatchSize),
+		config:    config,
+	}
+}
+
+// Concurrency returns count of batches updated at once
+func (runner *Runner) Concurrency() uint64 {
+	return runner.semaphore.Size() / runner.config.BatchSize
+}
+
+// InFlight returns count of accruals being updated
+func (runner *Runner) InFlight() uint64 {
+	return runner.semaphore.Current()
+}
+
+func (runner *Runner) Resize(concurrency uint64) {
+	runner.semaphore.Resize(concurrency * runner.config.BatchSize)
+}
+
+// Process takes accruals from the queue until ctx is done, workCtx cancels updates in flight.
+// Process returns after all updates in flight are finished
+func (runner *Runner) Process(ctx, workCtx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		// weight of the whole batch is acquired, the part not used by a smaller batch is released
+		if err := runner.semaphore.Acquire(ctx, runner.config.BatchSize); err != nil {
+			return err
+		}
+
+		leases, err := runner.queue.ReceiveBatch(ctx, runner.config.BatchSize, *runner.config.Linger)
+		if err != nil {
+			runner.semaphore.Release(runner.config.BatchSize)
+			return err
+		}
+		runner.semaphore.Release(runner.config.BatchSize - uint64(len(leases)))
+
+		wg.Add(1)
+		go func(leases []*queue.Lease[*responses.Accrual]) {
+			defer wg.Done()
+			defer runner.semaphore.Release(uint64(len(leases)))
+			if err := runner.ProcessBatch(workCtx, leases); err != nil {
+				logger.Logger.Warn("can`t update orders", zap.Error(err))
+			}
+		}(leases)
+	}
+}
+
+// Flush synchronously updates accruals left in the queue until it is empty or ctx is done
+func (runner *Runner) Flush(ctx context.Context) error {
+	for {
+		leases := runner.queue.TryReceiveBatch(runner.config.BatchSize)
+		if len(leases) == 0 {
+			return nil
+		}
+
+		if err := runner.ProcessBatch(ctx, leases); err != nil {
+			logger.Logger.Warn("can`t update orders", zap.Error(err))
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// ProcessBatch updates accruals of leases, failed ones are nacked or moved to dead letters
+func (runner *Runner) ProcessBatch(ctx context.Context, leases []*queue.Lease[*responses.Accrual]) error {
+	return runner.isolator.Process(ctx, leases, runner.update)
+}
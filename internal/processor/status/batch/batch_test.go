@@ -0,0 +1,75 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
+	. "github.com/ovechkin-dm/mockio/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_Process(t *testing.T) {
+	SetUp(t)
+
+	accrualQueue := queue.New[*responses.Accrual](5)
+	for i := uint64(1); i <= 5; i++ {
+		accrualQueue.Push(&responses.Accrual{OrderID: i})
+	}
+
+	var mutex sync.Mutex
+	updated := make([]uint64, 0, 5)
+	linger := time.Duration(0)
+	runner := New(accrualQueue, Mock[deadLetterManager](), "test", func(ctx context.Context, accruals []*responses.Accrual) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.LessOrEqual(t, len(accruals), 2)
+		for _, accrual := range accruals {
+			updated = append(updated, accrual.OrderID)
+		}
+
+		return nil
+	}, &Config{
+		BatchSize: 2,
+		Linger:    &linger,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- runner.Process(ctx, context.Background())
+	}()
+
+	require.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(updated) == 5
+	}, time.Second, time.Millisecond*10)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	assert.ElementsMatch(t, []uint64{1, 2, 3, 4, 5}, updated)
+	assert.EqualValues(t, 0, runner.InFlight())
+}
+
+func TestRunner_Resize(t *testing.T) {
+	SetUp(t)
+
+	runner := New(queue.New[*responses.Accrual](1), Mock[deadLetterManager](), "test", func(ctx context.Context, accruals []*responses.Accrual) error {
+		return nil
+	}, &Config{
+		Concurrency: 2,
+		BatchSize:   10,
+	})
+	assert.EqualValues(t, 2, runner.Concurrency())
+	assert.EqualValues(t, 20, runner.semaphore.Size())
+
+	runner.Resize(5)
+	assert.EqualValues(t, 5, runner.Concurrency())
+	assert.EqualValues(t, 50, runner.semaphore.Size())
+	assert.EqualValues(t, 0, runner.InFlight())
+}
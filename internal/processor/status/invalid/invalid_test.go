@@ -8,13 +8,14 @@ import (
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/batch"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	. "github.com/ovechkin-dm/mockio/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestProcessor_processAccrualsOK(t *testing.T) {
+func TestProcessor_ProcessBatchOK(t *testing.T) {
 	SetUp(t)
 
 	count := rand.Uint64N(100) + 100
@@ -35,9 +36,12 @@ func TestProcessor_processAccrualsOK(t *testing.T) {
 		Exact(responses.AccrualStatusInvalid),
 	)).ThenReturn(nil)
 
-	processor := NewProcessor(invalidQueue, orderManager, &Config{})
+	processor := NewProcessor(invalidQueue, orderManager, Mock[deadLetterManager](), &batch.Config{})
 
-	require.NoError(t, processor.processAccruals(context.Background(), accruals))
+	for _, accrual := range accruals {
+		invalidQueue.Push(accrual)
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), invalidQueue.TryReceiveBatch(uint64(len(accruals)))))
 	assert.EqualValues(t, 0, invalidQueue.Count())
 
 	Verify(orderManager, Once()).UpdateStatus(
@@ -47,7 +51,7 @@ func TestProcessor_processAccrualsOK(t *testing.T) {
 	)
 }
 
-func TestProcessor_processAccrualsErr(t *testing.T) {
+func TestProcessor_ProcessBatchErr(t *testing.T) {
 	SetUp(t)
 
 	count := rand.Uint64N(100) + 100
@@ -65,16 +69,19 @@ func TestProcessor_processAccrualsErr(t *testing.T) {
 	orderManager := Mock[orderManager]()
 	WhenSingle(orderManager.UpdateStatus(
 		AnyContext(),
-		Equal(ids),
+		Any[[]uint64](),
 		Exact(responses.AccrualStatusInvalid),
 	)).ThenReturn(someErr)
 
 	noDelay := time.Duration(0)
-	processor := NewProcessor(invalidQueue, orderManager, &Config{
+	processor := NewProcessor(invalidQueue, orderManager, Mock[deadLetterManager](), &batch.Config{
 		FailedTaskDelay: &noDelay,
 	})
 
-	require.ErrorIs(t, processor.processAccruals(context.Background(), accruals), someErr)
+	for _, accrual := range accruals {
+		invalidQueue.Push(accrual)
+	}
+	require.ErrorIs(t, processor.ProcessBatch(context.Background(), invalidQueue.TryReceiveBatch(uint64(len(accruals)))), someErr)
 	assert.EqualValues(t, count, invalidQueue.Count())
 
 	Verify(orderManager, Once()).UpdateStatus(
@@ -82,4 +89,10 @@ func TestProcessor_processAccrualsErr(t *testing.T) {
 		Equal(ids),
 		Exact(responses.AccrualStatusInvalid),
 	)
+	// batch is bisected down to every single accrual
+	Verify(orderManager, Times(int(2*count-1))).UpdateStatus(
+		AnyContext(),
+		Any[[]uint64](),
+		Exact(responses.AccrualStatusInvalid),
+	)
 }
@@ -2,118 +2,46 @@ package processed
 
 import (
 	"context"
-	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
-	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/batch"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
-	"github.com/m1khal3v/gophermart-loyalty-service/pkg/semaphore"
-	"go.uber.org/zap"
 )
 
-const DefaultConcurrency = 10
-const DefaultBatchSize = 100
-const DefaultNoTasksDelay = time.Second * 5
-const DefaultFailedTaskDelay = time.Second * 10
-
 type userOrderManager interface {
-	AccrueBatch(ctx context.Context, accruals map[uint64]float64) error
-}
-
-type Processor struct {
-	processedQueue   *queue.Queue[*responses.Accrual]
-	userOrderManager userOrderManager
-	config           *Config
+	AccrueBatch(ctx context.Context, accruals map[uint64]money.Amount) error
 }
 
-type Config struct {
-	Concurrency     uint64
-	BatchSize       uint64
-	NoTasksDelay    *time.Duration
-	FailedTaskDelay *time.Duration
+type deadLetterManager interface {
+	AddAccrual(ctx context.Context, source string, accrual *responses.Accrual, attempts uint64, reason string) error
 }
 
-func prepareConfig(config *Config) {
-	if config.Concurrency == 0 {
-		config.Concurrency = DefaultConcurrency
-	}
-	if config.BatchSize == 0 {
-		config.BatchSize = DefaultBatchSize
-	}
-	if config.NoTasksDelay == nil || *config.NoTasksDelay < 0 {
-		defaultValue := DefaultNoTasksDelay
-		config.NoTasksDelay = &defaultValue
-	}
-	if config.FailedTaskDelay == nil || *config.FailedTaskDelay < 0 {
-		defaultValue := DefaultFailedTaskDelay
-		config.FailedTaskDelay = &defaultValue
-	}
+type Processor struct {
+	*batch.Runner
+	userOrderManager userOrderManager
 }
 
 func NewProcessor(
 	processedQueue *queue.Queue[*responses.Accrual],
 	userOrderManager userOrderManager,
-	config *Config,
+	deadLetterManager deadLetterManager,
+	config *batch.Config,
 ) *Processor {
-	prepareConfig(config)
-	return &Processor{
-		processedQueue:   processedQueue,
+	processor := &Processor{
 		userOrderManager: userOrderManager,
-		config:           config,
 	}
-}
-
-func (processor *Processor) Process(ctx context.Context) error {
-	semaphore := semaphore.New(processor.config.Concurrency)
-
-	for {
-		if err := semaphore.Acquire(ctx); err != nil {
-			return err
-		}
+	processor.Runner = batch.New(processedQueue, deadLetterManager, entity.DeadLetterSourceProcessed, processor.accrue, config)
 
-		if err := processor.waitIfNeed(ctx); err != nil {
-			return err
-		}
-
-		accruals := processor.processedQueue.PopBatch(processor.config.BatchSize)
-		if len(accruals) == 0 {
-			// this case should never happen
-			logger.Logger.Error("accrual in processed status queue is empty, but should not")
-			semaphore.Release()
-		} else {
-			go func(accruals []*responses.Accrual) {
-				defer semaphore.Release()
-				if err := processor.processAccruals(ctx, accruals); err != nil {
-					logger.Logger.Warn("can`t update orders", zap.Error(err))
-				}
-			}(accruals)
-		}
-	}
+	return processor
 }
 
-func (processor *Processor) processAccruals(ctx context.Context, accruals []*responses.Accrual) error {
-	batch := make(map[uint64]float64, len(accruals))
+func (processor *Processor) accrue(ctx context.Context, accruals []*responses.Accrual) error {
+	amounts := make(map[uint64]money.Amount, len(accruals))
 	for _, accrual := range accruals {
-		batch[accrual.OrderID] = *accrual.Accrual
-	}
-
-	if err := processor.userOrderManager.AccrueBatch(ctx, batch); err != nil {
-		processor.processedQueue.PushBatchDelayed(ctx, accruals, *processor.config.FailedTaskDelay)
-		return err
-	}
-
-	return nil
-}
-
-func (processor *Processor) waitIfNeed(ctx context.Context) error {
-	for processor.processedQueue.Count() == 0 {
-		select {
-		case <-ctx.Done():
-			return context.Cause(ctx)
-		case <-time.After(*processor.config.NoTasksDelay):
-			continue
-		}
+		amounts[accrual.OrderID] = *accrual.Accrual
 	}
 
-	return nil
+	return processor.userOrderManager.AccrueBatch(ctx, amounts)
 }
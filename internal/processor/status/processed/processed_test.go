@@ -8,80 +8,193 @@ import (
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/batch"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/repository"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	. "github.com/ovechkin-dm/mockio/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestProcessor_processAccrualsOK(t *testing.T) {
+func TestProcessor_ProcessBatchOK(t *testing.T) {
 	SetUp(t)
 
 	count := rand.Uint64N(100) + 100
 	processedQueue := queue.New[*responses.Accrual](count)
 	accruals := make([]*responses.Accrual, 0, count)
-	batch := make(map[uint64]float64, count)
+	amounts := make(map[uint64]money.Amount, count)
 	for i := 0; i < int(count); i++ {
-		accrual := 1.11 * float64(i)
+		accrual := money.Amount(111 * i)
 		accruals = append(accruals, &responses.Accrual{
 			OrderID: uint64(i + 1),
 			Status:  responses.AccrualStatusProcessed,
 			Accrual: &accrual,
 		})
-		batch[uint64(i+1)] = accrual
+		amounts[uint64(i+1)] = accrual
 	}
 
 	userOrderManager := Mock[userOrderManager]()
 	WhenSingle(userOrderManager.AccrueBatch(
 		AnyContext(),
-		Equal(batch),
+		Equal(amounts),
 	)).ThenReturn(nil)
 
-	processor := NewProcessor(processedQueue, userOrderManager, &Config{})
+	processor := NewProcessor(processedQueue, userOrderManager, Mock[deadLetterManager](), &batch.Config{})
 
-	require.NoError(t, processor.processAccruals(context.Background(), accruals))
+	for _, accrual := range accruals {
+		processedQueue.Push(accrual)
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), processedQueue.TryReceiveBatch(uint64(len(accruals)))))
 	assert.EqualValues(t, 0, processedQueue.Count())
 
 	Verify(userOrderManager, Once()).AccrueBatch(
 		AnyContext(),
-		Equal(batch),
+		Equal(amounts),
 	)
 }
 
-func TestProcessor_processAccrualsErr(t *testing.T) {
+func TestProcessor_ProcessBatchErr(t *testing.T) {
 	SetUp(t)
 
 	count := rand.Uint64N(100) + 100
 	processedQueue := queue.New[*responses.Accrual](count)
 	accruals := make([]*responses.Accrual, 0, count)
-	batch := make(map[uint64]float64, count)
+	amounts := make(map[uint64]money.Amount, count)
 	for i := 0; i < int(count); i++ {
-		accrual := 1.11 * float64(i)
+		accrual := money.Amount(111 * i)
 		accruals = append(accruals, &responses.Accrual{
 			OrderID: uint64(i + 1),
 			Status:  responses.AccrualStatusProcessed,
 			Accrual: &accrual,
 		})
-		batch[uint64(i+1)] = accrual
+		amounts[uint64(i+1)] = accrual
 	}
 
 	userOrderManager := Mock[userOrderManager]()
 	someErr := errors.New("some error")
 	WhenSingle(userOrderManager.AccrueBatch(
 		AnyContext(),
-		Equal(batch),
+		Any[map[uint64]money.Amount](),
 	)).ThenReturn(someErr)
 
 	noDelay := time.Duration(0)
-	processor := NewProcessor(processedQueue, userOrderManager, &Config{
+	processor := NewProcessor(processedQueue, userOrderManager, Mock[deadLetterManager](), &batch.Config{
 		FailedTaskDelay: &noDelay,
 	})
 
-	require.ErrorIs(t, processor.processAccruals(context.Background(), accruals), someErr)
+	for _, accrual := range accruals {
+		processedQueue.Push(accrual)
+	}
+	require.ErrorIs(t, processor.ProcessBatch(context.Background(), processedQueue.TryReceiveBatch(uint64(len(accruals)))), someErr)
 	assert.EqualValues(t, count, processedQueue.Count())
 
 	Verify(userOrderManager, Once()).AccrueBatch(
 		AnyContext(),
-		Equal(batch),
+		Equal(amounts),
+	)
+	// batch is bisected down to every single accrual
+	Verify(userOrderManager, Times(int(2*count-1))).AccrueBatch(
+		AnyContext(),
+		Any[map[uint64]money.Amount](),
+	)
+}
+
+func TestProcessor_ProcessBatchPoison(t *testing.T) {
+	SetUp(t)
+
+	count := rand.Uint64N(100) + 100
+	poisonID := rand.Uint64N(count) + 1
+	processedQueue := queue.New[*responses.Accrual](count)
+	accruals := make([]*responses.Accrual, 0, count)
+	var poison *responses.Accrual
+	for i := 0; i < int(count); i++ {
+		accrual := money.Amount(111 * i)
+		accruals = append(accruals, &responses.Accrual{
+			OrderID: uint64(i + 1),
+			Status:  responses.AccrualStatusProcessed,
+			Accrual: &accrual,
+		})
+		if uint64(i+1) == poisonID {
+			poison = accruals[i]
+		}
+	}
+
+	accrued := make(map[uint64]money.Amount, count)
+	userOrderManager := Mock[userOrderManager]()
+	WhenSingle(userOrderManager.AccrueBatch(
+		AnyContext(),
+		Any[map[uint64]money.Amount](),
+	)).ThenAnswer(func(args []any) error {
+		amounts := args[1].(map[uint64]money.Amount)
+		if _, ok := amounts[poisonID]; ok {
+			return repository.ErrOrderNotFound
+		}
+		for orderID, accrual := range amounts {
+			accrued[orderID] = accrual
+		}
+
+		return nil
+	})
+
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenSingle(deadLetterManager.AddAccrual(
+		AnyContext(),
+		Exact(entity.DeadLetterSourceProcessed),
+		Exact(poison),
+		Exact(uint64(1)),
+		Exact(repository.ErrOrderNotFound.Error()),
+	)).ThenReturn(nil)
+
+	processor := NewProcessor(processedQueue, userOrderManager, deadLetterManager, &batch.Config{
+		MaxAttempts: 1,
+	})
+
+	for _, accrual := range accruals {
+		processedQueue.Push(accrual)
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), processedQueue.TryReceiveBatch(uint64(len(accruals)))))
+	assert.EqualValues(t, 0, processedQueue.Count())
+	assert.Len(t, accrued, int(count-1))
+	assert.NotContains(t, accrued, poisonID)
+
+	Verify(deadLetterManager, Once()).AddAccrual(
+		AnyContext(),
+		Exact(entity.DeadLetterSourceProcessed),
+		Exact(poison),
+		Exact(uint64(1)),
+		Exact(repository.ErrOrderNotFound.Error()),
+	)
+}
+
+func TestProcessor_Flush(t *testing.T) {
+	SetUp(t)
+
+	processedQueue := queue.New[*responses.Accrual](3)
+	for i := uint64(1); i <= 3; i++ {
+		accrual := money.Amount(100 * i)
+		processedQueue.Push(&responses.Accrual{
+			OrderID: i,
+			Status:  responses.AccrualStatusProcessed,
+			Accrual: &accrual,
+		})
+	}
+
+	userOrderManager := Mock[userOrderManager]()
+	WhenSingle(userOrderManager.AccrueBatch(
+		AnyContext(),
+		Any[map[uint64]money.Amount](),
+	)).ThenReturn(nil)
+
+	processor := NewProcessor(processedQueue, userOrderManager, Mock[deadLetterManager](), &batch.Config{
+		BatchSize: 2,
+	})
+
+	require.NoError(t, processor.Flush(context.Background()))
+	assert.EqualValues(t, 0, processedQueue.Count())
+	Verify(userOrderManager, Times(2)).AccrueBatch(
+		AnyContext(),
+		Any[map[uint64]money.Amount](),
 	)
 }
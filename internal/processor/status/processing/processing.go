@@ -6,124 +6,65 @@ import (
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
-	"github.com/m1khal3v/gophermart-loyalty-service/internal/logger"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/schedule"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/batch"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
-	"github.com/m1khal3v/gophermart-loyalty-service/pkg/semaphore"
-	"go.uber.org/zap"
 )
 
-const DefaultConcurrency = 10
-const DefaultBatchSize = 100
-const DefaultNoTasksDelay = time.Second * 5
-const DefaultFailedTaskDelay = time.Second * 10
-const DefaultNotFinalStatusDelay = time.Minute
-
 type orderManager interface {
 	UpdateStatus(ctx context.Context, ids []uint64, status string) error
 }
 
-type Processor struct {
-	orderQueue      *queue.Queue[uint64]
-	processingQueue *queue.Queue[*responses.Accrual]
-	orderManager    orderManager
-	config          *Config
+type scheduler interface {
+	Next(ctx context.Context, orderID uint64, status string) (time.Duration, bool, error)
+	Forget(orderID uint64)
 }
 
-type Config struct {
-	Concurrency         uint64
-	BatchSize           uint64
-	NoTasksDelay        *time.Duration
-	FailedTaskDelay     *time.Duration
-	NotFinalStatusDelay *time.Duration
+type deadLetterManager interface {
+	AddAccrual(ctx context.Context, source string, accrual *responses.Accrual, attempts uint64, reason string) error
 }
 
-func prepareConfig(config *Config) {
-	if config.Concurrency == 0 {
-		config.Concurrency = DefaultConcurrency
-	}
-	if config.BatchSize == 0 {
-		config.BatchSize = DefaultBatchSize
-	}
-	if config.NoTasksDelay == nil || *config.NoTasksDelay < 0 {
-		defaultValue := DefaultNoTasksDelay
-		config.NoTasksDelay = &defaultValue
-	}
-	if config.FailedTaskDelay == nil || *config.FailedTaskDelay < 0 {
-		defaultValue := DefaultFailedTaskDelay
-		config.FailedTaskDelay = &defaultValue
-	}
-	if config.NotFinalStatusDelay == nil || *config.NotFinalStatusDelay < 0 {
-		defaultValue := DefaultNotFinalStatusDelay
-		config.NotFinalStatusDelay = &defaultValue
-	}
+type Processor struct {
+	*batch.Runner
+	orderQueue        *queue.Queue[uint64]
+	orderManager      orderManager
+	scheduler         scheduler
+	deadLetterManager deadLetterManager
+	config            *batch.Config
 }
 
 func NewProcessor(
 	orderQueue *queue.Queue[uint64],
 	processingQueue *queue.Queue[*responses.Accrual],
 	orderManager orderManager,
-	config *Config,
+	scheduler scheduler,
+	deadLetterManager deadLetterManager,
+	config *batch.Config,
 ) *Processor {
-	prepareConfig(config)
-	return &Processor{
-		orderQueue:      orderQueue,
-		processingQueue: processingQueue,
-		orderManager:    orderManager,
-		config:          config,
+	processor := &Processor{
+		orderQueue:        orderQueue,
+		orderManager:      orderManager,
+		scheduler:         scheduler,
+		deadLetterManager: deadLetterManager,
+		config:            config,
 	}
-}
+	processor.Runner = batch.New(processingQueue, deadLetterManager, entity.DeadLetterSourceProcessing, processor.updateStatus, config)
 
-func (processor *Processor) Process(ctx context.Context) error {
-	semaphore := semaphore.New(processor.config.Concurrency)
-
-	for {
-		if err := semaphore.Acquire(ctx); err != nil {
-			return err
-		}
-
-		if err := processor.waitIfNeed(ctx); err != nil {
-			return err
-		}
-
-		accruals := processor.processingQueue.PopBatch(processor.config.BatchSize)
-		if len(accruals) == 0 {
-			// this case should never happen
-			logger.Logger.Error("accrual in processing status queue is empty, but should not")
-			semaphore.Release()
-		} else {
-			go func(accruals []*responses.Accrual) {
-				defer semaphore.Release()
-				if err := processor.processAccruals(ctx, accruals); err != nil {
-					logger.Logger.Warn("can`t update orders", zap.Error(err))
-				}
-			}(accruals)
-		}
-	}
+	return processor
 }
 
-func (processor *Processor) processAccruals(ctx context.Context, accruals []*responses.Accrual) error {
+func (processor *Processor) updateStatus(ctx context.Context, accruals []*responses.Accrual) error {
 	ids := make([]uint64, 0, len(accruals))
 	for _, accrual := range accruals {
 		ids = append(ids, accrual.OrderID)
 	}
 
 	if err := processor.orderManager.UpdateStatus(ctx, ids, entity.OrderStatusProcessing); err != nil {
-		processor.processingQueue.PushBatchDelayed(ctx, accruals, *processor.config.FailedTaskDelay)
 		return err
 	}
 
-	processor.orderQueue.PushBatchDelayed(ctx, ids, *processor.config.NotFinalStatusDelay)
-	return nil
-}
-
-func (processor *Processor) waitIfNeed(ctx context.Context) error {
-	for processor.processingQueue.Count() == 0 {
-		select {
-		case <-ctx.Done():
-			return context.Cause(ctx)
-		case <-time.After(*processor.config.NoTasksDelay):
-			continue
-		}
+	for _, accrual := range accruals {
+		schedule.Reschedule(ctx, accrual, processor.scheduler, processor.orderQueue, processor.deadLetterManager, entity.DeadLetterSourceProcessing, *processor.config.FailedTaskDelay)
 	}
 
 	return nil
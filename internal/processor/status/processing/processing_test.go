@@ -8,13 +8,15 @@ import (
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/accrual/responses"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/processor/status/batch"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/queue"
 	. "github.com/ovechkin-dm/mockio/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestProcessor_processAccrualsOK(t *testing.T) {
+func TestProcessor_ProcessBatchOK(t *testing.T) {
 	SetUp(t)
 
 	count := rand.Uint64N(100) + 100
@@ -36,14 +38,17 @@ func TestProcessor_processAccrualsOK(t *testing.T) {
 		Exact(responses.AccrualStatusProcessing),
 	)).ThenReturn(nil)
 
-	noDelay := time.Duration(0)
-	processor := NewProcessor(orderQueue, processingQueue, orderManager, &Config{
-		NotFinalStatusDelay: &noDelay,
-	})
+	scheduler := Mock[scheduler]()
+	When(scheduler.Next(AnyContext(), Any[uint64](), Exact(responses.AccrualStatusProcessing))).ThenReturn(time.Duration(0), true, nil)
+	processor := NewProcessor(orderQueue, processingQueue, orderManager, scheduler, Mock[deadLetterManager](), &batch.Config{})
 
-	require.NoError(t, processor.processAccruals(context.Background(), accruals))
+	for _, accrual := range accruals {
+		processingQueue.Push(accrual)
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), processingQueue.TryReceiveBatch(uint64(len(accruals)))))
 	assert.EqualValues(t, 0, processingQueue.Count())
 	assert.EqualValues(t, count, orderQueue.Count())
+	Verify(scheduler, Times(int(count))).Next(AnyContext(), Any[uint64](), Exact(responses.AccrualStatusProcessing))
 
 	Verify(orderManager, Once()).UpdateStatus(
 		AnyContext(),
@@ -52,7 +57,7 @@ func TestProcessor_processAccrualsOK(t *testing.T) {
 	)
 }
 
-func TestProcessor_processAccrualsErr(t *testing.T) {
+func TestProcessor_ProcessBatchErr(t *testing.T) {
 	SetUp(t)
 
 	count := rand.Uint64N(100) + 100
@@ -71,16 +76,19 @@ func TestProcessor_processAccrualsErr(t *testing.T) {
 	orderManager := Mock[orderManager]()
 	WhenSingle(orderManager.UpdateStatus(
 		AnyContext(),
-		Equal(ids),
+		Any[[]uint64](),
 		Exact(responses.AccrualStatusProcessing),
 	)).ThenReturn(someErr)
 
 	noDelay := time.Duration(0)
-	processor := NewProcessor(orderQueue, processingQueue, orderManager, &Config{
+	processor := NewProcessor(orderQueue, processingQueue, orderManager, Mock[scheduler](), Mock[deadLetterManager](), &batch.Config{
 		FailedTaskDelay: &noDelay,
 	})
 
-	require.ErrorIs(t, processor.processAccruals(context.Background(), accruals), someErr)
+	for _, accrual := range accruals {
+		processingQueue.Push(accrual)
+	}
+	require.ErrorIs(t, processor.ProcessBatch(context.Background(), processingQueue.TryReceiveBatch(uint64(len(accruals)))), someErr)
 	assert.EqualValues(t, count, processingQueue.Count())
 	assert.EqualValues(t, 0, orderQueue.Count())
 
@@ -89,4 +97,51 @@ func TestProcessor_processAccrualsErr(t *testing.T) {
 		Equal(ids),
 		Exact(responses.AccrualStatusProcessing),
 	)
+	// batch is bisected down to every single accrual
+	Verify(orderManager, Times(int(2*count-1))).UpdateStatus(
+		AnyContext(),
+		Any[[]uint64](),
+		Exact(responses.AccrualStatusProcessing),
+	)
+}
+
+func TestProcessor_ProcessBatchHorizon(t *testing.T) {
+	SetUp(t)
+
+	orderQueue := queue.New[uint64](2)
+	processingQueue := queue.New[*responses.Accrual](2)
+	accruals := []*responses.Accrual{
+		{OrderID: 1, Status: responses.AccrualStatusProcessing},
+		{OrderID: 2, Status: responses.AccrualStatusProcessing},
+	}
+	orderManager := Mock[orderManager]()
+	WhenSingle(orderManager.UpdateStatus(
+		AnyContext(),
+		Equal([]uint64{1, 2}),
+		Exact(responses.AccrualStatusProcessing),
+	)).ThenReturn(nil)
+	scheduler := Mock[scheduler]()
+	When(scheduler.Next(AnyContext(), Exact(uint64(1)), Exact(responses.AccrualStatusProcessing))).ThenReturn(time.Duration(0), true, nil)
+	When(scheduler.Next(AnyContext(), Exact(uint64(2)), Exact(responses.AccrualStatusProcessing))).ThenReturn(time.Duration(0), false, nil)
+	deadLetterManager := Mock[deadLetterManager]()
+	WhenSingle(deadLetterManager.AddAccrual(
+		AnyContext(),
+		Exact(entity.DeadLetterSourceProcessing),
+		Exact(accruals[1]),
+		Exact(uint64(1)),
+		Exact("polling horizon exceeded"),
+	)).ThenReturn(nil)
+
+	processor := NewProcessor(orderQueue, processingQueue, orderManager, scheduler, deadLetterManager, &batch.Config{})
+
+	for _, accrual := range accruals {
+		processingQueue.Push(accrual)
+	}
+	require.NoError(t, processor.ProcessBatch(context.Background(), processingQueue.TryReceiveBatch(uint64(len(accruals)))))
+	assert.EqualValues(t, 0, processingQueue.Count())
+	require.EqualValues(t, 1, orderQueue.Count())
+	orderID, ok := orderQueue.Pop()
+	require.True(t, ok)
+	assert.EqualValues(t, 1, orderID)
+	Verify(scheduler, Once()).Forget(Exact(uint64(2)))
 }
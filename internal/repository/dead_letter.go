@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeadLetterRepository struct {
+	*Repository[entity.DeadLetter]
+}
+
+func NewDeadLetterRepository(db *gorm.DB) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		Repository: New[entity.DeadLetter](db),
+	}
+}
+
+// Upsert replaces dead letter of the same order, so an order has at most one dead letter
+func (repository *DeadLetterRepository) Upsert(ctx context.Context, deadLetter *entity.DeadLetter) error {
+	return repository.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "order_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source", "status", "accrual", "attempts", "reason", "created_at"}),
+	}).Create(deadLetter).Error
+}
+
+func (repository *DeadLetterRepository) FindOneByID(ctx context.Context, id uint64) (*entity.DeadLetter, error) {
+	return repository.FindOneBy(ctx, "id = ?", id)
+}
+
+func (repository *DeadLetterRepository) FindAll(ctx context.Context) (*generator.Stream[*entity.DeadLetter], error) {
+	return repository.FindBy(ctx, "id ASC", "1 = 1")
+}
+
+// DeleteByID returns false if dead letter does not exist (e.g. is already replayed)
+func (repository *DeadLetterRepository) DeleteByID(ctx context.Context, id uint64) (bool, error) {
+	result := repository.db.WithContext(ctx).Delete(&entity.DeadLetter{}, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
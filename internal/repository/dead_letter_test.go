@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterRepository_FindOneByID(t *testing.T) {
+	gorm, sqlMock := NewDBMock(t)
+	repository := NewDeadLetterRepository(gorm)
+	id := rand.Uint64N(1000) + 1
+	orderID := rand.Uint64N(1000) + 1
+	rows := sqlMock.
+		NewRows([]string{"id", "order_id", "source", "status", "accrual", "attempts", "reason", "created_at"}).
+		AddRow(int64(id), int64(orderID), entity.DeadLetterSourceProcessed, "PROCESSED", int64(500), int64(10), "order not found", time.Now())
+	sqlMock.
+		ExpectQuery(`SELECT * FROM "dead_letters" WHERE id = $1 LIMIT $2`).
+		WithArgs(id, 1).
+		WillReturnRows(rows)
+
+	deadLetter, err := repository.FindOneByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, id, deadLetter.ID)
+	assert.Equal(t, orderID, deadLetter.OrderID)
+	assert.Equal(t, entity.DeadLetterSourceProcessed, deadLetter.Source)
+	require.NotNil(t, deadLetter.Accrual)
+	assert.EqualValues(t, 500, *deadLetter.Accrual)
+	assert.EqualValues(t, 10, deadLetter.Attempts)
+}
+
+func TestDeadLetterRepository_DeleteByID(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		want         bool
+	}{
+		{
+			name:         "deleted",
+			rowsAffected: 1,
+			want:         true,
+		},
+		{
+			name:         "not found",
+			rowsAffected: 0,
+			want:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gorm, sqlMock := NewDBMock(t)
+			repository := NewDeadLetterRepository(gorm)
+			id := rand.Uint64N(1000) + 1
+			sqlMock.ExpectBegin()
+			sqlMock.
+				ExpectExec(`DELETE FROM "dead_letters" WHERE "dead_letters"."id" = $1`).
+				WithArgs(id).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			sqlMock.ExpectCommit()
+
+			deleted, err := repository.DeleteByID(context.Background(), id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, deleted)
+			require.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeadLetterRepository_Upsert(t *testing.T) {
+	gorm, sqlMock := NewDBMock(t)
+	repository := NewDeadLetterRepository(gorm)
+	orderID := rand.Uint64N(1000) + 1
+	sqlMock.ExpectBegin()
+	sqlMock.
+		ExpectQuery(`INSERT INTO "dead_letters" ("order_id","source","status","accrual","attempts","reason","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT ("order_id") DO UPDATE SET "source"="excluded"."source","status"="excluded"."status","accrual"="excluded"."accrual","attempts"="excluded"."attempts","reason"="excluded"."reason","created_at"="excluded"."created_at" RETURNING "id"`).
+		WithArgs(orderID, entity.DeadLetterSourceRetriever, "", nil, 1, "reason", sqlmock.AnyArg()).
+		WillReturnRows(sqlMock.NewRows([]string{"id"}).AddRow(int64(1)))
+	sqlMock.ExpectCommit()
+
+	deadLetter := &entity.DeadLetter{
+		OrderID:  orderID,
+		Source:   entity.DeadLetterSourceRetriever,
+		Attempts: 1,
+		Reason:   "reason",
+	}
+	require.NoError(t, repository.Upsert(context.Background(), deadLetter))
+	assert.EqualValues(t, 1, deadLetter.ID)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
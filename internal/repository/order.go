@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"gorm.io/gorm"
 )
 
@@ -26,7 +28,7 @@ func (repository *OrderRepository) FindOneByUserID(ctx context.Context, userID u
 	return repository.FindOneBy(ctx, "user_id = ?", userID)
 }
 
-func (repository *OrderRepository) FindByUserID(ctx context.Context, userID uint32) (<-chan *entity.Order, error) {
+func (repository *OrderRepository) FindByUserID(ctx context.Context, userID uint32) (*generator.Stream[*entity.Order], error) {
 	return repository.FindBy(ctx, "created_at DESC", "user_id = ?", userID)
 }
 
@@ -34,16 +36,37 @@ func (repository *OrderRepository) FindByID(ctx context.Context, id uint64) (*en
 	return repository.FindOneBy(ctx, "id = ?", id)
 }
 
-func (repository *OrderRepository) FindUnprocessedIDs(ctx context.Context) (<-chan uint64, error) {
-	return repository.FindIDsBy(ctx, "created_at ASC", "status IN (?)", []string{
-		entity.OrderStatusNew,
-		entity.OrderStatusProcessing,
-	})
+// FindUnprocessedIDs skips orders parked in dead letters until they are replayed
+func (repository *OrderRepository) FindUnprocessedIDs(ctx context.Context) (*generator.Stream[uint64], error) {
+	return repository.FindIDsBy(
+		ctx,
+		"created_at ASC",
+		"status IN (?) AND NOT EXISTS (SELECT 1 FROM dead_letters WHERE dead_letters.order_id = orders.id)",
+		[]string{
+			entity.OrderStatusNew,
+			entity.OrderStatusProcessing,
+		},
+	)
 }
 
+// Process sets accrual and PROCESSED status, false means the order is processed already
+func (repository *OrderRepository) Process(ctx context.Context, id uint64, accrual money.Amount) (bool, error) {
+	affected, err := repository.Updates(ctx, &entity.Order{}, map[string]interface{}{
+		"status":  entity.OrderStatusProcessed,
+		"accrual": accrual,
+	}, "id = ? AND status <> ?", id, entity.OrderStatusProcessed)
+
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// UpdateStatus skips processed orders, so a repeated update can not reopen an order which is credited already
 func (repository *OrderRepository) UpdateStatus(ctx context.Context, ids []uint64, status string) error {
 	return repository.UpdateOmitZero(ctx, &entity.Order{}, &entity.Order{
 		Status:    status,
 		UpdatedAt: time.Now(),
-	}, "id IN (?)", ids)
+	}, "id IN (?) AND status <> ?", ids, entity.OrderStatusProcessed)
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
 	"math/rand/v2"
 	"testing"
 	"time"
@@ -53,7 +54,7 @@ func TestOrderRepository_FindByUserID(t *testing.T) {
 	orders, err := repository.FindByUserID(context.Background(), userID)
 	require.NoError(t, err)
 	ordersSlice := make([]*entity.Order, 0, 2)
-	for order := range orders {
+	for order := range orders.Items() {
 		ordersSlice = append(ordersSlice, order)
 	}
 	require.Len(t, ordersSlice, 2)
@@ -71,6 +72,33 @@ func TestOrderRepository_FindByUserID(t *testing.T) {
 	assert.Equal(t, accrual+1, uint64(order.Accrual))
 }
 
+func TestOrderRepository_FindByUserIDRowError(t *testing.T) {
+	gorm, sqlMock := NewDBMock(t)
+	repository := NewOrderRepository(gorm)
+	userID := rand.Uint32N(1000) + 1
+	rowErr := errors.New("connection reset")
+	rows := sqlMock.
+		NewRows([]string{"id", "user_id", "status", "accrual", "created_at", "updated_at"}).
+		AddRow(int64(1), int32(userID), "TEST_STATUS", int64(100), time.Now(), time.Now()).
+		AddRow(int64(2), int32(userID), "TEST_STATUS", int64(100), time.Now(), time.Now()).
+		RowError(1, rowErr)
+	sqlMock.
+		ExpectQuery(`SELECT * FROM "orders" WHERE user_id = $1 ORDER BY created_at DESC`).
+		WithArgs(userID).
+		WillReturnRows(rows).
+		RowsWillBeClosed()
+
+	orders, err := repository.FindByUserID(context.Background(), userID)
+	require.NoError(t, err)
+	ordersSlice := make([]*entity.Order, 0, 1)
+	for order := range orders.Items() {
+		ordersSlice = append(ordersSlice, order)
+	}
+	require.Len(t, ordersSlice, 1)
+	require.ErrorIs(t, orders.Err(), rowErr)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
 func TestOrderRepository_FindByID(t *testing.T) {
 	gorm, sqlMock := NewDBMock(t)
 	repository := NewOrderRepository(gorm)
@@ -102,14 +130,14 @@ func TestOrderRepository_FindUnprocessedIDs(t *testing.T) {
 		AddRow(int64(id)).
 		AddRow(int64(id) + 1)
 	sqlMock.
-		ExpectQuery(`SELECT * FROM "orders" WHERE status IN ($1,$2) ORDER BY created_at ASC`).
+		ExpectQuery(`SELECT * FROM "orders" WHERE status IN ($1,$2) AND NOT EXISTS (SELECT 1 FROM dead_letters WHERE dead_letters.order_id = orders.id) ORDER BY created_at ASC`).
 		WithArgs(entity.OrderStatusNew, entity.OrderStatusProcessing).
 		WillReturnRows(rows)
 
 	ids, err := repository.FindUnprocessedIDs(context.Background())
 	require.NoError(t, err)
 	idsSlice := make([]uint64, 0, 2)
-	for queriedID := range ids {
+	for queriedID := range ids.Items() {
 		idsSlice = append(idsSlice, queriedID)
 	}
 	require.Len(t, idsSlice, 2)
@@ -132,8 +160,8 @@ func TestOrderRepository_UpdateStatus(t *testing.T) {
 
 	sqlMock.ExpectBegin()
 	sqlMock.
-		ExpectExec(`UPDATE "orders" SET "status"=$1,"updated_at"=$2 WHERE id IN ($3,$4,$5)`).
-		WithArgs("TEST_STATUS", sqlmock.AnyArg(), ids[0], ids[1], ids[2]).
+		ExpectExec(`UPDATE "orders" SET "status"=$1,"updated_at"=$2 WHERE id IN ($3,$4,$5) AND status <> $6`).
+		WithArgs("TEST_STATUS", sqlmock.AnyArg(), ids[0], ids[1], ids[2], entity.OrderStatusProcessed).
 		WillReturnResult(driver.ResultNoRows)
 	sqlMock.ExpectCommit()
 
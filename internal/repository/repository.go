@@ -75,54 +75,56 @@ func (repository *Repository[T]) CreateOrFind(ctx context.Context, entity *T) (*
 	return entity, true, nil
 }
 
-func (repository *Repository[T]) FindBy(ctx context.Context, order, condition any, args ...any) (<-chan *T, error) {
+// FindBy streams entities, rows are closed once the stream is stopped or closed
+func (repository *Repository[T]) FindBy(ctx context.Context, order, condition any, args ...any) (*generator.Stream[*T], error) {
 	result, err := repository.findModelBy(ctx, new(T), order, condition, args...)
 	if err != nil {
 		return nil, err
 	}
 	if result.Err() != nil {
-		return nil, result.Err()
+		return nil, errors.Join(result.Err(), result.Close())
 	}
 
-	return generator.NewFromFunctionWithContext(ctx, func() (*T, bool) {
+	return generator.NewStream(ctx, func() (*T, bool, error) {
 		if !result.Next() {
-			return nil, false
+			return nil, false, result.Err()
 		}
 
 		entity := new(T)
 		if err := repository.db.ScanRows(result, entity); err != nil {
-			return nil, false
+			return nil, false, err
 		}
 
-		return entity, true
-	}), nil
+		return entity, true, nil
+	}, result.Close), nil
 }
 
 type ID struct {
 	ID uint64
 }
 
-func (repository *Repository[T]) FindIDsBy(ctx context.Context, order, condition any, args ...any) (<-chan uint64, error) {
+// FindIDsBy streams IDs of entities, rows are closed once the stream is stopped or closed
+func (repository *Repository[T]) FindIDsBy(ctx context.Context, order, condition any, args ...any) (*generator.Stream[uint64], error) {
 	result, err := repository.findModelBy(ctx, new(T), order, condition, args...)
 	if err != nil {
 		return nil, err
 	}
 	if result.Err() != nil {
-		return nil, result.Err()
+		return nil, errors.Join(result.Err(), result.Close())
 	}
 
-	return generator.NewFromFunctionWithContext(ctx, func() (uint64, bool) {
+	return generator.NewStream(ctx, func() (uint64, bool, error) {
 		if !result.Next() {
-			return 0, false
+			return 0, false, result.Err()
 		}
 
 		entity := &ID{}
 		if err := repository.db.ScanRows(result, entity); err != nil {
-			return 0, false
+			return 0, false, err
 		}
 
-		return entity.ID, true
-	}), nil
+		return entity.ID, true, nil
+	}, result.Close), nil
 }
 
 func (repository *Repository[T]) findModelBy(ctx context.Context, model, order, condition any, args ...any) (*sql.Rows, error) {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/phc"
 	"gorm.io/gorm"
 )
 
@@ -26,12 +27,11 @@ func (repository *UserRepository) FindByID(ctx context.Context, id uint32) (*ent
 	return repository.FindOneBy(ctx, "id = ?", id)
 }
 
-func (repository *UserRepository) Withdraw(ctx context.Context, id uint32, sum float64) (bool, error) {
-	money := money.New(sum)
+func (repository *UserRepository) Withdraw(ctx context.Context, id uint32, sum money.Amount) (bool, error) {
 	affected, err := repository.Updates(ctx, &entity.User{}, map[string]interface{}{
-		"balance":   gorm.Expr("balance - ?", money),
-		"withdrawn": gorm.Expr("withdrawn + ?", money),
-	}, "id = ? AND balance >= ?", id, money)
+		"balance":   gorm.Expr("balance - ?", sum),
+		"withdrawn": gorm.Expr("withdrawn + ?", sum),
+	}, "id = ? AND balance >= ?", id, sum)
 
 	if err != nil {
 		return false, err
@@ -40,10 +40,9 @@ func (repository *UserRepository) Withdraw(ctx context.Context, id uint32, sum f
 	return affected == 1, nil
 }
 
-func (repository *UserRepository) Accrue(ctx context.Context, id uint32, sum float64) (bool, error) {
-	money := money.New(sum)
+func (repository *UserRepository) Accrue(ctx context.Context, id uint32, sum money.Amount) (bool, error) {
 	affected, err := repository.Updates(ctx, &entity.User{}, map[string]interface{}{
-		"balance": gorm.Expr("balance + ?", money),
+		"balance": gorm.Expr("balance + ?", sum),
 	}, "id = ?", id)
 
 	if err != nil {
@@ -52,3 +51,47 @@ func (repository *UserRepository) Accrue(ctx context.Context, id uint32, sum flo
 
 	return affected == 1, nil
 }
+
+// UpdatePassword replaces password only if it was not changed since current was read
+func (repository *UserRepository) UpdatePassword(ctx context.Context, id uint32, current, replacement phc.Hash) (bool, error) {
+	affected, err := repository.Updates(ctx, &entity.User{}, map[string]interface{}{
+		"password": replacement,
+	}, "id = ? AND password = ?", id, current)
+
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// ChangePassword replaces password and revokes issued tokens
+func (repository *UserRepository) ChangePassword(ctx context.Context, id uint32, current, replacement phc.Hash) (bool, error) {
+	affected, err := repository.Updates(ctx, &entity.User{}, map[string]interface{}{
+		"password":      replacement,
+		"token_version": gorm.Expr("token_version + 1"),
+	}, "id = ? AND password = ? AND deleted_at IS NULL", id, current)
+
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Anonymize replaces user credentials and marks user as deleted. Orders and withdrawals are kept,
+// so fk_users_orders and fk_users_withdrawals remain valid
+func (repository *UserRepository) Anonymize(ctx context.Context, id uint32, login string) (bool, error) {
+	affected, err := repository.Updates(ctx, &entity.User{}, map[string]interface{}{
+		"login":         login,
+		"password":      phc.Hash(""),
+		"token_version": gorm.Expr("token_version + 1"),
+		"deleted_at":    gorm.Expr("NOW()"),
+	}, "id = ? AND deleted_at IS NULL", id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 
-	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"gorm.io/gorm"
 )
@@ -22,7 +21,8 @@ func NewUserOrderRepository(db *gorm.DB) *UserOrderRepository {
 	}
 }
 
-func (userOrderRepository *UserOrderRepository) Accrue(ctx context.Context, orderID uint64, accrual float64) error {
+// Accrue marks the order as processed and credits the user balance. Accrue of processed order is a no-op
+func (userOrderRepository *UserOrderRepository) Accrue(ctx context.Context, orderID uint64, accrual money.Amount) error {
 	return userOrderRepository.db.Transaction(func(transaction *gorm.DB) error {
 		orderRepository := NewOrderRepository(transaction)
 		userRepository := NewUserRepository(transaction)
@@ -35,12 +35,12 @@ func (userOrderRepository *UserOrderRepository) Accrue(ctx context.Context, orde
 			return ErrOrderNotFound
 		}
 
-		order.Status = entity.OrderStatusProcessed
-		order.Accrual = money.New(accrual)
-		if err := orderRepository.Save(ctx, order); err != nil {
+		// accrual may be delivered again while the first update commits, balance is credited once
+		processed, err := orderRepository.Process(ctx, orderID, accrual)
+		if err != nil {
 			return err
 		}
-		if accrual == 0 {
+		if !processed || accrual == 0 {
 			return nil
 		}
 
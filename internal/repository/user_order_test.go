@@ -19,7 +19,7 @@ func TestUserOrderRepository_AccrueOK(t *testing.T) {
 	repository := NewUserOrderRepository(gorm)
 	id := rand.Uint64N(1000) + 1
 	userID := rand.Uint32N(1000) + 1
-	sum := money.New(rand.Float64() + 100)
+	sum := money.Amount(rand.Uint64N(10000) + 100)
 
 	sqlMock.ExpectBegin()
 	rows := sqlMock.
@@ -30,8 +30,8 @@ func TestUserOrderRepository_AccrueOK(t *testing.T) {
 		WithArgs(id, 1).
 		WillReturnRows(rows)
 	sqlMock.
-		ExpectExec(`UPDATE "orders" SET "user_id"=$1,"status"=$2,"accrual"=$3,"created_at"=$4,"updated_at"=$5 WHERE "id" = $6`).
-		WithArgs(userID, entity.OrderStatusProcessed, uint64(sum), sqlmock.AnyArg(), sqlmock.AnyArg(), id).
+		ExpectExec(`UPDATE "orders" SET "accrual"=$1,"status"=$2,"updated_at"=$3 WHERE id = $4 AND status <> $5`).
+		WithArgs(uint64(sum), entity.OrderStatusProcessed, sqlmock.AnyArg(), id, entity.OrderStatusProcessed).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	sqlMock.
 		ExpectExec(`UPDATE "users" SET "balance"=balance + $1,"updated_at"=$2 WHERE id = $3`).
@@ -39,7 +39,7 @@ func TestUserOrderRepository_AccrueOK(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	sqlMock.ExpectCommit()
 
-	err := repository.Accrue(context.Background(), id, sum.AsFloat())
+	err := repository.Accrue(context.Background(), id, sum)
 	require.NoError(t, err)
 }
 
@@ -48,7 +48,7 @@ func TestUserOrderRepository_AccrueFailed(t *testing.T) {
 	repository := NewUserOrderRepository(gorm)
 	id := rand.Uint64N(1000) + 1
 	userID := rand.Uint32N(1000) + 1
-	sum := money.New(rand.Float64() + 100)
+	sum := money.Amount(rand.Uint64N(10000) + 100)
 
 	sqlMock.ExpectBegin()
 	rows := sqlMock.
@@ -59,8 +59,8 @@ func TestUserOrderRepository_AccrueFailed(t *testing.T) {
 		WithArgs(id, 1).
 		WillReturnRows(rows)
 	sqlMock.
-		ExpectExec(`UPDATE "orders" SET "user_id"=$1,"status"=$2,"accrual"=$3,"created_at"=$4,"updated_at"=$5 WHERE "id" = $6`).
-		WithArgs(userID, entity.OrderStatusProcessed, uint64(sum), sqlmock.AnyArg(), sqlmock.AnyArg(), id).
+		ExpectExec(`UPDATE "orders" SET "accrual"=$1,"status"=$2,"updated_at"=$3 WHERE id = $4 AND status <> $5`).
+		WithArgs(uint64(sum), entity.OrderStatusProcessed, sqlmock.AnyArg(), id, entity.OrderStatusProcessed).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	sqlMock.
 		ExpectExec(`UPDATE "users" SET "balance"=balance + $1,"updated_at"=$2 WHERE id = $3`).
@@ -68,16 +68,51 @@ func TestUserOrderRepository_AccrueFailed(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	sqlMock.ExpectRollback()
 
-	err := repository.Accrue(context.Background(), id, sum.AsFloat())
+	err := repository.Accrue(context.Background(), id, sum)
 	assert.ErrorIs(t, err, ErrAccrueFailed)
 }
 
+func TestUserOrderRepository_AccrueTwice(t *testing.T) {
+	gorm, sqlMock := NewDBMock(t)
+	repository := NewUserOrderRepository(gorm)
+	id := rand.Uint64N(1000) + 1
+	userID := rand.Uint32N(1000) + 1
+	sum := money.Amount(rand.Uint64N(10000) + 100)
+
+	for _, affected := range []int64{1, 0} {
+		sqlMock.ExpectBegin()
+		rows := sqlMock.
+			NewRows([]string{"id", "user_id", "status", "accrual", "created_at", "updated_at"}).
+			AddRow(int64(id), int32(userID), "TEST_STATUS", int64(0), time.Now(), time.Now())
+		sqlMock.
+			ExpectQuery(`SELECT * FROM "orders" WHERE id = $1 LIMIT $2`).
+			WithArgs(id, 1).
+			WillReturnRows(rows)
+		sqlMock.
+			ExpectExec(`UPDATE "orders" SET "accrual"=$1,"status"=$2,"updated_at"=$3 WHERE id = $4 AND status <> $5`).
+			WithArgs(uint64(sum), entity.OrderStatusProcessed, sqlmock.AnyArg(), id, entity.OrderStatusProcessed).
+			WillReturnResult(sqlmock.NewResult(0, affected))
+		if affected == 1 {
+			sqlMock.
+				ExpectExec(`UPDATE "users" SET "balance"=balance + $1,"updated_at"=$2 WHERE id = $3`).
+				WithArgs(uint64(sum), sqlmock.AnyArg(), userID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		sqlMock.ExpectCommit()
+	}
+
+	// second delivery of the same accrual does not credit the balance
+	require.NoError(t, repository.Accrue(context.Background(), id, sum))
+	require.NoError(t, repository.Accrue(context.Background(), id, sum))
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
 func TestUserOrderRepository_AccrueZero(t *testing.T) {
 	gorm, sqlMock := NewDBMock(t)
 	repository := NewUserOrderRepository(gorm)
 	id := rand.Uint64N(1000) + 1
 	userID := rand.Uint32N(1000) + 1
-	sum := money.New(0.0)
+	sum := money.Amount(0)
 
 	sqlMock.ExpectBegin()
 	rows := sqlMock.
@@ -88,12 +123,12 @@ func TestUserOrderRepository_AccrueZero(t *testing.T) {
 		WithArgs(id, 1).
 		WillReturnRows(rows)
 	sqlMock.
-		ExpectExec(`UPDATE "orders" SET "user_id"=$1,"status"=$2,"accrual"=$3,"created_at"=$4,"updated_at"=$5 WHERE "id" = $6`).
-		WithArgs(userID, entity.OrderStatusProcessed, uint64(sum), sqlmock.AnyArg(), sqlmock.AnyArg(), id).
+		ExpectExec(`UPDATE "orders" SET "accrual"=$1,"status"=$2,"updated_at"=$3 WHERE id = $4 AND status <> $5`).
+		WithArgs(uint64(sum), entity.OrderStatusProcessed, sqlmock.AnyArg(), id, entity.OrderStatusProcessed).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	sqlMock.ExpectCommit()
 
-	err := repository.Accrue(context.Background(), id, sum.AsFloat())
+	err := repository.Accrue(context.Background(), id, sum)
 	require.NoError(t, err)
 }
 
@@ -101,7 +136,7 @@ func TestUserOrderRepository_AccrueOrderNotFound(t *testing.T) {
 	gorm, sqlMock := NewDBMock(t)
 	repository := NewUserOrderRepository(gorm)
 	id := rand.Uint64N(1000) + 1
-	sum := money.New(rand.Float64() + 100)
+	sum := money.Amount(rand.Uint64N(10000) + 100)
 
 	sqlMock.ExpectBegin()
 	sqlMock.
@@ -110,6 +145,6 @@ func TestUserOrderRepository_AccrueOrderNotFound(t *testing.T) {
 		WillReturnError(gormerr.ErrRecordNotFound)
 	sqlMock.ExpectRollback()
 
-	err := repository.Accrue(context.Background(), id, sum.AsFloat())
+	err := repository.Accrue(context.Background(), id, sum)
 	require.ErrorIs(t, err, ErrOrderNotFound)
 }
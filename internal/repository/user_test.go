@@ -9,6 +9,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/phc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -79,7 +80,7 @@ func TestUserRepository_Withdraw(t *testing.T) {
 			gorm, sqlMock := NewDBMock(t)
 			repository := NewUserRepository(gorm)
 			id := rand.Uint32N(1000) + 1
-			sum := money.New(rand.Float64() + 100)
+			sum := money.Amount(rand.Uint64N(10000) + 100)
 
 			sqlMock.ExpectBegin()
 			sqlMock.
@@ -88,7 +89,7 @@ func TestUserRepository_Withdraw(t *testing.T) {
 				WillReturnResult(tt.result)
 			sqlMock.ExpectCommit()
 
-			ok, err := repository.Withdraw(context.Background(), id, sum.AsFloat())
+			ok, err := repository.Withdraw(context.Background(), id, sum)
 			require.NoError(t, err)
 			assert.Equal(t, tt.ok, ok)
 		})
@@ -117,7 +118,7 @@ func TestUserRepository_Accrue(t *testing.T) {
 			gorm, sqlMock := NewDBMock(t)
 			repository := NewUserRepository(gorm)
 			id := rand.Uint32N(1000) + 1
-			sum := money.New(rand.Float64() + 100)
+			sum := money.Amount(rand.Uint64N(10000) + 100)
 
 			sqlMock.ExpectBegin()
 			sqlMock.
@@ -126,7 +127,122 @@ func TestUserRepository_Accrue(t *testing.T) {
 				WillReturnResult(tt.result)
 			sqlMock.ExpectCommit()
 
-			ok, err := repository.Accrue(context.Background(), id, sum.AsFloat())
+			ok, err := repository.Accrue(context.Background(), id, sum)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	tests := []struct {
+		name   string
+		result driver.Result
+		ok     bool
+	}{
+		{
+			name:   "success",
+			result: sqlmock.NewResult(0, 1),
+			ok:     true,
+		},
+		{
+			name:   "password changed concurrently",
+			result: driver.ResultNoRows,
+			ok:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gorm, sqlMock := NewDBMock(t)
+			repository := NewUserRepository(gorm)
+			id := rand.Uint32N(1000) + 1
+			current := phc.Hash("$2a$04$current")
+			replacement := phc.Hash("$argon2id$replacement")
+
+			sqlMock.ExpectBegin()
+			sqlMock.
+				ExpectExec(`UPDATE "users" SET "password"=$1,"updated_at"=$2 WHERE id = $3 AND password = $4`).
+				WithArgs(string(replacement), sqlmock.AnyArg(), id, string(current)).
+				WillReturnResult(tt.result)
+			sqlMock.ExpectCommit()
+
+			ok, err := repository.UpdatePassword(context.Background(), id, current, replacement)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestUserRepository_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name   string
+		result driver.Result
+		ok     bool
+	}{
+		{
+			name:   "success",
+			result: sqlmock.NewResult(0, 1),
+			ok:     true,
+		},
+		{
+			name:   "failure",
+			result: driver.ResultNoRows,
+			ok:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gorm, sqlMock := NewDBMock(t)
+			repository := NewUserRepository(gorm)
+			id := rand.Uint32N(1000) + 1
+			current := phc.Hash("$argon2id$current")
+			replacement := phc.Hash("$argon2id$replacement")
+
+			sqlMock.ExpectBegin()
+			sqlMock.
+				ExpectExec(`UPDATE "users" SET "password"=$1,"token_version"=token_version + 1,"updated_at"=$2 WHERE id = $3 AND password = $4 AND deleted_at IS NULL`).
+				WithArgs(string(replacement), sqlmock.AnyArg(), id, string(current)).
+				WillReturnResult(tt.result)
+			sqlMock.ExpectCommit()
+
+			ok, err := repository.ChangePassword(context.Background(), id, current, replacement)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestUserRepository_Anonymize(t *testing.T) {
+	tests := []struct {
+		name   string
+		result driver.Result
+		ok     bool
+	}{
+		{
+			name:   "success",
+			result: sqlmock.NewResult(0, 1),
+			ok:     true,
+		},
+		{
+			name:   "already deleted",
+			result: driver.ResultNoRows,
+			ok:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gorm, sqlMock := NewDBMock(t)
+			repository := NewUserRepository(gorm)
+			id := rand.Uint32N(1000) + 1
+
+			sqlMock.ExpectBegin()
+			sqlMock.
+				ExpectExec(`UPDATE "users" SET "deleted_at"=NOW(),"login"=$1,"password"=$2,"token_version"=token_version + 1,"updated_at"=$3 WHERE id = $4 AND deleted_at IS NULL`).
+				WithArgs("deleted:1", "", sqlmock.AnyArg(), id).
+				WillReturnResult(tt.result)
+			sqlMock.ExpectCommit()
+
+			ok, err := repository.Anonymize(context.Background(), id, "deleted:1")
 			require.NoError(t, err)
 			assert.Equal(t, tt.ok, ok)
 		})
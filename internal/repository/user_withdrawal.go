@@ -21,11 +21,11 @@ func NewUserWithdrawalRepository(db *gorm.DB) *UserWithdrawalRepository {
 	}
 }
 
-func (userWithdrawalRepository *UserWithdrawalRepository) Withdraw(ctx context.Context, orderID uint64, userID uint32, sum float64) (*entity.Withdrawal, error) {
+func (userWithdrawalRepository *UserWithdrawalRepository) Withdraw(ctx context.Context, orderID uint64, userID uint32, sum money.Amount) (*entity.Withdrawal, error) {
 	withdrawal := &entity.Withdrawal{
 		OrderID: orderID,
 		UserID:  userID,
-		Sum:     money.New(sum),
+		Sum:     sum,
 	}
 
 	err := userWithdrawalRepository.db.Transaction(func(transaction *gorm.DB) error {
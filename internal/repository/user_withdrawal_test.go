@@ -16,7 +16,7 @@ func TestUserWithdrawalRepository_Withdraw(t *testing.T) {
 	repository := NewUserWithdrawalRepository(gorm)
 	id := rand.Uint64N(1000) + 1
 	userID := rand.Uint32N(1000) + 1
-	sum := money.New(rand.Float64() + 100)
+	sum := money.Amount(rand.Uint64N(10000) + 100)
 
 	sqlMock.ExpectBegin()
 	sqlMock.
@@ -29,7 +29,7 @@ func TestUserWithdrawalRepository_Withdraw(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	sqlMock.ExpectCommit()
 
-	withdrawal, err := repository.Withdraw(context.Background(), id, userID, sum.AsFloat())
+	withdrawal, err := repository.Withdraw(context.Background(), id, userID, sum)
 	require.NoError(t, err)
 	assert.NotNil(t, withdrawal)
 }
@@ -39,7 +39,7 @@ func TestUserWithdrawalRepository_WithdrawNoFunds(t *testing.T) {
 	repository := NewUserWithdrawalRepository(gorm)
 	id := rand.Uint64N(1000) + 1
 	userID := rand.Uint32N(1000) + 1
-	sum := money.New(rand.Float64() + 100)
+	sum := money.Amount(rand.Uint64N(10000) + 100)
 
 	sqlMock.ExpectBegin()
 	sqlMock.
@@ -52,7 +52,7 @@ func TestUserWithdrawalRepository_WithdrawNoFunds(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	sqlMock.ExpectRollback()
 
-	withdrawal, err := repository.Withdraw(context.Background(), id, userID, sum.AsFloat())
+	withdrawal, err := repository.Withdraw(context.Background(), id, userID, sum)
 	require.NoError(t, err)
 	assert.Nil(t, withdrawal)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/entity"
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/generator"
 	"gorm.io/gorm"
 )
 
@@ -21,6 +22,6 @@ func (repository *WithdrawalRepository) FindOneByUserID(ctx context.Context, use
 	return repository.FindOneBy(ctx, "user_id = ?", userID)
 }
 
-func (repository *WithdrawalRepository) FindByUserID(ctx context.Context, userID uint32) (<-chan *entity.Withdrawal, error) {
+func (repository *WithdrawalRepository) FindByUserID(ctx context.Context, userID uint32) (*generator.Stream[*entity.Withdrawal], error) {
 	return repository.FindBy(ctx, "created_at DESC", "user_id = ?", userID)
 }
@@ -50,7 +50,7 @@ func TestWithdrawalRepository_FindByUserID(t *testing.T) {
 	withdrawals, err := repository.FindByUserID(context.Background(), userID)
 	require.NoError(t, err)
 	withdrawalsSlice := make([]*entity.Withdrawal, 0, 2)
-	for withdrawal := range withdrawals {
+	for withdrawal := range withdrawals.Items() {
 		withdrawalsSlice = append(withdrawalsSlice, withdrawal)
 	}
 	require.Len(t, withdrawalsSlice, 2)
@@ -7,6 +7,7 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httprate"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller"
+	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/admin"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/auth"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/balance"
 	"github.com/m1khal3v/gophermart-loyalty-service/internal/controller/order"
@@ -23,15 +24,19 @@ func New(
 	orderRoutes *order.Container,
 	balanceRoutes *balance.Container,
 	withdrawalRoutes *withdrawal.Container,
+	adminRoutes *admin.Container,
 	jwt *jwt.Container,
+	tokenValidator internalMiddleware.TokenValidator,
+	adminToken string,
+	decompressConfig *pkgMiddleware.DecompressConfig,
 ) chi.Router {
 	router := chi.NewRouter()
 	router.Use(pkgMiddleware.ZapLogRequest(logger.Logger, "http-request"))
 	router.Use(internalMiddleware.Recover())
 	router.Use(pkgMiddleware.ZapLogPanic(logger.Logger, "http-panic"))
 	router.Use(middleware.RealIP)
-	router.Use(pkgMiddleware.Decompress())
-	router.Use(pkgMiddleware.Compress(5, "text/html", "application/json"))
+	router.Use(pkgMiddleware.Decompress(decompressConfig))
+	router.Use(pkgMiddleware.Compress(5, 1024, "text/html", "application/json"))
 	router.Route("/api", func(router chi.Router) {
 		router.Route("/user", func(router chi.Router) {
 			// Anonymous
@@ -51,7 +56,10 @@ func New(
 
 			// Authorized
 			router.Group(func(router chi.Router) {
-				router.Use(internalMiddleware.ValidateAuthorizationToken(jwt))
+				router.Use(internalMiddleware.ValidateAuthorizationToken(jwt, tokenValidator))
+
+				router.Delete("/", authRoutes.Delete)
+				router.Post("/password", authRoutes.ChangePassword)
 
 				router.Post("/orders", orderRoutes.Register)
 				router.Get("/orders", orderRoutes.List)
@@ -62,6 +70,18 @@ func New(
 				router.Get("/withdrawals", withdrawalRoutes.List)
 			})
 		})
+
+		// Admin routes are disabled if admin token is not configured
+		if adminToken != "" {
+			router.Route("/admin", func(router chi.Router) {
+				router.Use(internalMiddleware.ValidateAdminToken(adminToken))
+
+				router.Get("/dead-letters", adminRoutes.DeadLetters)
+				router.Post("/dead-letters/{id}/replay", adminRoutes.ReplayDeadLetter)
+				router.Get("/concurrency", adminRoutes.Concurrency)
+				router.Put("/concurrency/{processor}", adminRoutes.ResizeConcurrency)
+			})
+		}
 	})
 
 	return router
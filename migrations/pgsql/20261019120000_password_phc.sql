@@ -0,0 +1,7 @@
+-- +goose Up
+-- modify "users" table
+ALTER TABLE "users" ALTER COLUMN "password" TYPE character varying(255) USING convert_from("password", 'UTF8');
+
+-- +goose Down
+-- reverse: modify "users" table
+ALTER TABLE "users" ALTER COLUMN "password" TYPE bytea USING convert_to("password", 'UTF8');
@@ -0,0 +1,7 @@
+-- +goose Up
+-- modify "users" table
+ALTER TABLE "users" ADD COLUMN "token_version" bigint NOT NULL DEFAULT 0, ADD COLUMN "deleted_at" timestamptz NULL;
+
+-- +goose Down
+-- reverse: modify "users" table
+ALTER TABLE "users" DROP COLUMN "deleted_at", DROP COLUMN "token_version";
@@ -0,0 +1,17 @@
+-- +goose Up
+-- create "dead_letters" table
+CREATE TABLE "dead_letters" (
+  "id" bigserial NOT NULL,
+  "order_id" bigint NOT NULL,
+  "reason" character varying(255) NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- create index "idx_dead_letter_order_id" to table: "dead_letters"
+CREATE INDEX "idx_dead_letter_order_id" ON "dead_letters" ("order_id");
+
+-- +goose Down
+-- reverse: create index "idx_dead_letter_order_id" to table: "dead_letters"
+DROP INDEX "idx_dead_letter_order_id";
+-- reverse: create "dead_letters" table
+DROP TABLE "dead_letters";
@@ -0,0 +1,7 @@
+-- +goose Up
+-- modify "dead_letters" table
+ALTER TABLE "dead_letters" ADD COLUMN "source" character varying(16) NOT NULL DEFAULT 'retriever', ADD COLUMN "status" character varying(16) NOT NULL DEFAULT '', ADD COLUMN "accrual" bigint NULL, ADD COLUMN "attempts" bigint NOT NULL DEFAULT 1;
+
+-- +goose Down
+-- reverse: modify "dead_letters" table
+ALTER TABLE "dead_letters" DROP COLUMN "attempts", DROP COLUMN "accrual", DROP COLUMN "status", DROP COLUMN "source";
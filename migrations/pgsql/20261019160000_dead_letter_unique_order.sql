@@ -0,0 +1,13 @@
+-- +goose Up
+-- keep the latest dead letter of the order
+DELETE FROM "dead_letters" AS "older" USING "dead_letters" AS "newer" WHERE "older"."order_id" = "newer"."order_id" AND "older"."id" < "newer"."id";
+-- drop index "idx_dead_letter_order_id" from table: "dead_letters"
+DROP INDEX "idx_dead_letter_order_id";
+-- create index "idx_dead_letter_order_id" to table: "dead_letters"
+CREATE UNIQUE INDEX "idx_dead_letter_order_id" ON "dead_letters" ("order_id");
+
+-- +goose Down
+-- reverse: create index "idx_dead_letter_order_id" to table: "dead_letters"
+DROP INDEX "idx_dead_letter_order_id";
+-- reverse: drop index "idx_dead_letter_order_id" from table: "dead_letters"
+CREATE INDEX "idx_dead_letter_order_id" ON "dead_letters" ("order_id");
@@ -0,0 +1,131 @@
+package aimd
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const DefaultDecreaseFactor = 0.5
+const DefaultLatencyThreshold = time.Second
+
+// Limiter is an adaptive concurrency limiter with additive increase/multiplicative decrease (AIMD) policy.
+// Every successful fast call increases the limit by 1/limit (so the limit grows by 1 per window of calls),
+// a failed or slow call multiplies the limit by DecreaseFactor (at most once per window)
+type Limiter struct {
+	mutex         sync.Mutex
+	limit         float64
+	inFlight      uint64
+	sinceDecrease uint64
+	released      chan struct{}
+	config        *Config
+}
+
+type Config struct {
+	Min uint64
+	// Max is also the initial limit
+	Max              uint64
+	DecreaseFactor   float64
+	LatencyThreshold *time.Duration
+	// OnChange is called synchronously under the limiter lock when the integer limit changes
+	OnChange func(from, to uint64)
+}
+
+func prepareConfig(config *Config) {
+	if config.Min == 0 {
+		config.Min = 1
+	}
+	if config.Max < config.Min {
+		config.Max = config.Min
+	}
+	if config.DecreaseFactor <= 0 || config.DecreaseFactor >= 1 {
+		config.DecreaseFactor = DefaultDecreaseFactor
+	}
+	if config.LatencyThreshold == nil || *config.LatencyThreshold <= 0 {
+		defaultValue := DefaultLatencyThreshold
+		config.LatencyThreshold = &defaultValue
+	}
+}
+
+func New(config *Config) *Limiter {
+	prepareConfig(config)
+
+	return &Limiter{
+		limit: float64(config.Max),
+		// the first failure decreases the limit immediately
+		sinceDecrease: config.Max,
+		released:      make(chan struct{}),
+		config:        config,
+	}
+}
+
+// Limit returns current concurrency limit
+func (limiter *Limiter) Limit() uint64 {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	return uint64(limiter.limit)
+}
+
+// Acquire blocks until in-flight calls count is below the limit
+func (limiter *Limiter) Acquire(ctx context.Context) error {
+	for {
+		limiter.mutex.Lock()
+		if limiter.inFlight < uint64(limiter.limit) {
+			limiter.inFlight++
+			limiter.mutex.Unlock()
+
+			return nil
+		}
+		released := limiter.released
+		limiter.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-released:
+		}
+	}
+}
+
+// Discard releases the slot acquired by Acquire without adapting the limit, e.g. when no call was made
+func (limiter *Limiter) Discard() {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.inFlight--
+	limiter.notify()
+}
+
+// Release finishes the call acquired by Acquire and adapts the limit to the call result
+func (limiter *Limiter) Release(latency time.Duration, failed bool) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.inFlight--
+	limiter.sinceDecrease++
+	from := uint64(limiter.limit)
+
+	if failed || latency > *limiter.config.LatencyThreshold {
+		// calls started before the previous decrease reflect the old limit, don't punish twice for them
+		if float64(limiter.sinceDecrease) >= limiter.limit {
+			limiter.limit = math.Max(float64(limiter.config.Min), limiter.limit*limiter.config.DecreaseFactor)
+			limiter.sinceDecrease = 0
+		}
+	} else {
+		limiter.limit = math.Min(float64(limiter.config.Max), limiter.limit+1/limiter.limit)
+	}
+
+	if to := uint64(limiter.limit); from != to && limiter.config.OnChange != nil {
+		limiter.config.OnChange(from, to)
+	}
+
+	limiter.notify()
+}
+
+// notify wakes up all waiters
+func (limiter *Limiter) notify() {
+	close(limiter.released)
+	limiter.released = make(chan struct{})
+}
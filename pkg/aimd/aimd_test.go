@@ -0,0 +1,100 @@
+package aimd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Adapt(t *testing.T) {
+	changes := [][2]uint64{}
+	threshold := time.Millisecond * 100
+	limiter := New(&Config{
+		Min:              2,
+		Max:              8,
+		LatencyThreshold: &threshold,
+		OnChange: func(from, to uint64) {
+			changes = append(changes, [2]uint64{from, to})
+		},
+	})
+	ctx := context.Background()
+	call := func(latency time.Duration, failed bool) {
+		require.NoError(t, limiter.Acquire(ctx))
+		limiter.Release(latency, failed)
+	}
+
+	assert.EqualValues(t, 8, limiter.Limit())
+
+	// failure decreases multiplicatively
+	call(time.Millisecond, true)
+	assert.EqualValues(t, 4, limiter.Limit())
+
+	// next failures of the same window are ignored
+	call(time.Millisecond, true)
+	call(time.Second, false)
+	call(time.Millisecond, true)
+	assert.EqualValues(t, 4, limiter.Limit())
+
+	// slow call of the next window decreases, but not below min
+	call(time.Second, false)
+	assert.EqualValues(t, 2, limiter.Limit())
+	for range 10 {
+		call(time.Second, true)
+	}
+	assert.EqualValues(t, 2, limiter.Limit())
+
+	// successes increase additively: +1 per window
+	call(time.Millisecond, false)
+	call(time.Millisecond, false)
+	call(time.Millisecond, false)
+	assert.EqualValues(t, 3, limiter.Limit())
+	for range 100 {
+		call(time.Millisecond, false)
+	}
+	assert.EqualValues(t, 8, limiter.Limit())
+
+	assert.Equal(t, [][2]uint64{{8, 4}, {4, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 7}, {7, 8}}, changes)
+}
+
+func TestLimiter_Acquire(t *testing.T) {
+	limiter := New(&Config{Max: 1})
+	require.NoError(t, limiter.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	require.ErrorIs(t, limiter.Acquire(ctx), context.DeadlineExceeded)
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- limiter.Acquire(context.Background())
+	}()
+	limiter.Release(time.Millisecond, false)
+
+	select {
+	case err := <-acquired:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("acquire was not unblocked by release")
+	}
+}
+
+func TestLimiter_Discard(t *testing.T) {
+	limiter := New(&Config{Max: 1})
+	require.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Discard()
+	require.NoError(t, limiter.Acquire(context.Background()))
+	assert.EqualValues(t, 1, limiter.Limit())
+}
+
+func TestPrepareConfig(t *testing.T) {
+	config := &Config{DecreaseFactor: 2}
+	prepareConfig(config)
+
+	assert.EqualValues(t, 1, config.Min)
+	assert.EqualValues(t, 1, config.Max)
+	assert.Equal(t, DefaultDecreaseFactor, config.DecreaseFactor)
+	assert.Equal(t, DefaultLatencyThreshold, *config.LatencyThreshold)
+}
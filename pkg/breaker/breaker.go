@@ -0,0 +1,215 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const DefaultFailureThreshold = 5
+const DefaultOpenTimeout = time.Second * 10
+const DefaultHalfOpenRequests = 1
+
+// halfOpenRetryAfter is a hint for callers rejected while probe requests are in flight
+const halfOpenRetryAfter = time.Second
+
+type State int32
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (state State) String() string {
+	switch state {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(state))
+	}
+}
+
+type ErrOpen struct {
+	RetryAfterTime time.Time
+}
+
+func (err ErrOpen) Error() string {
+	return "circuit breaker is open"
+}
+
+// Breaker is a circuit breaker: after FailureThreshold consecutive failures it opens and rejects calls for OpenTimeout,
+// then lets HalfOpenRequests probe calls through. Probes success closes the breaker, any probe failure opens it again.
+// Results of calls admitted before the last state change are ignored, canceled calls are not counted
+type Breaker struct {
+	mutex sync.Mutex
+	state State
+	// generation is incremented on every state change
+	generation uint64
+	failures   uint64
+	successes  uint64
+	probes     uint64
+	openedAt   time.Time
+	now        func() time.Time
+	config     *Config
+}
+
+type Config struct {
+	FailureThreshold uint64
+	OpenTimeout      *time.Duration
+	HalfOpenRequests uint64
+	// IsFailure decides which errors are failures. By default, any non-nil error is.
+	// Calls canceled with context.Canceled are not recorded
+	IsFailure func(err error) bool
+	// OnStateChange is called synchronously under the breaker lock, so it must not call the breaker
+	OnStateChange func(from, to State)
+}
+
+func prepareConfig(config *Config) {
+	if config.FailureThreshold == 0 {
+		config.FailureThreshold = DefaultFailureThreshold
+	}
+	if config.OpenTimeout == nil || *config.OpenTimeout <= 0 {
+		defaultValue := DefaultOpenTimeout
+		config.OpenTimeout = &defaultValue
+	}
+	if config.HalfOpenRequests == 0 {
+		config.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(err error) bool {
+			return err != nil
+		}
+	}
+}
+
+func New(config *Config) *Breaker {
+	prepareConfig(config)
+
+	return &Breaker{
+		now:    time.Now,
+		config: config,
+	}
+}
+
+func (breaker *Breaker) State() State {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.refresh(breaker.now())
+
+	return breaker.state
+}
+
+// Do calls function if breaker allows it and records the result. Returns ErrOpen without calling function otherwise
+func (breaker *Breaker) Do(function func() error) error {
+	generation, err := breaker.allow()
+	if err != nil {
+		return err
+	}
+
+	err = function()
+	if errors.Is(err, context.Canceled) {
+		breaker.cancel(generation)
+	} else {
+		breaker.done(generation, breaker.config.IsFailure(err))
+	}
+
+	return err
+}
+
+func (breaker *Breaker) allow() (uint64, error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	now := breaker.now()
+	breaker.refresh(now)
+
+	switch breaker.state {
+	case StateOpen:
+		return 0, ErrOpen{RetryAfterTime: breaker.openedAt.Add(*breaker.config.OpenTimeout)}
+	case StateHalfOpen:
+		if breaker.probes >= breaker.config.HalfOpenRequests {
+			return 0, ErrOpen{RetryAfterTime: now.Add(halfOpenRetryAfter)}
+		}
+		breaker.probes++
+	}
+
+	return breaker.generation, nil
+}
+
+// cancel frees the probe slot of the canceled call, so another probe can be admitted
+func (breaker *Breaker) cancel(generation uint64) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if generation == breaker.generation && breaker.state == StateHalfOpen {
+		breaker.probes--
+	}
+}
+
+func (breaker *Breaker) done(generation uint64, failed bool) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if generation != breaker.generation {
+		// call is admitted in the previous state
+		return
+	}
+
+	now := breaker.now()
+
+	switch breaker.state {
+	case StateClosed:
+		if !failed {
+			breaker.failures = 0
+			return
+		}
+
+		breaker.failures++
+		if breaker.failures >= breaker.config.FailureThreshold {
+			breaker.open(now)
+		}
+	case StateHalfOpen:
+		if failed {
+			breaker.open(now)
+			return
+		}
+
+		breaker.successes++
+		if breaker.successes >= breaker.config.HalfOpenRequests {
+			breaker.setState(StateClosed)
+		}
+	}
+}
+
+// refresh moves open breaker to half-open state after timeout
+func (breaker *Breaker) refresh(now time.Time) {
+	if breaker.state == StateOpen && !now.Before(breaker.openedAt.Add(*breaker.config.OpenTimeout)) {
+		breaker.setState(StateHalfOpen)
+	}
+}
+
+func (breaker *Breaker) open(now time.Time) {
+	breaker.openedAt = now
+	breaker.setState(StateOpen)
+}
+
+func (breaker *Breaker) setState(state State) {
+	from := breaker.state
+	breaker.state = state
+	breaker.generation++
+	breaker.failures = 0
+	breaker.successes = 0
+	breaker.probes = 0
+
+	if breaker.config.OnStateChange != nil && from != state {
+		breaker.config.OnStateChange(from, state)
+	}
+}
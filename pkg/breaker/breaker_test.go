@@ -0,0 +1,170 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type transition struct {
+	from State
+	to   State
+}
+
+func newTestBreaker(config *Config) (*Breaker, *time.Time, *[]transition) {
+	now := time.Now()
+	transitions := &[]transition{}
+	config.OnStateChange = func(from, to State) {
+		*transitions = append(*transitions, transition{from: from, to: to})
+	}
+	breaker := New(config)
+	breaker.now = func() time.Time {
+		return now
+	}
+
+	return breaker, &now, transitions
+}
+
+func TestBreaker(t *testing.T) {
+	someErr := errors.New("some error")
+	timeout := time.Second * 5
+	breaker, now, transitions := newTestBreaker(&Config{
+		FailureThreshold: 2,
+		OpenTimeout:      &timeout,
+		HalfOpenRequests: 2,
+	})
+	fail := func() error { return someErr }
+	succeed := func() error { return nil }
+
+	// success resets consecutive failures
+	require.ErrorIs(t, breaker.Do(fail), someErr)
+	require.NoError(t, breaker.Do(succeed))
+	require.ErrorIs(t, breaker.Do(fail), someErr)
+	assert.Equal(t, StateClosed, breaker.State())
+
+	// second consecutive failure opens the breaker
+	require.ErrorIs(t, breaker.Do(fail), someErr)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	called := false
+	err := breaker.Do(func() error {
+		called = true
+		return nil
+	})
+	target := ErrOpen{}
+	require.ErrorAs(t, err, &target)
+	assert.Equal(t, now.Add(timeout), target.RetryAfterTime)
+	assert.False(t, called)
+
+	// probe failure opens the breaker again
+	*now = now.Add(timeout)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	require.ErrorIs(t, breaker.Do(fail), someErr)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	// all probes succeeded
+	*now = now.Add(timeout)
+	require.NoError(t, breaker.Do(succeed))
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	require.NoError(t, breaker.Do(succeed))
+	assert.Equal(t, StateClosed, breaker.State())
+
+	assert.Equal(t, []transition{
+		{from: StateClosed, to: StateOpen},
+		{from: StateOpen, to: StateHalfOpen},
+		{from: StateHalfOpen, to: StateOpen},
+		{from: StateOpen, to: StateHalfOpen},
+		{from: StateHalfOpen, to: StateClosed},
+	}, *transitions)
+}
+
+func TestBreaker_HalfOpenLimit(t *testing.T) {
+	timeout := time.Second
+	breaker, now, _ := newTestBreaker(&Config{
+		FailureThreshold: 1,
+		OpenTimeout:      &timeout,
+	})
+
+	require.Error(t, breaker.Do(func() error { return errors.New("some error") }))
+	*now = now.Add(timeout)
+
+	err := breaker.Do(func() error {
+		// concurrent call while the only probe is in flight
+		target := ErrOpen{}
+		require.ErrorAs(t, breaker.Do(func() error { return nil }), &target)
+		assert.Equal(t, now.Add(halfOpenRetryAfter), target.RetryAfterTime)
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_StaleResult(t *testing.T) {
+	timeout := time.Second
+	breaker, now, _ := newTestBreaker(&Config{
+		FailureThreshold: 1,
+		OpenTimeout:      &timeout,
+	})
+
+	err := breaker.Do(func() error {
+		// slow call admitted while closed finishes after the breaker is opened and half-opened
+		require.Error(t, breaker.Do(func() error { return errors.New("some error") }))
+		*now = now.Add(timeout)
+		assert.Equal(t, StateHalfOpen, breaker.State())
+
+		return nil
+	})
+	require.NoError(t, err)
+	// stale success is not counted as the probe
+	assert.Equal(t, StateHalfOpen, breaker.State())
+
+	// probe admitted in the half-open state closes the breaker
+	require.NoError(t, breaker.Do(func() error { return nil }))
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_Canceled(t *testing.T) {
+	timeout := time.Second
+	breaker, now, _ := newTestBreaker(&Config{
+		FailureThreshold: 1,
+		OpenTimeout:      &timeout,
+	})
+
+	require.Error(t, breaker.Do(func() error { return errors.New("some error") }))
+	*now = now.Add(timeout)
+
+	// canceled probe neither closes the breaker nor holds the probe slot
+	require.ErrorIs(t, breaker.Do(func() error { return context.Canceled }), context.Canceled)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	require.NoError(t, breaker.Do(func() error { return nil }))
+	assert.Equal(t, StateClosed, breaker.State())
+
+	// canceled call is not a failure
+	require.ErrorIs(t, breaker.Do(func() error { return context.Canceled }), context.Canceled)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_IsFailure(t *testing.T) {
+	ignoredErr := errors.New("ignored error")
+	breaker, _, _ := newTestBreaker(&Config{
+		FailureThreshold: 1,
+		IsFailure: func(err error) bool {
+			return err != nil && !errors.Is(err, ignoredErr)
+		},
+	})
+
+	require.ErrorIs(t, breaker.Do(func() error { return ignoredErr }), ignoredErr)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "unknown(7)", State(7).String())
+}
@@ -177,6 +177,42 @@ func (client *Client) Withdraw(ctx context.Context, token string, request *reque
 	return result.Result().(*responses.Message), nil, nil
 }
 
+func (client *Client) ChangePassword(ctx context.Context, token string, request *requests.ChangePassword) (*responses.Auth, *responses.APIError, error) {
+	result, err := client.doRequest(client.createRequest(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+		SetBody(request).
+		SetResult(&responses.Auth{}),
+		resty.MethodPost, "api/user/password")
+
+	if err != nil {
+		if result == nil || result.RawResponse == nil {
+			return nil, nil, err
+		} else {
+			return nil, result.Error().(*responses.APIError), err
+		}
+	}
+
+	return result.Result().(*responses.Auth), nil, nil
+}
+
+func (client *Client) DeleteUser(ctx context.Context, token string) (*responses.Message, *responses.APIError, error) {
+	result, err := client.doRequest(client.createRequest(ctx).
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+		SetResult(&responses.Message{}),
+		resty.MethodDelete, "api/user")
+
+	if err != nil {
+		if result == nil || result.RawResponse == nil {
+			return nil, nil, err
+		} else {
+			return nil, result.Error().(*responses.APIError), err
+		}
+	}
+
+	return result.Result().(*responses.Message), nil, nil
+}
+
 func (client *Client) createRequest(ctx context.Context) *resty.Request {
 	return client.resty.R().SetContext(ctx).SetError(&responses.APIError{})
 }
@@ -208,13 +244,28 @@ func (client *Client) doRequest(request *resty.Request, method, url string) (*re
 
 	var err error
 	if client.config.retry {
-		err = retry.Retry(time.Second, 5*time.Second, 4, 2, do, func(err error) bool {
-			return !errors.As(err, &ErrUnexpectedStatus{}) &&
-				!errors.As(err, &ErrTooManyRequests{}) &&
-				!errors.Is(err, ErrInvalidCredentials) &&
-				!errors.Is(err, context.DeadlineExceeded) &&
-				!errors.Is(err, context.Canceled)
-		})
+		err = retry.RetryContext(
+			request.Context(),
+			func(ctx context.Context) error {
+				return do()
+			},
+			retry.WithMaxElapsedTime(client.config.maxRetryElapsedTime),
+			retry.WithFilter(func(err error) bool {
+				return !errors.As(err, &ErrUnexpectedStatus{}) &&
+					!errors.Is(err, ErrInvalidCredentials) &&
+					!errors.Is(err, context.DeadlineExceeded) &&
+					!errors.Is(err, context.Canceled)
+			}),
+			// server provided delay is waited unless it exceeds max elapsed time
+			retry.WithDelayFromError(func(err error) (time.Duration, bool) {
+				tooManyRequests := ErrTooManyRequests{}
+				if !errors.As(err, &tooManyRequests) {
+					return 0, false
+				}
+
+				return time.Until(tooManyRequests.RetryAfterTime), true
+			}),
+		)
 	} else {
 		err = do()
 	}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/http/retryafter"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/requests"
 	"github.com/m1khal3v/gophermart-loyalty-service/pkg/responses"
@@ -416,7 +417,7 @@ func TestClient_AddOrder(t *testing.T) {
 
 func TestClient_Orders(t *testing.T) {
 	now := time.Now().UTC()
-	accrual := 1.23
+	accrual := money.MustParse("1.23")
 	tests := []struct {
 		name       string
 		transport  roundTripFunction
@@ -552,12 +553,12 @@ func TestClient_Withdrawals(t *testing.T) {
 				return createResponse(t, http.StatusOK, []responses.Withdrawal{
 					{
 						Order:       1,
-						Sum:         1.11,
+						Sum:         money.MustParse("1.11"),
 						ProcessedAt: now,
 					},
 					{
 						Order:       2,
-						Sum:         2.22,
+						Sum:         money.MustParse("2.22"),
 						ProcessedAt: now,
 					},
 				}), nil
@@ -565,12 +566,12 @@ func TestClient_Withdrawals(t *testing.T) {
 			want: []responses.Withdrawal{
 				{
 					Order:       1,
-					Sum:         1.11,
+					Sum:         money.MustParse("1.11"),
 					ProcessedAt: now,
 				},
 				{
 					Order:       2,
-					Sum:         2.22,
+					Sum:         money.MustParse("2.22"),
 					ProcessedAt: now,
 				},
 			},
@@ -669,13 +670,13 @@ func TestClient_Balance(t *testing.T) {
 			name: "valid",
 			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
 				return createResponse(t, http.StatusOK, responses.Balance{
-					Current:   1.23,
-					Withdrawn: 3.21,
+					Current:   money.MustParse("1.23"),
+					Withdrawn: money.MustParse("3.21"),
 				}), nil
 			}),
 			want: &responses.Balance{
-				Current:   1.23,
-				Withdrawn: 3.21,
+				Current:   money.MustParse("1.23"),
+				Withdrawn: money.MustParse("3.21"),
 			},
 		},
 		{
@@ -766,7 +767,7 @@ func TestClient_Withdraw(t *testing.T) {
 			name: "valid",
 			request: &requests.Withdraw{
 				Order: 123456,
-				Sum:   1.23,
+				Sum:   money.MustParse("1.23"),
 			},
 			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
 				return createResponse(t, http.StatusOK, responses.Message{
@@ -781,7 +782,7 @@ func TestClient_Withdraw(t *testing.T) {
 			name: "bad request",
 			request: &requests.Withdraw{
 				Order: 123456,
-				Sum:   1.23,
+				Sum:   money.MustParse("1.23"),
 			},
 			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
 				return createResponse(t, http.StatusBadRequest, responses.APIError{
@@ -799,7 +800,7 @@ func TestClient_Withdraw(t *testing.T) {
 			name: "insufficient balance",
 			request: &requests.Withdraw{
 				Order: 123456,
-				Sum:   1.23,
+				Sum:   money.MustParse("1.23"),
 			},
 			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
 				return createResponse(t, http.StatusPaymentRequired, responses.APIError{
@@ -817,7 +818,7 @@ func TestClient_Withdraw(t *testing.T) {
 			name: "internal server error",
 			request: &requests.Withdraw{
 				Order: 123456,
-				Sum:   1.23,
+				Sum:   money.MustParse("1.23"),
 			},
 			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
 				return createResponse(t, http.StatusInternalServerError, responses.APIError{
@@ -835,7 +836,7 @@ func TestClient_Withdraw(t *testing.T) {
 			name: "invalid credentials",
 			request: &requests.Withdraw{
 				Order: 123456,
-				Sum:   1.23,
+				Sum:   money.MustParse("1.23"),
 			},
 			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
 				return createResponse(t, http.StatusUnauthorized, responses.APIError{
@@ -853,7 +854,7 @@ func TestClient_Withdraw(t *testing.T) {
 			name: "unexpected status",
 			request: &requests.Withdraw{
 				Order: 123456,
-				Sum:   1.23,
+				Sum:   money.MustParse("1.23"),
 			},
 			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
 				return createResponse(t, http.StatusTeapot, responses.APIError{
@@ -901,6 +902,191 @@ func TestClient_Withdraw(t *testing.T) {
 	}
 }
 
+func TestClient_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		transport  roundTripFunction
+		want       *responses.Auth
+		wantAPIErr *responses.APIError
+		wantErr    error
+	}{
+		{
+			name: "valid",
+			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
+				return createResponse(t, http.StatusOK, responses.Auth{
+					AccessToken: "n3w_t0k3n",
+				}), nil
+			}),
+			want: &responses.Auth{
+				AccessToken: "n3w_t0k3n",
+			},
+		},
+		{
+			name: "invalid credentials",
+			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
+				return createResponse(t, http.StatusUnauthorized, responses.APIError{
+					Code:    http.StatusUnauthorized,
+					Message: "invalid credentials",
+				}), nil
+			}),
+			wantAPIErr: &responses.APIError{
+				Code:    http.StatusUnauthorized,
+				Message: "invalid credentials",
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			request := &requests.ChangePassword{
+				OldPassword: "swordfish",
+				NewPassword: "swordfish_2",
+			}
+			client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPost, req.Method)
+				assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+				assert.Equal(t, fmt.Sprintf("Bearer t0k3n_%d", i), req.Header.Get("Authorization"))
+				assert.Equal(t, "/api/user/password", req.URL.Path)
+
+				return tt.transport(req)
+			})
+			response, apiErr, err := client.ChangePassword(ctx, fmt.Sprintf("t0k3n_%d", i), request)
+			if tt.wantErr != nil {
+				assert.ErrorAs(t, err, &tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tt.wantAPIErr != nil {
+				assert.Equal(t, tt.wantAPIErr, apiErr)
+			} else {
+				require.Nil(t, apiErr)
+			}
+
+			if tt.want != nil {
+				assert.Equal(t, tt.want, response)
+			} else {
+				require.Nil(t, response)
+			}
+		})
+	}
+}
+
+func TestClient_DeleteUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		transport  roundTripFunction
+		want       *responses.Message
+		wantAPIErr *responses.APIError
+		wantErr    error
+	}{
+		{
+			name: "valid",
+			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
+				return createResponse(t, http.StatusOK, responses.Message{
+					Message: "user has been successfully deleted",
+				}), nil
+			}),
+			want: &responses.Message{
+				Message: "user has been successfully deleted",
+			},
+		},
+		{
+			name: "invalid credentials",
+			transport: roundTripFunction(func(req *http.Request) (*http.Response, error) {
+				return createResponse(t, http.StatusUnauthorized, responses.APIError{
+					Code:    http.StatusUnauthorized,
+					Message: "token revoked",
+				}), nil
+			}),
+			wantAPIErr: &responses.APIError{
+				Code:    http.StatusUnauthorized,
+				Message: "token revoked",
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := newTestClient(t, func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodDelete, req.Method)
+				assert.Equal(t, fmt.Sprintf("Bearer t0k3n_%d", i), req.Header.Get("Authorization"))
+				assert.Equal(t, "/api/user", req.URL.Path)
+
+				return tt.transport(req)
+			})
+			response, apiErr, err := client.DeleteUser(ctx, fmt.Sprintf("t0k3n_%d", i))
+			if tt.wantErr != nil {
+				assert.ErrorAs(t, err, &tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tt.wantAPIErr != nil {
+				assert.Equal(t, tt.wantAPIErr, apiErr)
+			} else {
+				require.Nil(t, apiErr)
+			}
+
+			if tt.want != nil {
+				assert.Equal(t, tt.want, response)
+			} else {
+				require.Nil(t, response)
+			}
+		})
+	}
+}
+
+func TestClient_RetryTooManyRequests(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryAfter   string
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "retry after is waited",
+			retryAfter:   "1",
+			wantAttempts: 2,
+		},
+		{
+			name:         "retry after exceeds max elapsed time",
+			retryAfter:   "60",
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			client := New("test", WithMaxRetryElapsedTime(time.Second*5), withTransport(roundTripFunction(func(req *http.Request) (*http.Response, error) {
+				attempts++
+				if attempts > 1 {
+					return createResponse(t, http.StatusOK, responses.Balance{}), nil
+				}
+
+				response := createResponse(t, http.StatusTooManyRequests, responses.APIError{
+					Code:    http.StatusTooManyRequests,
+					Message: "too many requests",
+				})
+				response.Header.Set("Retry-After", tt.retryAfter)
+
+				return response, nil
+			})))
+
+			_, _, err := client.Balance(context.Background(), "token")
+			if tt.wantErr {
+				require.ErrorAs(t, err, &ErrTooManyRequests{})
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAttempts, attempts)
+		})
+	}
+}
+
 type roundTripFunction func(req *http.Request) (*http.Response, error)
 
 func (function roundTripFunction) RoundTrip(req *http.Request) (*http.Response, error) {
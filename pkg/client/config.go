@@ -8,13 +8,15 @@ import (
 )
 
 const defaultRetryAfter = time.Second * 10
+const defaultMaxRetryElapsedTime = time.Second * 30
 
 type config struct {
 	baseURL           *url.URL
 	defaultRetryAfter time.Duration
 
-	compress bool
-	retry    bool
+	compress            bool
+	retry               bool
+	maxRetryElapsedTime time.Duration
 
 	transport http.RoundTripper
 }
@@ -27,10 +29,11 @@ func newConfig(address string, options ...ConfigOption) *config {
 			Scheme: "http",
 			Host:   address,
 		},
-		defaultRetryAfter: defaultRetryAfter,
-		compress:          true,
-		retry:             true,
-		transport:         http.DefaultTransport,
+		defaultRetryAfter:   defaultRetryAfter,
+		compress:            true,
+		retry:               true,
+		maxRetryElapsedTime: defaultMaxRetryElapsedTime,
+		transport:           http.DefaultTransport,
 	}
 
 	if strings.Contains(address, "://") {
@@ -59,6 +62,13 @@ func WithoutRetry() ConfigOption {
 	}
 }
 
+// WithMaxRetryElapsedTime limits total time of retries including server provided Retry-After delays
+func WithMaxRetryElapsedTime(maxRetryElapsedTime time.Duration) ConfigOption {
+	return func(config *config) {
+		config.maxRetryElapsedTime = maxRetryElapsedTime
+	}
+}
+
 func WithDefaultRetryAfter(retryAfter time.Duration) ConfigOption {
 	return func(config *config) {
 		config.defaultRetryAfter = retryAfter
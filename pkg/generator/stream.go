@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"context"
+	"errors"
+)
+
+var errStreamClosed = errors.New("stream is closed")
+
+// Stream is a channel of generated items which reports why the generation is stopped
+type Stream[T any] struct {
+	items  chan T
+	done   chan struct{}
+	cancel context.CancelCauseFunc
+	err    error
+}
+
+// NewStream sends items returned by generate until it returns false or an error.
+// release is called once the generation is stopped, e.g. to close sql.Rows
+func NewStream[T any](ctx context.Context, generate func() (T, bool, error), release func() error) *Stream[T] {
+	if generate == nil {
+		panic("generate function cannot be nil")
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stream := &Stream[T]{
+		items:  make(chan T, 1),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+
+	go func() {
+		defer close(stream.done)
+		defer close(stream.items)
+		defer cancel(nil)
+
+		stream.err = stream.generate(ctx, generate)
+		if errors.Is(stream.err, errStreamClosed) {
+			// closed by the consumer
+			stream.err = nil
+		}
+		if release != nil {
+			stream.err = errors.Join(stream.err, release())
+		}
+	}()
+
+	return stream
+}
+
+// NewStreamFromSlice sends items and then stops with err
+func NewStreamFromSlice[T any](items []T, err error) *Stream[T] {
+	i := 0
+	return NewStream(context.Background(), func() (T, bool, error) {
+		if i < len(items) {
+			i++
+			return items[i-1], true, nil
+		}
+
+		var empty T
+		return empty, false, err
+	}, nil)
+}
+
+func (stream *Stream[T]) generate(ctx context.Context, generate func() (T, bool, error)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return context.Cause(ctx)
+		}
+
+		value, ok, err := generate()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case stream.items <- value:
+		}
+	}
+}
+
+// Items is closed when the generation is stopped
+func (stream *Stream[T]) Items() <-chan T {
+	return stream.items
+}
+
+// Err waits until the generation is stopped and returns its error, nil means all items are generated or the stream is closed
+func (stream *Stream[T]) Err() error {
+	<-stream.done
+
+	return stream.err
+}
+
+// Close stops the generation and waits until resources are released.
+// Items not received yet are discarded
+func (stream *Stream[T]) Close() error {
+	stream.cancel(errStreamClosed)
+
+	return stream.Err()
+}
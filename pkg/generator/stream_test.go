@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStream(t *testing.T) {
+	someErr := errors.New("some error")
+	tests := []struct {
+		name       string
+		err        error
+		releaseErr error
+		want       []int
+		wantErr    []error
+	}{
+		{
+			name: "all items",
+			want: []int{1, 2, 3},
+		},
+		{
+			name:    "failed",
+			err:     someErr,
+			want:    []int{1, 2, 3},
+			wantErr: []error{someErr},
+		},
+		{
+			name:       "release failed",
+			err:        someErr,
+			releaseErr: context.DeadlineExceeded,
+			want:       []int{1, 2, 3},
+			wantErr:    []error{someErr, context.DeadlineExceeded},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := 0
+			released := 0
+			stream := NewStream(context.Background(), func() (int, bool, error) {
+				if i < 3 {
+					i++
+					return i, true, nil
+				}
+
+				return 0, false, tt.err
+			}, func() error {
+				released++
+				return tt.releaseErr
+			})
+
+			items := make([]int, 0)
+			for item := range stream.Items() {
+				items = append(items, item)
+			}
+
+			assert.Equal(t, tt.want, items)
+			err := stream.Err()
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			}
+			for _, wantErr := range tt.wantErr {
+				require.ErrorIs(t, err, wantErr)
+			}
+			assert.Equal(t, 1, released)
+		})
+	}
+}
+
+func TestStream_Close(t *testing.T) {
+	released := make(chan struct{})
+	stream := NewStream(context.Background(), func() (int, bool, error) {
+		return 1, true, nil
+	}, func() error {
+		close(released)
+		return nil
+	})
+
+	assert.Equal(t, 1, <-stream.Items())
+	require.NoError(t, stream.Close())
+	// resources are released before Close returns
+	select {
+	case <-released:
+	default:
+		t.Fatal("stream is not released")
+	}
+	require.NoError(t, stream.Err())
+}
+
+func TestStream_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := NewStream(ctx, func() (int, bool, error) {
+		return 1, true, nil
+	}, nil)
+
+	assert.Equal(t, 1, <-stream.Items())
+	cancel()
+	for range stream.Items() {
+	}
+	require.ErrorIs(t, stream.Err(), context.Canceled)
+}
+
+func TestNewStreamFromSlice(t *testing.T) {
+	someErr := errors.New("some error")
+	stream := NewStreamFromSlice([]string{"one", "two"}, someErr)
+
+	items := make([]string, 0)
+	for item := range stream.Items() {
+		items = append(items, item)
+	}
+
+	assert.Equal(t, []string{"one", "two"}, items)
+	require.ErrorIs(t, stream.Err(), someErr)
+}
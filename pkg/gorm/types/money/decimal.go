@@ -0,0 +1,118 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+func pow10(precision uint8) uint64 {
+	result := uint64(1)
+	for i := uint8(0); i < precision; i++ {
+		result *= 10
+	}
+
+	return result
+}
+
+func parseDecimal(value string, precision uint8) (uint64, error) {
+	if strings.HasPrefix(value, "-") {
+		return 0, ErrNegative
+	}
+
+	integer, fraction, hasFraction := strings.Cut(value, ".")
+	if !isDigits(integer) || (hasFraction && !isDigits(fraction)) {
+		return 0, ErrInvalidFormat
+	}
+	if len(fraction) > int(precision) {
+		return 0, ErrTooManyFractionalDigits
+	}
+
+	fraction += strings.Repeat("0", int(precision)-len(fraction))
+	result := uint64(0)
+	for _, digit := range integer + fraction {
+		next := result*10 + uint64(digit-'0')
+		if result > uint64(Max)/10 || next > uint64(Max) {
+			return 0, ErrOverflow
+		}
+		result = next
+	}
+
+	return result, nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatDecimal(value uint64, precision uint8) string {
+	if precision == 0 {
+		return fmt.Sprintf("%d", value)
+	}
+
+	factor := pow10(precision)
+
+	return fmt.Sprintf("%d.%0*d", value/factor, precision, value%factor)
+}
+
+func add(x, y uint64) (uint64, error) {
+	if x > uint64(Max) || y > uint64(Max)-x {
+		return 0, ErrOverflow
+	}
+
+	return x + y, nil
+}
+
+func sub(x, y uint64) (uint64, error) {
+	if y > x {
+		return 0, ErrNegative
+	}
+
+	return x - y, nil
+}
+
+// mul multiplies value by rate and rounds the result half to even (banker's rounding)
+func mul(value uint64, rate *big.Rat) (uint64, error) {
+	if rate.Sign() < 0 {
+		return 0, ErrNegative
+	}
+
+	numerator := new(big.Int).Mul(new(big.Int).SetUint64(value), rate.Num())
+	quotient, remainder := new(big.Int).QuoRem(numerator, rate.Denom(), new(big.Int))
+
+	switch new(big.Int).Lsh(remainder, 1).Cmp(rate.Denom()) {
+	case 1:
+		quotient.Add(quotient, big.NewInt(1))
+	case 0:
+		if quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	if !quotient.IsUint64() || quotient.Uint64() > uint64(Max) {
+		return 0, ErrOverflow
+	}
+
+	return quotient.Uint64(), nil
+}
+
+func cmp(x, y uint64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,166 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   uint64
+		rate    string
+		want    uint64
+		wantErr error
+	}{
+		{
+			name:  "identity",
+			value: 12345,
+			rate:  "1",
+			want:  12345,
+		},
+		{
+			name:  "exact",
+			value: 12345,
+			rate:  "0.2",
+			want:  2469,
+		},
+		{
+			name:  "half to even down",
+			value: 25,
+			rate:  "0.1",
+			want:  2,
+		},
+		{
+			name:  "half to even up",
+			value: 35,
+			rate:  "0.1",
+			want:  4,
+		},
+		{
+			name:  "above half",
+			value: 26,
+			rate:  "0.1",
+			want:  3,
+		},
+		{
+			name:  "below half",
+			value: 24,
+			rate:  "0.1",
+			want:  2,
+		},
+		{
+			name:  "fraction rate",
+			value: 100,
+			rate:  "1/3",
+			want:  33,
+		},
+		{
+			name:    "negative rate",
+			value:   100,
+			rate:    "-1",
+			wantErr: ErrNegative,
+		},
+		{
+			name:    "overflow",
+			value:   uint64(Max),
+			rate:    "2",
+			wantErr: ErrOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, ok := new(big.Rat).SetString(tt.rate)
+			require.True(t, ok)
+
+			result, err := mul(tt.value, rate)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestDecimalProperties(t *testing.T) {
+	config := &quick.Config{MaxCount: 5000}
+	bound := func(value uint64) uint64 {
+		return value % (uint64(Max) + 1)
+	}
+
+	t.Run("format then parse is identity", func(t *testing.T) {
+		require.NoError(t, quick.Check(func(value uint64, precision uint8) bool {
+			value, precision = bound(value), precision%(MaxPrecision+1)
+			parsed, err := parseDecimal(formatDecimal(value, precision), precision)
+
+			return err == nil && parsed == value
+		}, config))
+	})
+
+	t.Run("add is commutative and reversible by sub", func(t *testing.T) {
+		require.NoError(t, quick.Check(func(x, y uint64) bool {
+			x, y = bound(x)/2, bound(y)/2
+			xy, errXY := add(x, y)
+			yx, errYX := add(y, x)
+			back, errBack := sub(xy, y)
+
+			return errXY == nil && errYX == nil && errBack == nil && xy == yx && back == x
+		}, config))
+	})
+
+	t.Run("add never overflows silently", func(t *testing.T) {
+		require.NoError(t, quick.Check(func(x, y uint64) bool {
+			x, y = bound(x), bound(y)
+			result, err := add(x, y)
+			if err != nil {
+				return x+y > uint64(Max)
+			}
+
+			return result == x+y
+		}, config))
+	})
+
+	t.Run("sub below zero is an error", func(t *testing.T) {
+		require.NoError(t, quick.Check(func(x, y uint64) bool {
+			_, err := sub(x, y)
+
+			return (err != nil) == (y > x)
+		}, config))
+	})
+
+	t.Run("cmp is antisymmetric", func(t *testing.T) {
+		require.NoError(t, quick.Check(func(x, y uint64) bool {
+			return cmp(x, y) == -cmp(y, x) && (cmp(x, y) == 0) == (x == y)
+		}, config))
+	})
+
+	t.Run("mul rounds to the nearest even", func(t *testing.T) {
+		require.NoError(t, quick.Check(func(value uint32, numerator uint16, denominator uint16) bool {
+			rate := big.NewRat(int64(numerator), int64(denominator)+1)
+			result, err := mul(uint64(value), rate)
+			if err != nil {
+				return false
+			}
+
+			exact := new(big.Rat).Mul(new(big.Rat).SetUint64(uint64(value)), rate)
+			difference := new(big.Rat).Sub(exact, new(big.Rat).SetUint64(result))
+			half := big.NewRat(1, 2)
+
+			switch difference.Abs(difference).Cmp(half) {
+			case -1:
+				return true
+			case 0:
+				return result%2 == 0
+			default:
+				return false
+			}
+		}, config))
+	})
+}
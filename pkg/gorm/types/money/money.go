@@ -1,17 +1,29 @@
 package money
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-const precision = 2
+const precision uint8 = 2
 
-var factor = math.Pow10(precision)
+// Max is the largest storable amount, values are kept in signed bigint columns
+const Max = Amount(math.MaxInt64)
+
+var ErrInvalidFormat = errors.New("invalid money format")
+var ErrNegative = errors.New("money amount cannot be negative")
+var ErrTooManyFractionalDigits = errors.New("money amount has more fractional digits than its unit allows")
+var ErrOverflow = errors.New("money amount overflow")
+var ErrUnitMismatch = errors.New("money units mismatch")
 
 type ErrUnsupportedDBValue struct {
 	Value any
@@ -21,10 +33,92 @@ func (err ErrUnsupportedDBValue) Error() string {
 	return fmt.Sprintf("unsupported db value: %v", err.Value)
 }
 
+// Amount is a non-negative sum of money stored as an integer count of minimal units (cents)
 type Amount uint64
 
-func New(value float64) Amount {
-	return Amount(math.Round(value * factor))
+// Parse converts decimal string (e.g. "123.45") to Amount without any loss of precision
+func Parse(value string) (Amount, error) {
+	amount, err := parseDecimal(value, precision)
+	if err != nil {
+		return 0, err
+	}
+
+	return Amount(amount), nil
+}
+
+// MustParse is like Parse but panics if value cannot be parsed
+func MustParse(value string) Amount {
+	amount, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return amount
+}
+
+func (Amount) Unit() Unit {
+	return Bonus
+}
+
+func (amount Amount) Add(other Amount) (Amount, error) {
+	result, err := add(uint64(amount), uint64(other))
+
+	return Amount(result), err
+}
+
+func (amount Amount) Sub(other Amount) (Amount, error) {
+	result, err := sub(uint64(amount), uint64(other))
+
+	return Amount(result), err
+}
+
+// Mul multiplies amount by rate using banker's rounding
+func (amount Amount) Mul(rate *big.Rat) (Amount, error) {
+	result, err := mul(uint64(amount), rate)
+
+	return Amount(result), err
+}
+
+// Cmp returns -1, 0 or +1 if amount is less than, equal to or greater than other
+func (amount Amount) Cmp(other Amount) int {
+	return cmp(uint64(amount), uint64(other))
+}
+
+func (amount Amount) String() string {
+	return formatDecimal(uint64(amount), precision)
+}
+
+func (amount Amount) MarshalJSON() ([]byte, error) {
+	if amount > Max {
+		return nil, ErrOverflow
+	}
+
+	return []byte(amount.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and strings
+func (amount *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	value := string(data)
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return ErrInvalidFormat
+		}
+		value = unquoted
+	}
+
+	parsed, err := Parse(value)
+	if err != nil {
+		return err
+	}
+
+	*amount = parsed
+
+	return nil
 }
 
 func (Amount) GormDataType() string {
@@ -36,6 +130,9 @@ func (amount *Amount) Scan(value any) error {
 	if !ok {
 		return ErrUnsupportedDBValue{Value: value}
 	}
+	if count < 0 {
+		return ErrNegative
+	}
 
 	*amount = Amount(count)
 
@@ -50,7 +147,3 @@ func (amount Amount) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
 		},
 	}
 }
-
-func (amount *Amount) AsFloat() float64 {
-	return float64(*amount) / factor
-}
@@ -1,48 +1,215 @@
 package money
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func Test(t *testing.T) {
+func TestParse(t *testing.T) {
 	tests := []struct {
-		name     string
-		amount   float64
-		want     float64
-		wantUint uint64
+		name    string
+		value   string
+		want    Amount
+		wantErr error
 	}{
 		{
-			name:     "zero",
-			amount:   0,
-			want:     0,
-			wantUint: 0,
+			name:  "zero",
+			value: "0",
+			want:  0,
 		},
 		{
-			name:     "without round",
-			amount:   123.43,
-			want:     123.43,
-			wantUint: 12343,
+			name:  "integer",
+			value: "123",
+			want:  12300,
 		},
 		{
-			name:     "with round down",
-			amount:   123.433,
-			want:     123.43,
-			wantUint: 12343,
+			name:  "one fractional digit",
+			value: "123.4",
+			want:  12340,
 		},
 		{
-			name:     "with round up",
-			amount:   123.436,
-			want:     123.44,
-			wantUint: 12344,
+			name:  "two fractional digits",
+			value: "123.43",
+			want:  12343,
+		},
+		{
+			name:  "float unfriendly value",
+			value: "0.29",
+			want:  29,
+		},
+		{
+			name:  "max",
+			value: "92233720368547758.07",
+			want:  Max,
+		},
+		{
+			name:    "overflow",
+			value:   "92233720368547758.08",
+			wantErr: ErrOverflow,
+		},
+		{
+			name:    "uint64 overflow",
+			value:   "1844674407370955161600",
+			wantErr: ErrOverflow,
+		},
+		{
+			name:    "too many fractional digits",
+			value:   "123.433",
+			wantErr: ErrTooManyFractionalDigits,
+		},
+		{
+			name:    "negative",
+			value:   "-1.23",
+			wantErr: ErrNegative,
+		},
+		{
+			name:    "empty",
+			value:   "",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "empty integer part",
+			value:   ".12",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "empty fractional part",
+			value:   "12.",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "exponent",
+			value:   "1e2",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "not a number",
+			value:   "abc",
+			wantErr: ErrInvalidFormat,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			money := New(tt.amount)
-			assert.Equal(t, tt.want, money.AsFloat())
-			assert.Equal(t, tt.wantUint, uint64(money))
+			amount, err := Parse(tt.value)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, amount)
 		})
 	}
 }
+
+func TestAmount_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		want   string
+	}{
+		{
+			name:   "zero",
+			amount: 0,
+			want:   "0.00",
+		},
+		{
+			name:   "cents",
+			amount: 7,
+			want:   "0.07",
+		},
+		{
+			name:   "integer",
+			amount: 50000,
+			want:   "500.00",
+		},
+		{
+			name:   "fractional",
+			amount: 72998,
+			want:   "729.98",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.amount.String())
+			assert.Equal(t, tt.amount, MustParse(tt.amount.String()))
+		})
+	}
+}
+
+func TestAmount_JSON(t *testing.T) {
+	type payload struct {
+		Sum     Amount  `json:"sum"`
+		Accrual *Amount `json:"accrual"`
+	}
+
+	tests := []struct {
+		name    string
+		json    string
+		want    payload
+		wantErr error
+	}{
+		{
+			name: "numbers",
+			json: `{"sum": 751.1, "accrual": 0.29}`,
+			want: payload{Sum: 75110, Accrual: func() *Amount { amount := Amount(29); return &amount }()},
+		},
+		{
+			name: "strings",
+			json: `{"sum": "751.10", "accrual": "500"}`,
+			want: payload{Sum: 75110, Accrual: func() *Amount { amount := Amount(50000); return &amount }()},
+		},
+		{
+			name: "null",
+			json: `{"sum": null, "accrual": null}`,
+			want: payload{},
+		},
+		{
+			name:    "too many fractional digits",
+			json:    `{"sum": 751.123}`,
+			wantErr: ErrTooManyFractionalDigits,
+		},
+		{
+			name:    "negative",
+			json:    `{"sum": -751}`,
+			wantErr: ErrNegative,
+		},
+		{
+			name:    "overflow",
+			json:    `{"sum": 100000000000000000000}`,
+			wantErr: ErrOverflow,
+		},
+		{
+			name:    "exponent",
+			json:    `{"sum": 7.5e2}`,
+			wantErr: ErrInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := payload{}
+			err := json.Unmarshal([]byte(tt.json), &decoded)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, decoded)
+
+			encoded, err := json.Marshal(decoded)
+			require.NoError(t, err)
+			reDecoded := payload{}
+			require.NoError(t, json.Unmarshal(encoded, &reDecoded))
+			assert.Equal(t, decoded, reDecoded)
+		})
+	}
+}
+
+func TestAmount_MarshalJSONOverflow(t *testing.T) {
+	_, err := json.Marshal(Max + 1)
+	require.ErrorIs(t, err, ErrOverflow)
+}
@@ -0,0 +1,108 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// MaxPrecision is the largest precision whose factor (10^precision) still fits into uint64
+const MaxPrecision uint8 = 18
+
+var ErrInvalidUnitCode = errors.New("unit code must consist of 1-16 uppercase latin letters or digits")
+var ErrInvalidPrecision = fmt.Errorf("unit precision cannot be greater than %d", MaxPrecision)
+
+var unitCodeRegexp = regexp.MustCompile(`^[A-Z0-9]{1,16}$`)
+
+type ErrUnknownUnit struct {
+	Code string
+}
+
+func (err ErrUnknownUnit) Error() string {
+	return fmt.Sprintf("unknown unit: %s", err.Code)
+}
+
+type ErrUnitAlreadyRegistered struct {
+	Code string
+}
+
+func (err ErrUnitAlreadyRegistered) Error() string {
+	return fmt.Sprintf("unit %s already registered with another precision", err.Code)
+}
+
+// Unit describes a currency or a point system: its code and count of fractional digits
+type Unit struct {
+	code      string
+	precision uint8
+}
+
+func (unit Unit) Code() string {
+	return unit.code
+}
+
+func (unit Unit) Precision() uint8 {
+	return unit.precision
+}
+
+func (unit Unit) String() string {
+	return unit.code
+}
+
+var registry = struct {
+	sync.RWMutex
+	units map[string]Unit
+}{
+	units: map[string]Unit{},
+}
+
+// Bonus is the unit of Amount: loyalty bonuses with 2 fractional digits
+var Bonus = MustRegisterUnit("BONUS", precision)
+
+// RegisterUnit adds unit to the registry. Registering the same unit twice is a no-op
+func RegisterUnit(code string, precision uint8) (Unit, error) {
+	if !unitCodeRegexp.MatchString(code) {
+		return Unit{}, ErrInvalidUnitCode
+	}
+	if precision > MaxPrecision {
+		return Unit{}, ErrInvalidPrecision
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	unit := Unit{code: code, precision: precision}
+	if registered, ok := registry.units[code]; ok {
+		if registered != unit {
+			return Unit{}, ErrUnitAlreadyRegistered{Code: code}
+		}
+
+		return registered, nil
+	}
+
+	registry.units[code] = unit
+
+	return unit, nil
+}
+
+// MustRegisterUnit is like RegisterUnit but panics if unit cannot be registered
+func MustRegisterUnit(code string, precision uint8) Unit {
+	unit, err := RegisterUnit(code, precision)
+	if err != nil {
+		panic(err)
+	}
+
+	return unit
+}
+
+func LookupUnit(code string) (Unit, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	unit, ok := registry.units[code]
+	if !ok {
+		return Unit{}, ErrUnknownUnit{Code: code}
+	}
+
+	return unit, nil
+}
@@ -0,0 +1,211 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"math/big"
+	"strings"
+)
+
+// Value is an amount of money in an arbitrary registered unit.
+// It is serialized together with the unit code: "12.345 PTS" in DB and {"amount":"12.345","unit":"PTS"} in JSON.
+// Zero Value has no unit and is serialized as NULL in DB and null in JSON
+type Value struct {
+	units uint64
+	unit  Unit
+}
+
+type jsonValue struct {
+	Amount string `json:"amount"`
+	Unit   string `json:"unit"`
+}
+
+// NewValue creates Value from count of minimal units (e.g. 12345 is 12.345 for a unit with precision 3).
+// Unit must be registered
+func NewValue(units uint64, unit Unit) (Value, error) {
+	if err := checkRegistered(unit); err != nil {
+		return Value{}, err
+	}
+	if units > uint64(Max) {
+		return Value{}, ErrOverflow
+	}
+
+	return Value{units: units, unit: unit}, nil
+}
+
+// ParseValue converts decimal string to Value of the specified unit without any loss of precision.
+// Unit must be registered
+func ParseValue(value string, unit Unit) (Value, error) {
+	if err := checkRegistered(unit); err != nil {
+		return Value{}, err
+	}
+
+	units, err := parseDecimal(value, unit.precision)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return Value{units: units, unit: unit}, nil
+}
+
+// ParseValueWithUnit converts string like "12.345 PTS" to Value. Unit must be registered
+func ParseValueWithUnit(value string) (Value, error) {
+	amount, code, ok := strings.Cut(value, " ")
+	if !ok {
+		return Value{}, ErrInvalidFormat
+	}
+
+	unit, err := LookupUnit(code)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return ParseValue(amount, unit)
+}
+
+// checkRegistered rejects zero Unit, any other Unit can be obtained from the registry only
+func checkRegistered(unit Unit) error {
+	_, err := LookupUnit(unit.code)
+
+	return err
+}
+
+func (value Value) IsZero() bool {
+	return value == Value{}
+}
+
+func (value Value) Units() uint64 {
+	return value.units
+}
+
+func (value Value) Unit() Unit {
+	return value.unit
+}
+
+func (value Value) Add(other Value) (Value, error) {
+	if value.unit != other.unit {
+		return Value{}, ErrUnitMismatch
+	}
+
+	units, err := add(value.units, other.units)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return Value{units: units, unit: value.unit}, nil
+}
+
+func (value Value) Sub(other Value) (Value, error) {
+	if value.unit != other.unit {
+		return Value{}, ErrUnitMismatch
+	}
+
+	units, err := sub(value.units, other.units)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return Value{units: units, unit: value.unit}, nil
+}
+
+// Mul multiplies value by rate using banker's rounding to the unit precision
+func (value Value) Mul(rate *big.Rat) (Value, error) {
+	units, err := mul(value.units, rate)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return Value{units: units, unit: value.unit}, nil
+}
+
+// Cmp returns -1, 0 or +1 if value is less than, equal to or greater than other
+func (value Value) Cmp(other Value) (int, error) {
+	if value.unit != other.unit {
+		return 0, ErrUnitMismatch
+	}
+
+	return cmp(value.units, other.units), nil
+}
+
+// Amount returns decimal representation without unit
+func (value Value) Amount() string {
+	return formatDecimal(value.units, value.unit.precision)
+}
+
+func (value Value) String() string {
+	return value.Amount() + " " + value.unit.code
+}
+
+func (value Value) MarshalJSON() ([]byte, error) {
+	if value.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(jsonValue{
+		Amount: value.Amount(),
+		Unit:   value.unit.code,
+	})
+}
+
+func (value *Value) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*value = Value{}
+		return nil
+	}
+
+	decoded := jsonValue{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	unit, err := LookupUnit(decoded.Unit)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseValue(decoded.Amount, unit)
+	if err != nil {
+		return err
+	}
+
+	*value = parsed
+
+	return nil
+}
+
+func (Value) GormDataType() string {
+	return "string"
+}
+
+func (value *Value) Scan(src any) error {
+	var raw string
+	switch typed := src.(type) {
+	case nil:
+		*value = Value{}
+		return nil
+	case string:
+		raw = typed
+	case []byte:
+		raw = string(typed)
+	default:
+		return ErrUnsupportedDBValue{Value: src}
+	}
+
+	parsed, err := ParseValueWithUnit(raw)
+	if err != nil {
+		return err
+	}
+
+	*value = parsed
+
+	return nil
+}
+
+func (value Value) Value() (driver.Value, error) {
+	if value.IsZero() {
+		return nil, nil
+	}
+
+	return value.String(), nil
+}
@@ -0,0 +1,229 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterUnit(t *testing.T) {
+	unit, err := RegisterUnit("TESTPTS", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "TESTPTS", unit.Code())
+	assert.Equal(t, uint8(0), unit.Precision())
+
+	again, err := RegisterUnit("TESTPTS", 0)
+	require.NoError(t, err)
+	assert.Equal(t, unit, again)
+
+	_, err = RegisterUnit("TESTPTS", 3)
+	require.ErrorAs(t, err, &ErrUnitAlreadyRegistered{})
+
+	_, err = RegisterUnit("test pts", 0)
+	require.ErrorIs(t, err, ErrInvalidUnitCode)
+
+	_, err = RegisterUnit("TESTBIG", MaxPrecision+1)
+	require.ErrorIs(t, err, ErrInvalidPrecision)
+
+	found, err := LookupUnit("TESTPTS")
+	require.NoError(t, err)
+	assert.Equal(t, unit, found)
+
+	_, err = LookupUnit("TESTUNKNOWN")
+	require.ErrorAs(t, err, &ErrUnknownUnit{})
+
+	assert.Equal(t, Bonus, Amount(0).Unit())
+}
+
+func TestParseValue(t *testing.T) {
+	points := MustRegisterUnit("TESTP0", 0)
+	milli := MustRegisterUnit("TESTP3", 3)
+
+	tests := []struct {
+		name    string
+		value   string
+		unit    Unit
+		want    uint64
+		wantErr error
+	}{
+		{
+			name:  "precision 0",
+			value: "125",
+			unit:  points,
+			want:  125,
+		},
+		{
+			name:    "precision 0 with fraction",
+			value:   "125.5",
+			unit:    points,
+			wantErr: ErrTooManyFractionalDigits,
+		},
+		{
+			name:  "precision 3",
+			value: "12.345",
+			unit:  milli,
+			want:  12345,
+		},
+		{
+			name:  "precision 3 short fraction",
+			value: "12.3",
+			unit:  milli,
+			want:  12300,
+		},
+		{
+			name:    "precision 3 with long fraction",
+			value:   "12.3456",
+			unit:    milli,
+			wantErr: ErrTooManyFractionalDigits,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ParseValue(tt.value, tt.unit)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, value.Units())
+			assert.Equal(t, tt.unit, value.Unit())
+		})
+	}
+}
+
+func TestValue_Arithmetic(t *testing.T) {
+	points := MustRegisterUnit("TESTA0", 0)
+	milli := MustRegisterUnit("TESTA3", 3)
+
+	x, err := ParseValue("10.005", milli)
+	require.NoError(t, err)
+	y, err := ParseValue("0.995", milli)
+	require.NoError(t, err)
+
+	sum, err := x.Add(y)
+	require.NoError(t, err)
+	assert.Equal(t, "11.000 TESTA3", sum.String())
+
+	difference, err := x.Sub(y)
+	require.NoError(t, err)
+	assert.Equal(t, "9.010 TESTA3", difference.String())
+
+	_, err = y.Sub(x)
+	require.ErrorIs(t, err, ErrNegative)
+
+	product, err := x.Mul(big.NewRat(1, 2))
+	require.NoError(t, err)
+	assert.Equal(t, "5.002 TESTA3", product.String()) // 5.0025 rounded half to even
+
+	order, err := x.Cmp(y)
+	require.NoError(t, err)
+	assert.Equal(t, 1, order)
+
+	other, err := NewValue(10, points)
+	require.NoError(t, err)
+	_, err = x.Add(other)
+	require.ErrorIs(t, err, ErrUnitMismatch)
+	_, err = x.Sub(other)
+	require.ErrorIs(t, err, ErrUnitMismatch)
+	_, err = x.Cmp(other)
+	require.ErrorIs(t, err, ErrUnitMismatch)
+
+	_, err = NewValue(uint64(Max)+1, points)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = NewValue(10, Unit{})
+	require.ErrorAs(t, err, &ErrUnknownUnit{})
+	_, err = ParseValue("10", Unit{})
+	require.ErrorAs(t, err, &ErrUnknownUnit{})
+}
+
+func TestValue_Serialization(t *testing.T) {
+	milli := MustRegisterUnit("TESTS3", 3)
+	value, err := ParseValue("12.345", milli)
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(value)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"12.345","unit":"TESTS3"}`, string(encoded))
+
+	decoded := Value{}
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, value, decoded)
+
+	require.ErrorAs(t, json.Unmarshal([]byte(`{"amount":"1","unit":"TESTNOPE"}`), &decoded), &ErrUnknownUnit{})
+	require.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"1.2345","unit":"TESTS3"}`), &decoded), ErrTooManyFractionalDigits)
+
+	dbValue, err := value.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "12.345 TESTS3", dbValue)
+
+	scanned := Value{}
+	require.NoError(t, scanned.Scan(dbValue))
+	assert.Equal(t, value, scanned)
+	require.NoError(t, scanned.Scan([]byte("1.000 TESTS3")))
+	assert.Equal(t, uint64(1000), scanned.Units())
+	require.ErrorAs(t, scanned.Scan(int64(1)), &ErrUnsupportedDBValue{})
+	require.ErrorIs(t, scanned.Scan("12.345"), ErrInvalidFormat)
+}
+
+func TestValue_ZeroSerialization(t *testing.T) {
+	value := Value{}
+	assert.True(t, value.IsZero())
+
+	encoded, err := json.Marshal(value)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(encoded))
+
+	decoded, err := ParseValue("1", MustRegisterUnit("TESTZ0", 0))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, value, decoded)
+
+	dbValue, err := value.Value()
+	require.NoError(t, err)
+	assert.Nil(t, dbValue)
+
+	scanned, err := ParseValue("1", MustRegisterUnit("TESTZ0", 0))
+	require.NoError(t, err)
+	require.NoError(t, scanned.Scan(dbValue))
+	assert.Equal(t, value, scanned)
+}
+
+func TestValue_SerializationProperties(t *testing.T) {
+	units := make([]Unit, 0, MaxPrecision+1)
+	for precision := uint8(0); precision <= MaxPrecision; precision++ {
+		units = append(units, MustRegisterUnit("TESTQ"+string(rune('A'+precision)), precision))
+	}
+
+	require.NoError(t, quick.Check(func(raw uint64, index uint8) bool {
+		value, err := NewValue(raw%(uint64(Max)+1), units[int(index)%len(units)])
+		if err != nil {
+			return false
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return false
+		}
+		fromJSON := Value{}
+		if err := json.Unmarshal(encoded, &fromJSON); err != nil {
+			return false
+		}
+
+		dbValue, err := value.Value()
+		if err != nil {
+			return false
+		}
+		fromDB := Value{}
+		if err := fromDB.Scan(dbValue); err != nil {
+			return false
+		}
+
+		return fromJSON == value && fromDB == value
+	}, &quick.Config{MaxCount: 5000}))
+}
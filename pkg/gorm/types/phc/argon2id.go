@@ -0,0 +1,113 @@
+package phc
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+type argon2idHash struct {
+	version int
+	params  Argon2idParams
+	salt    []byte
+	key     []byte
+}
+
+func newArgon2idHash(password string, params Argon2idParams) (Hash, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return Hash(fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+func parseArgon2idHash(hash Hash) (*argon2idHash, error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != string(Argon2id) {
+		return nil, ErrInvalidHash
+	}
+
+	decoded := &argon2idHash{}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &decoded.version); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(
+		parts[3],
+		"m=%d,t=%d,p=%d",
+		&decoded.params.Memory,
+		&decoded.params.Iterations,
+		&decoded.params.Parallelism,
+	); err != nil {
+		return nil, ErrInvalidHash
+	}
+
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if len(decoded.key) == 0 || decoded.params.Iterations == 0 || decoded.params.Parallelism == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	decoded.params.SaltLength = uint32(len(decoded.salt))
+	decoded.params.KeyLength = uint32(len(decoded.key))
+
+	return decoded, nil
+}
+
+func compareArgon2id(hash Hash, password string) error {
+	decoded, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+	if decoded.version != argon2.Version {
+		return ErrUnsupportedAlgorithm
+	}
+
+	key := argon2.IDKey(
+		[]byte(password),
+		decoded.salt,
+		decoded.params.Iterations,
+		decoded.params.Memory,
+		decoded.params.Parallelism,
+		decoded.params.KeyLength,
+	)
+	if subtle.ConstantTimeCompare(key, decoded.key) != 1 {
+		return ErrMismatchedPassword
+	}
+
+	return nil
+}
+
+func argon2idNeedsRehash(hash Hash, params Argon2idParams) bool {
+	decoded, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return decoded.version != argon2.Version ||
+		decoded.params.Memory < params.Memory ||
+		decoded.params.Iterations < params.Iterations ||
+		decoded.params.Parallelism < params.Parallelism ||
+		decoded.params.SaltLength < params.SaltLength ||
+		decoded.params.KeyLength < params.KeyLength
+}
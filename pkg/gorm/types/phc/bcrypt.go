@@ -0,0 +1,32 @@
+package phc
+
+import (
+	"errors"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/bcrypt"
+	cryptobcrypt "golang.org/x/crypto/bcrypt"
+)
+
+func newBcryptHash(password string, cost int) (Hash, error) {
+	hash, err := bcrypt.NewHash(password, cost)
+	if err != nil {
+		return "", err
+	}
+
+	return Hash(hash), nil
+}
+
+func compareBcrypt(hash Hash, password string) error {
+	err := bcrypt.Hash(hash).CompareWithPassword(password)
+	if errors.Is(err, cryptobcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatchedPassword
+	}
+
+	return err
+}
+
+func bcryptNeedsRehash(hash Hash, cost int) bool {
+	hashCost, err := bcrypt.Hash(hash).Cost()
+
+	return err != nil || hashCost < cost
+}
@@ -0,0 +1,113 @@
+package phc
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Algorithm string
+
+const (
+	Argon2id Algorithm = "argon2id"
+	Bcrypt   Algorithm = "bcrypt"
+)
+
+var ErrInvalidHash = errors.New("invalid password hash format")
+var ErrUnsupportedAlgorithm = errors.New("unsupported password hash algorithm")
+var ErrMismatchedPassword = errors.New("password does not match the hash")
+
+type ErrUnsupportedDBValue struct {
+	Value any
+}
+
+func (err ErrUnsupportedDBValue) Error() string {
+	return fmt.Sprintf("unsupported db value: %v", err.Value)
+}
+
+// Hash is a password hash in PHC string format, e.g. "$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>".
+// bcrypt hashes are kept in their native "$2a$<cost>$..." form, which is identified the same way
+type Hash string
+
+func NewHash(password string, policy Policy) (Hash, error) {
+	switch policy.Algorithm {
+	case Argon2id:
+		return newArgon2idHash(password, policy.Argon2id)
+	case Bcrypt:
+		return newBcryptHash(password, policy.BcryptCost)
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+}
+
+// Algorithm returns the algorithm identifier stored in the hash
+func (hash Hash) Algorithm() (Algorithm, error) {
+	value, ok := strings.CutPrefix(string(hash), "$")
+	if !ok {
+		return "", ErrInvalidHash
+	}
+	id, _, ok := strings.Cut(value, "$")
+	if !ok {
+		return "", ErrInvalidHash
+	}
+
+	switch id {
+	case "argon2id":
+		return Argon2id, nil
+	case "2a", "2b", "2y":
+		return Bcrypt, nil
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+}
+
+func (hash Hash) CompareWithPassword(password string) error {
+	algorithm, err := hash.Algorithm()
+	if err != nil {
+		return err
+	}
+
+	switch algorithm {
+	case Argon2id:
+		return compareArgon2id(hash, password)
+	default:
+		return compareBcrypt(hash, password)
+	}
+}
+
+// NeedsRehash reports whether the hash uses another algorithm or weaker parameters than the policy requires
+func (hash Hash) NeedsRehash(policy Policy) bool {
+	algorithm, err := hash.Algorithm()
+	if err != nil || algorithm != policy.Algorithm {
+		return true
+	}
+
+	switch algorithm {
+	case Argon2id:
+		return argon2idNeedsRehash(hash, policy.Argon2id)
+	default:
+		return bcryptNeedsRehash(hash, policy.BcryptCost)
+	}
+}
+
+func (Hash) GormDataType() string {
+	return "string"
+}
+
+func (hash *Hash) Scan(value any) error {
+	switch typed := value.(type) {
+	case string:
+		*hash = Hash(typed)
+	case []byte:
+		*hash = Hash(typed)
+	default:
+		return ErrUnsupportedDBValue{Value: value}
+	}
+
+	return nil
+}
+
+func (hash Hash) Value() (driver.Value, error) {
+	return string(hash), nil
+}
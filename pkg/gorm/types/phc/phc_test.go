@@ -0,0 +1,155 @@
+package phc
+
+import (
+	"testing"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/bcrypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestNewHash(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		algorithm Algorithm
+		wantErr   error
+	}{
+		{
+			name:      "argon2id",
+			policy:    Policy{Algorithm: Argon2id, Argon2id: testArgon2idParams},
+			algorithm: Argon2id,
+		},
+		{
+			name:      "bcrypt",
+			policy:    Policy{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost},
+			algorithm: Bcrypt,
+		},
+		{
+			name:    "unsupported",
+			policy:  Policy{Algorithm: "md5"},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := NewHash("test_password", tt.policy)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			algorithm, err := hash.Algorithm()
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, algorithm)
+			assert.NoError(t, hash.CompareWithPassword("test_password"))
+			assert.ErrorIs(t, hash.CompareWithPassword("invalid_password"), ErrMismatchedPassword)
+			assert.False(t, hash.NeedsRehash(tt.policy))
+		})
+	}
+}
+
+func TestHash_Algorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    Hash
+		want    Algorithm
+		wantErr error
+	}{
+		{
+			name: "argon2id",
+			hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+			want: Argon2id,
+		},
+		{
+			name: "bcrypt",
+			hash: "$2a$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW",
+			want: Bcrypt,
+		},
+		{
+			name:    "empty",
+			hash:    "",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "no identifier",
+			hash:    "argon2id",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "unknown identifier",
+			hash:    "$scrypt$ln=16,r=8,p=1$c2FsdA$a2V5",
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithm, err := tt.hash.Algorithm()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, algorithm)
+		})
+	}
+}
+
+func TestHash_CompareWithPasswordInvalid(t *testing.T) {
+	assert.ErrorIs(t, Hash("$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5").CompareWithPassword("test"), ErrInvalidHash)
+	assert.ErrorIs(t, Hash("$argon2id$v=19$m=64,t=1$c2FsdA$a2V5").CompareWithPassword("test"), ErrInvalidHash)
+	assert.ErrorIs(t, Hash("$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5").CompareWithPassword("test"), ErrUnsupportedAlgorithm)
+	assert.ErrorIs(t, Hash("plain").CompareWithPassword("plain"), ErrInvalidHash)
+}
+
+func TestHash_NeedsRehash(t *testing.T) {
+	argon2idPolicy := Policy{Algorithm: Argon2id, Argon2id: testArgon2idParams}
+	bcryptPolicy := Policy{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost + 1}
+
+	argon2idHash, err := NewHash("test_password", argon2idPolicy)
+	require.NoError(t, err)
+	weakBcryptHash, err := NewHash("test_password", Policy{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+
+	stronger := func(modify func(params *Argon2idParams)) Policy {
+		policy := argon2idPolicy
+		modify(&policy.Argon2id)
+
+		return policy
+	}
+
+	assert.False(t, argon2idHash.NeedsRehash(argon2idPolicy))
+	assert.True(t, argon2idHash.NeedsRehash(bcryptPolicy))
+	assert.True(t, weakBcryptHash.NeedsRehash(argon2idPolicy))
+	assert.True(t, weakBcryptHash.NeedsRehash(bcryptPolicy))
+	assert.False(t, weakBcryptHash.NeedsRehash(Policy{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost}))
+	assert.True(t, argon2idHash.NeedsRehash(stronger(func(params *Argon2idParams) { params.Memory *= 2 })))
+	assert.True(t, argon2idHash.NeedsRehash(stronger(func(params *Argon2idParams) { params.Iterations++ })))
+	assert.True(t, argon2idHash.NeedsRehash(stronger(func(params *Argon2idParams) { params.Parallelism++ })))
+	assert.True(t, argon2idHash.NeedsRehash(stronger(func(params *Argon2idParams) { params.SaltLength++ })))
+	assert.True(t, argon2idHash.NeedsRehash(stronger(func(params *Argon2idParams) { params.KeyLength++ })))
+	assert.True(t, Hash("").NeedsRehash(argon2idPolicy))
+}
+
+func TestHash_Scan(t *testing.T) {
+	hash := Hash("")
+	require.NoError(t, hash.Scan("$2a$04$hash"))
+	assert.Equal(t, Hash("$2a$04$hash"), hash)
+	require.NoError(t, hash.Scan([]byte("$argon2id$hash")))
+	assert.Equal(t, Hash("$argon2id$hash"), hash)
+	require.ErrorAs(t, hash.Scan(1), &ErrUnsupportedDBValue{})
+
+	value, err := hash.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "$argon2id$hash", value)
+}
@@ -0,0 +1,32 @@
+package phc
+
+import "github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/bcrypt"
+
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Policy describes how new hashes are created and which existing hashes are considered weak
+type Policy struct {
+	Algorithm  Algorithm
+	Argon2id   Argon2idParams
+	BcryptCost int
+}
+
+// DefaultPolicy follows OWASP recommendations
+// See https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#argon2id
+var DefaultPolicy = Policy{
+	Algorithm: Argon2id,
+	Argon2id: Argon2idParams{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	},
+	BcryptCost: bcrypt.RecommendedCost,
+}
@@ -0,0 +1,199 @@
+package lockout
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const DefaultThreshold = 5
+const DefaultBaseDuration = time.Second
+const DefaultMaxDuration = time.Minute * 15
+const DefaultForgetAfter = time.Hour
+const DefaultMaxKeys = 100000
+
+// Lockout tracks failed attempts per key and locks the key with exponentially growing duration
+// after Threshold consecutive failures: BaseDuration, 2*BaseDuration, 4*BaseDuration ... up to MaxDuration
+type Lockout struct {
+	mutex   sync.Mutex
+	entries map[string]*entry
+	// order holds keys by the last failure, the oldest first, so forgotten and evicted keys are found without a scan
+	order  *list.List
+	now    func() time.Time
+	config *Config
+}
+
+type Config struct {
+	Threshold    uint64
+	BaseDuration *time.Duration
+	MaxDuration  *time.Duration
+	// ForgetAfter is a period without failures after which the key failures are forgotten
+	ForgetAfter *time.Duration
+	// MaxKeys limits tracked keys, the key with the oldest failure is forgotten first
+	MaxKeys uint64
+}
+
+type entry struct {
+	failures    uint64
+	lastFailure time.Time
+	lockedUntil time.Time
+	element     *list.Element
+}
+
+func prepareConfig(config *Config) {
+	if config.Threshold == 0 {
+		config.Threshold = DefaultThreshold
+	}
+	if config.BaseDuration == nil || *config.BaseDuration <= 0 {
+		defaultValue := DefaultBaseDuration
+		config.BaseDuration = &defaultValue
+	}
+	if config.MaxDuration == nil || *config.MaxDuration < *config.BaseDuration {
+		defaultValue := max(DefaultMaxDuration, *config.BaseDuration)
+		config.MaxDuration = &defaultValue
+	}
+	if config.ForgetAfter == nil || *config.ForgetAfter < *config.MaxDuration {
+		defaultValue := max(DefaultForgetAfter, *config.MaxDuration)
+		config.ForgetAfter = &defaultValue
+	}
+	if config.MaxKeys == 0 {
+		config.MaxKeys = DefaultMaxKeys
+	}
+}
+
+func New(config *Config) *Lockout {
+	prepareConfig(config)
+
+	return &Lockout{
+		entries: make(map[string]*entry),
+		order:   list.New(),
+		now:     time.Now,
+		config:  config,
+	}
+}
+
+// Check returns the remaining lock duration of the key, zero if the key is not locked
+func (lockout *Lockout) Check(key string) time.Duration {
+	lockout.mutex.Lock()
+	defer lockout.mutex.Unlock()
+
+	entry, ok := lockout.entries[key]
+	if !ok {
+		return 0
+	}
+
+	return max(entry.lockedUntil.Sub(lockout.now()), 0)
+}
+
+// Attempt reserves an attempt of the key before it is checked, so concurrent attempts can not bypass Threshold.
+// The attempt is counted as a failure until Reset is called. Returns false and remaining lock duration
+// if the key is locked, otherwise returns lock duration caused by the attempt if it fails
+func (lockout *Lockout) Attempt(key string) (bool, time.Duration) {
+	lockout.mutex.Lock()
+	defer lockout.mutex.Unlock()
+
+	now := lockout.now()
+	if entry, ok := lockout.entries[key]; ok && entry.lockedUntil.After(now) {
+		return false, entry.lockedUntil.Sub(now)
+	}
+
+	_, duration := lockout.fail(key, now)
+
+	return true, duration
+}
+
+// Release takes back the attempt which could not be checked, e.g. because of a storage error.
+// The key was not locked when the attempt was allowed, so a lock caused meanwhile is lifted
+func (lockout *Lockout) Release(key string) {
+	lockout.mutex.Lock()
+	defer lockout.mutex.Unlock()
+
+	released, ok := lockout.entries[key]
+	if !ok {
+		return
+	}
+
+	released.failures--
+	released.lockedUntil = time.Time{}
+	if released.failures == 0 {
+		lockout.remove(key, released)
+	}
+}
+
+// Fail registers a failed attempt. Returns count of consecutive failures and lock duration (zero if key is not locked)
+func (lockout *Lockout) Fail(key string) (uint64, time.Duration) {
+	lockout.mutex.Lock()
+	defer lockout.mutex.Unlock()
+
+	return lockout.fail(key, lockout.now())
+}
+
+// fail must be called with the mutex held
+func (lockout *Lockout) fail(key string, now time.Time) (uint64, time.Duration) {
+	lockout.forget(now)
+
+	failed, ok := lockout.entries[key]
+	if !ok {
+		lockout.evict()
+		failed = &entry{element: lockout.order.PushBack(key)}
+		lockout.entries[key] = failed
+	}
+
+	failed.failures++
+	failed.lastFailure = now
+	lockout.order.MoveToBack(failed.element)
+	if failed.failures < lockout.config.Threshold {
+		return failed.failures, 0
+	}
+
+	duration := lockout.duration(failed.failures - lockout.config.Threshold)
+	failed.lockedUntil = now.Add(duration)
+
+	return failed.failures, duration
+}
+
+// Reset forgets failures of the key, e.g. after a successful attempt
+func (lockout *Lockout) Reset(key string) {
+	lockout.mutex.Lock()
+	defer lockout.mutex.Unlock()
+
+	if reset, ok := lockout.entries[key]; ok {
+		lockout.remove(key, reset)
+	}
+}
+
+func (lockout *Lockout) duration(exponent uint64) time.Duration {
+	duration := *lockout.config.BaseDuration
+	for ; exponent > 0 && duration < *lockout.config.MaxDuration; exponent-- {
+		duration *= 2
+	}
+
+	return min(duration, *lockout.config.MaxDuration)
+}
+
+// forget removes keys without failures for ForgetAfter, so the map does not grow with never repeated keys
+func (lockout *Lockout) forget(now time.Time) {
+	for front := lockout.order.Front(); front != nil; front = lockout.order.Front() {
+		key := front.Value.(string)
+		oldest := lockout.entries[key]
+		if now.Sub(oldest.lastFailure) <= *lockout.config.ForgetAfter {
+			return
+		}
+		lockout.remove(key, oldest)
+	}
+}
+
+// evict makes room for a new key if MaxKeys is reached, the key with the oldest failure is removed
+func (lockout *Lockout) evict() {
+	if uint64(len(lockout.entries)) < lockout.config.MaxKeys {
+		return
+	}
+
+	key := lockout.order.Front().Value.(string)
+	lockout.remove(key, lockout.entries[key])
+}
+
+func (lockout *Lockout) remove(key string, removed *entry) {
+	lockout.order.Remove(removed.element)
+	delete(lockout.entries, key)
+}
@@ -0,0 +1,202 @@
+package lockout
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (clock *clock) Now() time.Time {
+	return clock.now
+}
+
+func newTestLockout(config *Config) (*Lockout, *clock) {
+	clock := &clock{now: time.Now()}
+	lockout := New(config)
+	lockout.now = clock.Now
+
+	return lockout, clock
+}
+
+func durationPointer(duration time.Duration) *time.Duration {
+	return &duration
+}
+
+func TestLockout_ExponentialLock(t *testing.T) {
+	lockout, _ := newTestLockout(&Config{
+		Threshold:    3,
+		BaseDuration: durationPointer(time.Second),
+		MaxDuration:  durationPointer(time.Second * 10),
+	})
+
+	expected := []time.Duration{0, 0, time.Second, time.Second * 2, time.Second * 4, time.Second * 8, time.Second * 10, time.Second * 10}
+	for i, want := range expected {
+		failures, locked := lockout.Fail("login")
+		assert.Equal(t, uint64(i+1), failures)
+		assert.Equal(t, want, locked, fmt.Sprintf("failure %d", i+1))
+		assert.Equal(t, want, lockout.Check("login"))
+	}
+
+	assert.Equal(t, time.Duration(0), lockout.Check("another_login"))
+}
+
+func TestLockout_Unlock(t *testing.T) {
+	lockout, clock := newTestLockout(&Config{
+		Threshold:    1,
+		BaseDuration: durationPointer(time.Minute),
+	})
+
+	_, locked := lockout.Fail("login")
+	assert.Equal(t, time.Minute, locked)
+
+	clock.now = clock.now.Add(time.Second * 20)
+	assert.Equal(t, time.Second*40, lockout.Check("login"))
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), lockout.Check("login"))
+
+	_, locked = lockout.Fail("login")
+	assert.Equal(t, time.Minute*2, locked)
+}
+
+func TestLockout_Reset(t *testing.T) {
+	lockout, _ := newTestLockout(&Config{Threshold: 1})
+
+	lockout.Fail("login")
+	assert.NotZero(t, lockout.Check("login"))
+
+	lockout.Reset("login")
+	assert.Zero(t, lockout.Check("login"))
+	failures, _ := lockout.Fail("login")
+	assert.Equal(t, uint64(1), failures)
+}
+
+func TestLockout_Forget(t *testing.T) {
+	lockout, clock := newTestLockout(&Config{
+		Threshold:    2,
+		BaseDuration: durationPointer(time.Second),
+		MaxDuration:  durationPointer(time.Minute),
+		ForgetAfter:  durationPointer(time.Hour),
+	})
+
+	lockout.Fail("login")
+	lockout.Fail("stale")
+
+	clock.now = clock.now.Add(time.Hour + time.Second)
+	failures, locked := lockout.Fail("login")
+	assert.Equal(t, uint64(1), failures)
+	assert.Zero(t, locked)
+	assert.NotContains(t, lockout.entries, "stale")
+}
+
+func TestLockout_Attempt(t *testing.T) {
+	lockout, _ := newTestLockout(&Config{
+		Threshold:    3,
+		BaseDuration: durationPointer(time.Minute),
+	})
+
+	allowed := atomic.Uint64{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := lockout.Attempt("login"); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 3, allowed.Load())
+	ok, retryAfter := lockout.Attempt("login")
+	assert.False(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	lockout.Reset("login")
+	ok, locked := lockout.Attempt("login")
+	assert.True(t, ok)
+	assert.Zero(t, locked)
+}
+
+func TestLockout_Release(t *testing.T) {
+	lockout, _ := newTestLockout(&Config{
+		Threshold:    2,
+		BaseDuration: durationPointer(time.Minute),
+	})
+
+	ok, _ := lockout.Attempt("login")
+	assert.True(t, ok)
+	ok, locked := lockout.Attempt("login")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, locked)
+
+	// the second attempt is taken back, so the key is not locked and keeps the first failure
+	lockout.Release("login")
+	assert.Zero(t, lockout.Check("login"))
+	ok, locked = lockout.Attempt("login")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, locked)
+
+	lockout.Release("login")
+	lockout.Release("login")
+	assert.NotContains(t, lockout.entries, "login")
+	lockout.Release("login")
+	assert.NotContains(t, lockout.entries, "login")
+}
+
+func TestLockout_MaxKeys(t *testing.T) {
+	lockout, clock := newTestLockout(&Config{
+		Threshold: 1,
+		MaxKeys:   2,
+	})
+
+	lockout.Fail("first")
+	clock.now = clock.now.Add(time.Second)
+	lockout.Fail("second")
+	clock.now = clock.now.Add(time.Second)
+	lockout.Fail("third")
+
+	assert.Len(t, lockout.entries, 2)
+	assert.NotContains(t, lockout.entries, "first")
+	assert.NotZero(t, lockout.Check("third"))
+}
+
+func TestLockout_MaxKeysRepeatedFailure(t *testing.T) {
+	lockout, clock := newTestLockout(&Config{
+		Threshold: 1,
+		MaxKeys:   2,
+	})
+
+	lockout.Fail("first")
+	clock.now = clock.now.Add(time.Second)
+	lockout.Fail("second")
+	clock.now = clock.now.Add(time.Second)
+	// second is the oldest failure now
+	lockout.Fail("first")
+	clock.now = clock.now.Add(time.Second)
+	lockout.Fail("third")
+
+	assert.Len(t, lockout.entries, 2)
+	assert.NotContains(t, lockout.entries, "second")
+	assert.Equal(t, 2, lockout.order.Len())
+}
+
+func TestPrepareConfig(t *testing.T) {
+	config := &Config{MaxDuration: durationPointer(time.Millisecond)}
+	prepareConfig(config)
+
+	assert.Equal(t, uint64(DefaultThreshold), config.Threshold)
+	assert.Equal(t, DefaultBaseDuration, *config.BaseDuration)
+	assert.Equal(t, DefaultMaxDuration, *config.MaxDuration)
+	assert.Equal(t, DefaultForgetAfter, *config.ForgetAfter)
+	assert.Equal(t, uint64(DefaultMaxKeys), config.MaxKeys)
+}
@@ -11,6 +11,9 @@ import (
 	"slices"
 	"strings"
 	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 )
 
 type encoderPool struct {
@@ -24,13 +27,38 @@ type compressedResponseWriter struct {
 	writer         io.Writer
 	encoding       string
 	supportedTypes []string
+	minSize        int
+	buffer         []byte
+	status         int
 	wroteHeader    bool
+	committed      bool
 }
 
+// newEncoderPool creates encoders ordered by preference, level is gzip level (1-9)
+// and is used as is by brotli (0-11) and converted to the closest zstd level
 func newEncoderPool(level uint8) *encoderPool {
 	return &encoderPool{
-		order: []string{"gzip", "deflate"},
+		order: []string{"zstd", "br", "gzip", "deflate"},
 		pool: map[string]*sync.Pool{
+			"zstd": {
+				New: func() any {
+					writer, err := zstd.NewWriter(
+						nil,
+						zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(int(level))),
+						zstd.WithEncoderConcurrency(1),
+					)
+					if err != nil {
+						return nil
+					}
+
+					return writer
+				},
+			},
+			"br": {
+				New: func() any {
+					return brotli.NewWriterLevel(nil, int(level))
+				},
+			},
 			"gzip": {
 				New: func() any {
 					writer, err := gzip.NewWriterLevel(io.Discard, int(level))
@@ -70,7 +98,9 @@ func getDefaultContentTypes() []string {
 	}
 }
 
-func Compress(level uint8, types ...string) func(next http.Handler) http.Handler {
+// Compress encodes responses of the given types with encoding negotiated by Accept-Encoding.
+// Responses shorter than minSize bytes are sent as is
+func Compress(level uint8, minSize uint64, types ...string) func(next http.Handler) http.Handler {
 	if len(types) == 0 {
 		types = getDefaultContentTypes()
 	}
@@ -78,6 +108,9 @@ func Compress(level uint8, types ...string) func(next http.Handler) http.Handler
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			// response depends on Accept-Encoding even if it is not compressed
+			writer.Header().Add("Vary", "Accept-Encoding")
+
 			encoder, encoding, restore := encoderPool.getEncoder(request.Header, writer)
 			if encoder == nil {
 				next.ServeHTTP(writer, request)
@@ -89,10 +122,12 @@ func Compress(level uint8, types ...string) func(next http.Handler) http.Handler
 				encoder:        encoder,
 				encoding:       encoding,
 				supportedTypes: types,
+				minSize:        int(minSize),
 			}
 
-			defer compressedWriter.Close()
+			// encoder is returned to the pool after the buffered response is written
 			defer restore()
+			defer compressedWriter.Close()
 
 			next.ServeHTTP(compressedWriter, request)
 		})
@@ -105,50 +140,79 @@ type resettableWriter interface {
 }
 
 func (encoderPool encoderPool) getEncoder(header http.Header, writer http.ResponseWriter) (io.Writer, string, func()) {
-	acceptEncoding := header.Get("Accept-Encoding")
-	acceptedEncodings := strings.Split(strings.ToLower(acceptEncoding), ",")
-	for _, encoding := range encoderPool.order {
-		for _, acceptedEncoding := range acceptedEncodings {
-			if strings.HasPrefix(strings.TrimLeft(acceptedEncoding, " "), encoding) {
-				pool := encoderPool.pool[encoding]
-				encoder := pool.Get().(resettableWriter)
-				restore := func() {
-					pool.Put(encoder)
-				}
-				encoder.Reset(writer)
-
-				return encoder, encoding, restore
-			}
-		}
+	encoding := negotiateEncoding(header.Values("Accept-Encoding"), encoderPool.order)
+	if encoding == "" {
+		return nil, "", nil
+	}
+
+	pool := encoderPool.pool[encoding]
+	encoder, ok := pool.Get().(resettableWriter)
+	if !ok {
+		return nil, "", nil
+	}
+	restore := func() {
+		pool.Put(encoder)
 	}
+	encoder.Reset(writer)
 
-	return nil, "", nil
+	return encoder, encoding, restore
 }
 
+// WriteHeader postpones the status until minSize bytes are written,
+// so short response can be sent without compression
 func (writer *compressedResponseWriter) WriteHeader(code int) {
 	if writer.wroteHeader {
-		writer.ResponseWriter.WriteHeader(code)
+		// superfluous call is dropped as net/http would do
 		return
 	}
 
-	defer writer.ResponseWriter.WriteHeader(code)
 	writer.wroteHeader = true
-	writer.writer = writer.ResponseWriter
+	writer.status = code
 
-	if writer.Header().Get("Content-Encoding") != "" {
+	if !writer.compressible() {
+		writer.commit(false)
 		return
 	}
+	if writer.minSize == 0 {
+		writer.commit(true)
+	}
+}
+
+func (writer *compressedResponseWriter) compressible() bool {
+	if writer.Header().Get("Content-Encoding") != "" {
+		return false
+	}
 
 	contentType := writer.Header().Get("Content-Type")
 	contentType, _, _ = strings.Cut(contentType, ";")
-	if !slices.Contains(writer.supportedTypes, contentType) {
-		return
+
+	return slices.Contains(writer.supportedTypes, contentType)
+}
+
+func (writer *compressedResponseWriter) commit(compress bool) {
+	writer.committed = true
+	writer.writer = writer.ResponseWriter
+
+	if compress {
+		writer.writer = writer.encoder
+		writer.Header().Set("Content-Encoding", writer.encoding)
+		writer.Header().Del("Content-Length")
+	}
+
+	writer.ResponseWriter.WriteHeader(writer.status)
+}
+
+// writeBuffer commits the response and writes buffered bytes
+func (writer *compressedResponseWriter) writeBuffer(compress bool) error {
+	writer.commit(compress)
+	buffer := writer.buffer
+	writer.buffer = nil
+	if len(buffer) == 0 {
+		return nil
 	}
 
-	writer.writer = writer.encoder
-	writer.Header().Set("Content-Encoding", writer.encoding)
-	writer.Header().Add("Vary", "Accept-Encoding")
-	writer.Header().Del("Content-Length")
+	_, err := writer.writer.Write(buffer)
+	return err
 }
 
 func (writer *compressedResponseWriter) Write(p []byte) (int, error) {
@@ -156,14 +220,30 @@ func (writer *compressedResponseWriter) Write(p []byte) (int, error) {
 		writer.WriteHeader(http.StatusOK)
 	}
 
-	return writer.writer.Write(p)
+	if writer.committed {
+		return writer.writer.Write(p)
+	}
+
+	writer.buffer = append(writer.buffer, p...)
+	if len(writer.buffer) >= writer.minSize {
+		if err := writer.writeBuffer(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
 }
 
 type compressFlusher interface {
 	Flush() error
 }
 
+// Flush compresses buffered bytes, because the size of the flushed response is unknown
 func (writer *compressedResponseWriter) Flush() {
+	if writer.wroteHeader && !writer.committed {
+		_ = writer.writeBuffer(true)
+	}
+
 	if flusher, ok := writer.writer.(compressFlusher); ok {
 		_ = flusher.Flush()
 	}
@@ -188,7 +268,18 @@ func (writer *compressedResponseWriter) Push(target string, opts *http.PushOptio
 }
 
 func (writer *compressedResponseWriter) Close() error {
-	if closer, ok := writer.writer.(io.WriteCloser); ok {
+	if writer.wroteHeader && !writer.committed {
+		// response is shorter than minSize
+		if err := writer.writeBuffer(false); err != nil {
+			return err
+		}
+	}
+
+	if writer.writer != writer.encoder {
+		// response is not compressed, so there is no encoder to finish
+		return nil
+	}
+	if closer, ok := writer.encoder.(io.WriteCloser); ok {
 		return closer.Close()
 	}
 	return errors.New("chi/middleware/compress: io.WriteCloser is unavailable on the writer")
@@ -10,8 +10,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/andybalholm/brotli"
 	"github.com/go-chi/chi/v5"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCompress(t *testing.T) {
@@ -64,6 +67,55 @@ func TestCompress(t *testing.T) {
 			acceptEncodings: []string{"deflate", "gzip"},
 			wantEncoding:    "gzip",
 		},
+		{
+			name:            "zstd encoding",
+			types:           []string{"text/html"},
+			contentType:     "text/html",
+			acceptEncodings: []string{"zstd"},
+			wantEncoding:    "zstd",
+		},
+		{
+			name:            "brotli encoding",
+			types:           []string{"text/html"},
+			contentType:     "text/html",
+			acceptEncodings: []string{"br"},
+			wantEncoding:    "br",
+		},
+		{
+			name:            "zstd preferred encoding",
+			types:           []string{"text/html"},
+			contentType:     "text/html",
+			acceptEncodings: []string{"gzip", "deflate", "br", "zstd"},
+			wantEncoding:    "zstd",
+		},
+		{
+			name:            "highest q-value",
+			types:           []string{"text/html"},
+			contentType:     "text/html",
+			acceptEncodings: []string{"zstd;q=0.1", "gzip;q=0.8", "br; q=0.5"},
+			wantEncoding:    "gzip",
+		},
+		{
+			name:            "wildcard",
+			types:           []string{"text/html"},
+			contentType:     "text/html",
+			acceptEncodings: []string{"zstd;q=0", "br;q=0", "*;q=0.5"},
+			wantEncoding:    "gzip",
+		},
+		{
+			name:            "not acceptable",
+			types:           []string{"text/html"},
+			contentType:     "text/html",
+			acceptEncodings: []string{"gzip;q=0", "identity"},
+			wantEncoding:    "",
+		},
+		{
+			name:            "invalid q-value",
+			types:           []string{"text/html"},
+			contentType:     "text/html",
+			acceptEncodings: []string{"zstd;q=abc", "deflate;q=0.3"},
+			wantEncoding:    "deflate",
+		},
 		{
 			name:            "multiple types",
 			types:           []string{"text/html", "text/plain"},
@@ -75,7 +127,7 @@ func TestCompress(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			router := chi.NewRouter()
-			router.Use(Compress(uint8(rand.UintN(3)+3), tt.types...))
+			router.Use(Compress(uint8(rand.UintN(3)+3), 0, tt.types...))
 			router.Get("/", func(writer http.ResponseWriter, request *http.Request) {
 				writer.Header().Set("Content-Type", tt.contentType)
 				writer.Write([]byte("Hello World!"))
@@ -95,11 +147,82 @@ func TestCompress(t *testing.T) {
 			}
 
 			assert.Equal(t, tt.wantEncoding, response.Header.Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", response.Header.Get("Vary"))
 			assert.Equal(t, "Hello World!", decodeResponseBody(t, response))
 		})
 	}
 }
 
+func TestCompressMinSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         []string
+		flush        bool
+		wantEncoding string
+	}{
+		{
+			name:         "short",
+			body:         []string{"Hello World!"},
+			wantEncoding: "",
+		},
+		{
+			name:         "empty",
+			body:         []string{},
+			wantEncoding: "",
+		},
+		{
+			name:         "long",
+			body:         []string{strings.Repeat("Hello World!", 100)},
+			wantEncoding: "gzip",
+		},
+		{
+			name:         "long by parts",
+			body:         []string{strings.Repeat("Hello", 10), strings.Repeat("World!", 10)},
+			wantEncoding: "gzip",
+		},
+		{
+			name:         "flushed",
+			body:         []string{"Hello", "World!"},
+			flush:        true,
+			wantEncoding: "gzip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			router.Use(Compress(5, 100, "text/plain"))
+			router.Get("/", func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", "text/plain")
+				writer.WriteHeader(http.StatusAccepted)
+				for _, part := range tt.body {
+					writer.Write([]byte(part))
+					if tt.flush {
+						writer.(http.Flusher).Flush()
+					}
+				}
+			})
+			httpServer := httptest.NewServer(router)
+			defer httpServer.Close()
+
+			request, err := http.NewRequest(http.MethodGet, httpServer.URL+"/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Accept-Encoding", "gzip")
+
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, http.StatusAccepted, response.StatusCode)
+			assert.Equal(t, tt.wantEncoding, response.Header.Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", response.Header.Get("Vary"))
+			assert.Equal(t, strings.Join(tt.body, ""), decodeResponseBody(t, response))
+		})
+	}
+}
+
 func decodeResponseBody(t *testing.T, response *http.Response) string {
 	t.Helper()
 	reader := response.Body
@@ -114,6 +237,14 @@ func decodeResponseBody(t *testing.T, response *http.Response) string {
 		}
 	case "deflate":
 		reader = flate.NewReader(response.Body)
+	case "zstd":
+		decoder, err := zstd.NewReader(response.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = decoder.IOReadCloser()
+	case "br":
+		reader = io.NopCloser(brotli.NewReader(response.Body))
 	}
 	defer reader.Close()
 
@@ -124,3 +255,66 @@ func decodeResponseBody(t *testing.T, response *http.Response) string {
 
 	return string(body)
 }
+
+func TestCompressedResponseWriter_WriteHeaderTwice(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := &compressedResponseWriter{
+		ResponseWriter: recorder,
+		encoder:        gzip.NewWriter(recorder),
+		encoding:       "gzip",
+		supportedTypes: []string{"text/plain"},
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+	writer.WriteHeader(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+}
+
+func TestCompressedResponseWriter_Close(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		minSize     int
+	}{
+		{
+			name: "nothing written",
+		},
+		{
+			name:        "not compressible",
+			contentType: "image/png",
+			body:        "png",
+		},
+		{
+			name:        "shorter than min size",
+			contentType: "text/plain",
+			body:        "short",
+			minSize:     100,
+		},
+		{
+			name:        "compressed",
+			contentType: "text/plain",
+			body:        "compressed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			writer := &compressedResponseWriter{
+				ResponseWriter: recorder,
+				encoder:        gzip.NewWriter(recorder),
+				encoding:       "gzip",
+				supportedTypes: []string{"text/plain"},
+				minSize:        tt.minSize,
+			}
+			if tt.contentType != "" {
+				writer.Header().Set("Content-Type", tt.contentType)
+				_, err := writer.Write([]byte(tt.body))
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, writer.Close())
+			assert.Equal(t, tt.body, decodeResponseBody(t, recorder.Result()))
+		})
+	}
+}
@@ -4,18 +4,56 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"sync"
 
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 	"golang.org/x/exp/maps"
 )
 
+const DefaultMaxCompressedSize = 1 << 20
+const DefaultMaxDecompressedSize = 4 << 20
+const DefaultMaxRatio = 100
+
+// ratioThreshold is decompressed size after which the ratio is checked,
+// so short and well compressed bodies are not rejected
+const ratioThreshold = 64 << 10
+
+// ErrBodyTooLarge is returned by the request body if a limit of DecompressConfig is exceeded
+var ErrBodyTooLarge = errors.New("request body is too large")
+
+var errUnsupportedEncoding = errors.New("unsupported encoding")
+
+type DecompressConfig struct {
+	// MaxCompressedSize is max size of the encoded body
+	MaxCompressedSize uint64
+	// MaxDecompressedSize is max size of the decoded body, also applies to the body without encoding
+	MaxDecompressedSize uint64
+	// MaxRatio is max ratio of the decoded body size to the encoded one
+	MaxRatio uint64
+}
+
 type decoderPool struct {
 	pool map[string]*sync.Pool
 }
 
+func prepareConfig(config *DecompressConfig) {
+	if config.MaxCompressedSize == 0 {
+		config.MaxCompressedSize = DefaultMaxCompressedSize
+	}
+	if config.MaxDecompressedSize == 0 {
+		config.MaxDecompressedSize = DefaultMaxDecompressedSize
+	}
+	if config.MaxRatio == 0 {
+		config.MaxRatio = DefaultMaxRatio
+	}
+}
+
 func newDecoderPool() *decoderPool {
 	return &decoderPool{
 		pool: map[string]*sync.Pool{
@@ -29,11 +67,29 @@ func newDecoderPool() *decoderPool {
 					return flate.NewReader(bytes.NewReader(nil))
 				},
 			},
+			"zstd": {
+				New: func() any {
+					decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+					if err != nil {
+						return nil
+					}
+
+					return &zstdDecoder{decoder}
+				},
+			},
+			"br": {
+				New: func() any {
+					return &brotliDecoder{brotli.NewReader(nil)}
+				},
+			},
 		},
 	}
 }
 
-func Decompress() func(next http.Handler) http.Handler {
+// Decompress decodes the request body by Content-Encoding. Reading of the body returns ErrBodyTooLarge
+// if a limit is exceeded, so a small encoded body can not be expanded to gigabytes
+func Decompress(config *DecompressConfig) func(next http.Handler) http.Handler {
+	prepareConfig(config)
 	decoderPool := newDecoderPool()
 
 	return func(next http.Handler) http.Handler {
@@ -41,50 +97,165 @@ func Decompress() func(next http.Handler) http.Handler {
 			writer.Header().Set("Accept-Encoding", strings.Join(maps.Keys(decoderPool.pool), ", "))
 			encoding := request.Header.Get("Content-Encoding")
 			if encoding == "" {
+				if request.Body != nil && request.Body != http.NoBody {
+					request.Body = &limitedBody{
+						Reader: newLimitedReader(request.Body, config.MaxDecompressedSize, request.ContentLength),
+						Closer: request.Body,
+					}
+				}
+
 				next.ServeHTTP(writer, request)
 				return
 			}
 
-			decoder, restore := decoderPool.getDecoder(encoding, request.Body)
-			if decoder == nil {
-				writer.WriteHeader(http.StatusUnsupportedMediaType)
+			compressed := newLimitedReader(request.Body, config.MaxCompressedSize, request.ContentLength)
+			decoder, restore, err := decoderPool.getDecoder(encoding, compressed)
+			if err != nil {
+				switch {
+				case errors.Is(compressed.err, ErrBodyTooLarge):
+					// some decoders read the header on reset, the error is left to the handler
+					request.Body = &limitedBody{Reader: compressed, Closer: request.Body}
+					next.ServeHTTP(writer, request)
+				case errors.Is(err, errUnsupportedEncoding):
+					writer.WriteHeader(http.StatusUnsupportedMediaType)
+				default:
+					// header of the encoded body is corrupted
+					writer.WriteHeader(http.StatusBadRequest)
+				}
+
 				return
 			}
 
-			defer decoder.Close()
+			// decoder is returned to the pool after it is closed, so it is not reset by another request
 			defer restore()
+			defer decoder.Close()
 
-			request.Body = decoder
+			request.Body = &limitedBody{
+				Reader: &ratioReader{
+					decompressed: newLimitedReader(decoder, config.MaxDecompressedSize, -1),
+					compressed:   compressed,
+					maxRatio:     config.MaxRatio,
+				},
+				Closer: decoder,
+			}
 			next.ServeHTTP(writer, request)
 		})
 	}
 }
 
-type gzipResetter interface {
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedReader returns ErrBodyTooLarge if more than limit bytes can be read
+type limitedReader struct {
+	reader io.Reader
+	limit  uint64
+	read   uint64
+	err    error
+}
+
+// newLimitedReader fails on the first read if contentLength is known and exceeds limit
+func newLimitedReader(reader io.Reader, limit uint64, contentLength int64) *limitedReader {
+	limitedReader := &limitedReader{
+		reader: reader,
+		limit:  limit,
+	}
+	if contentLength > 0 && uint64(contentLength) > limit {
+		limitedReader.err = fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, limit)
+	}
+
+	return limitedReader
+}
+
+func (reader *limitedReader) Read(p []byte) (int, error) {
+	if reader.err != nil {
+		return 0, reader.err
+	}
+
+	// one more byte is read to distinguish the body of exactly limit bytes
+	if remaining := reader.limit - reader.read; remaining < uint64(len(p)) {
+		p = p[:remaining+1]
+	}
+
+	n, err := reader.reader.Read(p)
+	reader.read += uint64(n)
+	if reader.read > reader.limit {
+		n -= int(reader.read - reader.limit)
+		reader.read = reader.limit
+		reader.err = fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, reader.limit)
+
+		return n, reader.err
+	}
+
+	return n, err
+}
+
+// ratioReader returns ErrBodyTooLarge if the decompressed body is more than maxRatio times larger than the compressed one
+type ratioReader struct {
+	decompressed *limitedReader
+	compressed   *limitedReader
+	maxRatio     uint64
+}
+
+func (reader *ratioReader) Read(p []byte) (int, error) {
+	n, err := reader.decompressed.Read(p)
+	if reader.decompressed.read > ratioThreshold &&
+		reader.decompressed.read/max(reader.compressed.read, 1) > reader.maxRatio {
+		return n, fmt.Errorf("%w: compression ratio is more than %d", ErrBodyTooLarge, reader.maxRatio)
+	}
+
+	return n, err
+}
+
+type readerResetter interface {
 	Reset(r io.Reader) error
 }
 
-func (decoderPool decoderPool) getDecoder(encoding string, body io.ReadCloser) (io.ReadCloser, func()) {
+// zstdDecoder is not destroyed on Close, so it can be returned to the pool
+type zstdDecoder struct {
+	*zstd.Decoder
+}
+
+func (decoder *zstdDecoder) Close() error {
+	return decoder.Reset(nil)
+}
+
+type brotliDecoder struct {
+	*brotli.Reader
+}
+
+func (decoder *brotliDecoder) Close() error {
+	return nil
+}
+
+func (decoderPool decoderPool) getDecoder(encoding string, body io.Reader) (io.ReadCloser, func(), error) {
 	pool, ok := decoderPool.pool[encoding]
 	if !ok {
-		return nil, nil
+		return nil, nil, errUnsupportedEncoding
 	}
 
 	decoder := pool.Get()
+	if decoder == nil {
+		return nil, nil, errUnsupportedEncoding
+	}
 	restore := func() {
 		pool.Put(decoder)
 	}
 
+	var err error
 	switch encoding {
-	case "gzip":
-		if err := decoder.(gzipResetter).Reset(body); err != nil {
-			return nil, nil
-		}
+	case "gzip", "zstd", "br":
+		err = decoder.(readerResetter).Reset(body)
 	case "deflate":
-		if err := decoder.(flate.Resetter).Reset(body, nil); err != nil {
-			return nil, nil
-		}
+		err = decoder.(flate.Resetter).Reset(body, nil)
+	}
+	if err != nil {
+		// decoder is reset again before the next use
+		restore()
+		return nil, nil, err
 	}
 
-	return decoder.(io.ReadCloser), restore
+	return decoder.(io.ReadCloser), restore, nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"errors"
 	"io"
 	"math/rand/v2"
 	"net/http"
@@ -11,14 +12,18 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/andybalholm/brotli"
 	"github.com/go-chi/chi/v5"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecompress(t *testing.T) {
 	tests := []struct {
 		name            string
 		contentEncoding string
+		corrupted       bool
 		wantStatusCode  int
 	}{
 		{
@@ -36,6 +41,22 @@ func TestDecompress(t *testing.T) {
 			contentEncoding: "deflate",
 			wantStatusCode:  http.StatusOK,
 		},
+		{
+			name:            "zstd encoding",
+			contentEncoding: "zstd",
+			wantStatusCode:  http.StatusOK,
+		},
+		{
+			name:            "brotli encoding",
+			contentEncoding: "br",
+			wantStatusCode:  http.StatusOK,
+		},
+		{
+			name:            "corrupted body",
+			contentEncoding: "gzip",
+			corrupted:       true,
+			wantStatusCode:  http.StatusBadRequest,
+		},
 		{
 			name:            "unknown encoding",
 			contentEncoding: "lz4",
@@ -45,7 +66,7 @@ func TestDecompress(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			router := chi.NewRouter()
-			router.Use(Decompress())
+			router.Use(Decompress(&DecompressConfig{}))
 			router.Get("/", func(writer http.ResponseWriter, request *http.Request) {
 				bytes, err := io.ReadAll(request.Body)
 				if err != nil {
@@ -57,7 +78,11 @@ func TestDecompress(t *testing.T) {
 			httpServer := httptest.NewServer(router)
 			defer httpServer.Close()
 
-			request, err := http.NewRequest(http.MethodGet, httpServer.URL+"/", getBody(t, tt.contentEncoding))
+			body := getBody(t, tt.contentEncoding)
+			if tt.corrupted {
+				body = strings.NewReader("Hello World!")
+			}
+			request, err := http.NewRequest(http.MethodGet, httpServer.URL+"/", body)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -72,6 +97,8 @@ func TestDecompress(t *testing.T) {
 			encodings := strings.Split(response.Header.Get("Accept-Encoding"), ", ")
 			assert.Contains(t, encodings, "gzip")
 			assert.Contains(t, encodings, "deflate")
+			assert.Contains(t, encodings, "zstd")
+			assert.Contains(t, encodings, "br")
 			assert.Equal(t, tt.wantStatusCode, response.StatusCode)
 
 			if tt.wantStatusCode == http.StatusOK {
@@ -88,8 +115,101 @@ func TestDecompress(t *testing.T) {
 	}
 }
 
+func TestDecompressLimits(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+		config          *DecompressConfig
+		wantStatusCode  int
+	}{
+		{
+			name:           "not compressed",
+			body:           bytes.Repeat([]byte("a"), 100),
+			config:         &DecompressConfig{MaxDecompressedSize: 100},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "not compressed too large",
+			body:           bytes.Repeat([]byte("a"), 101),
+			config:         &DecompressConfig{MaxDecompressedSize: 100},
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "compressed",
+			contentEncoding: "gzip",
+			body:            bytes.Repeat([]byte("a"), 1000),
+			config:          &DecompressConfig{MaxCompressedSize: 100, MaxDecompressedSize: 1000},
+			wantStatusCode:  http.StatusOK,
+		},
+		{
+			name:            "compressed too large",
+			contentEncoding: "gzip",
+			body:            randomBytes(1000),
+			config:          &DecompressConfig{MaxCompressedSize: 500},
+			wantStatusCode:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "decompressed too large",
+			contentEncoding: "zstd",
+			body:            bytes.Repeat([]byte("a"), 1001),
+			config:          &DecompressConfig{MaxDecompressedSize: 1000},
+			wantStatusCode:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "ratio exceeded",
+			contentEncoding: "br",
+			body:            bytes.Repeat([]byte("a"), 1<<20),
+			config:          &DecompressConfig{MaxDecompressedSize: 1 << 30, MaxRatio: 100},
+			wantStatusCode:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "ratio below threshold",
+			contentEncoding: "deflate",
+			body:            bytes.Repeat([]byte("a"), ratioThreshold),
+			config:          &DecompressConfig{MaxRatio: 2},
+			wantStatusCode:  http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Decompress(tt.config)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				body, err := io.ReadAll(request.Body)
+				if errors.Is(err, ErrBodyTooLarge) {
+					writer.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.body, body)
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, "/", encodeBody(t, tt.contentEncoding, tt.body))
+			request.Header.Set("Content-Encoding", tt.contentEncoding)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.wantStatusCode, recorder.Code)
+		})
+	}
+}
+
+func randomBytes(size int) []byte {
+	body := make([]byte, size)
+	for i := range body {
+		body[i] = byte(rand.UintN(256))
+	}
+
+	return body
+}
+
 func getBody(t *testing.T, encoding string) io.Reader {
 	t.Helper()
+
+	return encodeBody(t, encoding, []byte("Hello World!"))
+}
+
+func encodeBody(t *testing.T, encoding string, body []byte) io.Reader {
+	t.Helper()
 	var buffer io.Writer = bytes.NewBuffer(nil)
 	writer := buffer
 
@@ -107,9 +227,17 @@ func getBody(t *testing.T, encoding string) io.Reader {
 		if err != nil {
 			t.Fatal(err)
 		}
+	case "zstd":
+		var err error
+		writer, err = zstd.NewWriter(buffer, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "br":
+		writer = brotli.NewWriterLevel(buffer, level)
 	}
 
-	if _, err := writer.Write([]byte("Hello World!")); err != nil {
+	if _, err := writer.Write(body); err != nil {
 		t.Fatal(err)
 	}
 
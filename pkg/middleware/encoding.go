@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+)
+
+// negotiateEncoding chooses encoding with the highest q-value of Accept-Encoding,
+// encodings with equal q-value are chosen by order. Empty string means no encoding
+func negotiateEncoding(acceptEncodings []string, order []string) string {
+	qualities := parseAcceptEncoding(acceptEncodings)
+	wildcard, hasWildcard := qualities["*"]
+
+	chosen := ""
+	chosenQuality := 0.0
+	for _, encoding := range order {
+		quality, ok := qualities[encoding]
+		if !ok {
+			if !hasWildcard {
+				continue
+			}
+			quality = wildcard
+		}
+
+		if quality > chosenQuality {
+			chosen, chosenQuality = encoding, quality
+		}
+	}
+
+	return chosen
+}
+
+// parseAcceptEncoding returns q-values of encodings, invalid values are skipped
+func parseAcceptEncoding(acceptEncodings []string) map[string]float64 {
+	qualities := make(map[string]float64)
+	for _, acceptEncoding := range acceptEncodings {
+		for _, item := range strings.Split(acceptEncoding, ",") {
+			encoding, params, _ := strings.Cut(item, ";")
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding == "" {
+				continue
+			}
+
+			quality, ok := parseQuality(params)
+			if !ok {
+				continue
+			}
+			qualities[encoding] = quality
+		}
+	}
+
+	return qualities
+}
+
+func parseQuality(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		if strings.ToLower(strings.TrimSpace(name)) != "q" {
+			continue
+		}
+
+		quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || quality < 0 || quality > 1 {
+			return 0, false
+		}
+
+		return quality, true
+	}
+
+	return 1, true
+}
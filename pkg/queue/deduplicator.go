@@ -0,0 +1,52 @@
+package queue
+
+import "sync"
+
+// Deduplicator tracks keys of items which are pending in the queues or in flight.
+// Key is tracked from the push until Ack, so it may be shared by several queues, e.g. priority lanes
+type Deduplicator[K comparable] struct {
+	mutex sync.Mutex
+	keys  map[K]struct{}
+}
+
+func NewDeduplicator[K comparable]() *Deduplicator[K] {
+	return &Deduplicator[K]{
+		keys: make(map[K]struct{}),
+	}
+}
+
+// reserve reports whether the key was not tracked yet
+func (deduplicator *Deduplicator[K]) reserve(key K) bool {
+	deduplicator.mutex.Lock()
+	defer deduplicator.mutex.Unlock()
+
+	if _, ok := deduplicator.keys[key]; ok {
+		return false
+	}
+	deduplicator.keys[key] = struct{}{}
+
+	return true
+}
+
+func (deduplicator *Deduplicator[K]) Release(key K) {
+	deduplicator.mutex.Lock()
+	defer deduplicator.mutex.Unlock()
+
+	delete(deduplicator.keys, key)
+}
+
+func (deduplicator *Deduplicator[K]) Has(key K) bool {
+	deduplicator.mutex.Lock()
+	defer deduplicator.mutex.Unlock()
+
+	_, ok := deduplicator.keys[key]
+
+	return ok
+}
+
+func (deduplicator *Deduplicator[K]) Count() uint64 {
+	deduplicator.mutex.Lock()
+	defer deduplicator.mutex.Unlock()
+
+	return uint64(len(deduplicator.keys))
+}
@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func identity(item uint64) uint64 {
+	return item
+}
+
+func TestQueue_Deduplication(t *testing.T) {
+	deduplicator := NewDeduplicator[uint64]()
+	fresh := New(3, WithDeduplicator(deduplicator, identity))
+	retry := New(3, WithDeduplicator(deduplicator, identity))
+
+	fresh.Push(1)
+	require.NoError(t, fresh.TryPush(1))
+	require.NoError(t, retry.PushContext(context.Background(), 1))
+	retry.PushBatch([]uint64{1, 2})
+	assert.EqualValues(t, 1, fresh.Count())
+	assert.EqualValues(t, 1, retry.Count())
+	assert.EqualValues(t, 2, deduplicator.Count())
+
+	item, ok := fresh.Pop()
+	require.True(t, ok)
+	assert.EqualValues(t, 1, item)
+
+	// in flight until ack
+	retry.Push(1)
+	assert.EqualValues(t, 1, retry.Count())
+	assert.True(t, deduplicator.Has(1))
+
+	retry.Ack(1)
+	assert.False(t, deduplicator.Has(1))
+	retry.Push(1)
+	assert.EqualValues(t, 2, retry.Count())
+}
+
+func TestQueue_DeduplicationReleaseNotPushed(t *testing.T) {
+	tests := []struct {
+		name string
+		push func(queue *Queue[uint64]) error
+	}{
+		{
+			name: "try push to full queue",
+			push: func(queue *Queue[uint64]) error {
+				return queue.TryPush(2)
+			},
+		},
+		{
+			name: "push with canceled context",
+			push: func(queue *Queue[uint64]) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return queue.PushContext(ctx, 2)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deduplicator := NewDeduplicator[uint64]()
+			queue := New(1, WithDeduplicator(deduplicator, identity))
+			queue.Push(1)
+
+			require.Error(t, tt.push(queue))
+			assert.False(t, deduplicator.Has(2))
+		})
+	}
+}
+
+func TestQueue_DeduplicationDropOldest(t *testing.T) {
+	deduplicator := NewDeduplicator[uint64]()
+	queue := New(1, WithDeduplicator(deduplicator, identity), WithOverflowPolicy(DropOldest[uint64](nil)))
+	queue.Push(1)
+
+	require.NoError(t, queue.TryPush(2))
+	assert.False(t, deduplicator.Has(1))
+	assert.True(t, deduplicator.Has(2))
+}
+
+func TestQueue_DeduplicationDelayed(t *testing.T) {
+	deduplicator := NewDeduplicator[uint64]()
+	queue := New(2, WithDeduplicator(deduplicator, identity))
+	queue.Push(1)
+	queue.PushDelayed(context.Background(), 1, time.Millisecond*10)
+	queue.PushDelayed(context.Background(), 1, time.Millisecond*20)
+	assert.EqualValues(t, 1, queue.DelayedCount())
+
+	time.Sleep(time.Millisecond * 50)
+	assert.EqualValues(t, 0, queue.DelayedCount())
+	assert.EqualValues(t, 1, queue.Count())
+}
@@ -0,0 +1,231 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type delayedItem[T any] struct {
+	ctx   context.Context
+	item  T
+	key   any
+	due   time.Time
+	index int
+}
+
+type delayedHeap[T any] []*delayedItem[T]
+
+func (items delayedHeap[T]) Len() int {
+	return len(items)
+}
+
+func (items delayedHeap[T]) Less(i, j int) bool {
+	return items[i].due.Before(items[j].due)
+}
+
+func (items delayedHeap[T]) Swap(i, j int) {
+	items[i], items[j] = items[j], items[i]
+	items[i].index = i
+	items[j].index = j
+}
+
+func (items *delayedHeap[T]) Push(item any) {
+	delayed := item.(*delayedItem[T])
+	delayed.index = len(*items)
+	*items = append(*items, delayed)
+}
+
+func (items *delayedHeap[T]) Pop() any {
+	old := *items
+	last := len(old) - 1
+	delayed := old[last]
+	old[last] = nil
+	delayed.index = -1
+	*items = old[:last]
+
+	return delayed
+}
+
+// fullRetryDelay is the pause of the scheduler after the due item can`t be moved to the full queue
+const fullRetryDelay = time.Millisecond * 10
+
+// delayed holds items until they are due and moves them to the queue.
+// All items share a single timer and a scheduler goroutine, which exits when no items are left.
+// push must not block: if it returns ErrFull, the item is kept due and retried after fullRetryDelay
+type delayed[T any] struct {
+	mutex   sync.Mutex
+	items   delayedHeap[T]
+	keys    map[any]*delayedItem[T]
+	key     func(item T) any
+	push    func(ctx context.Context, item T) error
+	wake    chan struct{}
+	running bool
+	now     func() time.Time
+}
+
+func newDelayed[T any](push func(ctx context.Context, item T) error) *delayed[T] {
+	return &delayed[T]{
+		keys: make(map[any]*delayedItem[T]),
+		push: push,
+		wake: make(chan struct{}, 1),
+		now:  time.Now,
+	}
+}
+
+// schedule adds the item or, if the item with the same key is already scheduled, moves it to the earlier due time
+func (delayed *delayed[T]) schedule(ctx context.Context, item T, delay time.Duration) {
+	delayed.mutex.Lock()
+	defer delayed.mutex.Unlock()
+
+	due := delayed.now().Add(delay)
+	var key any
+	if delayed.key != nil {
+		key = delayed.key(item)
+		if scheduled, ok := delayed.keys[key]; ok {
+			if due.Before(scheduled.due) {
+				scheduled.ctx, scheduled.item, scheduled.due = ctx, item, due
+				heap.Fix(&delayed.items, scheduled.index)
+				delayed.notify()
+			}
+
+			return
+		}
+	}
+
+	scheduled := &delayedItem[T]{ctx: ctx, item: item, key: key, due: due}
+	delayed.add(scheduled)
+
+	if !delayed.running {
+		delayed.running = true
+		go delayed.run()
+	} else if scheduled.index == 0 {
+		delayed.notify()
+	}
+}
+
+func (delayed *delayed[T]) cancel(key any) bool {
+	delayed.mutex.Lock()
+	defer delayed.mutex.Unlock()
+
+	scheduled, ok := delayed.keys[key]
+	if !ok {
+		return false
+	}
+
+	heap.Remove(&delayed.items, scheduled.index)
+	delete(delayed.keys, key)
+	delayed.notify()
+
+	return true
+}
+
+func (delayed *delayed[T]) scheduled(key any) bool {
+	delayed.mutex.Lock()
+	defer delayed.mutex.Unlock()
+
+	_, ok := delayed.keys[key]
+
+	return ok
+}
+
+func (delayed *delayed[T]) next() (time.Time, bool) {
+	delayed.mutex.Lock()
+	defer delayed.mutex.Unlock()
+
+	if len(delayed.items) == 0 {
+		return time.Time{}, false
+	}
+
+	return delayed.items[0].due, true
+}
+
+func (delayed *delayed[T]) count() uint64 {
+	delayed.mutex.Lock()
+	defer delayed.mutex.Unlock()
+
+	return uint64(len(delayed.items))
+}
+
+// add must be called with the mutex held
+func (delayed *delayed[T]) add(scheduled *delayedItem[T]) {
+	heap.Push(&delayed.items, scheduled)
+	if delayed.key != nil {
+		delayed.keys[scheduled.key] = scheduled
+	}
+}
+
+// restore returns the due item which can`t be pushed, unless the item with the same key is scheduled meanwhile.
+// The scheduled one is moved to the earlier due time
+func (delayed *delayed[T]) restore(head *delayedItem[T]) {
+	delayed.mutex.Lock()
+	defer delayed.mutex.Unlock()
+
+	if delayed.key != nil {
+		if scheduled, ok := delayed.keys[head.key]; ok {
+			if head.due.Before(scheduled.due) {
+				scheduled.due = head.due
+				heap.Fix(&delayed.items, scheduled.index)
+			}
+
+			return
+		}
+	}
+
+	delayed.add(head)
+}
+
+// notify must be called with the mutex held
+func (delayed *delayed[T]) notify() {
+	select {
+	case delayed.wake <- struct{}{}:
+	default:
+		// scheduler is already notified
+	}
+}
+
+func (delayed *delayed[T]) run() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for {
+		delayed.mutex.Lock()
+		if len(delayed.items) == 0 {
+			delayed.running = false
+			delayed.mutex.Unlock()
+			return
+		}
+
+		head := delayed.items[0]
+		wait := head.due.Sub(delayed.now())
+		if wait <= 0 {
+			heap.Pop(&delayed.items)
+			if delayed.key != nil {
+				delete(delayed.keys, head.key)
+			}
+			delayed.mutex.Unlock()
+
+			// push is cancelled if context is cancelled
+			if head.ctx.Err() != nil || !errors.Is(delayed.push(head.ctx, head.item), ErrFull) {
+				continue
+			}
+
+			delayed.restore(head)
+			wait = fullRetryDelay
+		} else {
+			delayed.mutex.Unlock()
+		}
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-delayed.wake:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		}
+	}
+}
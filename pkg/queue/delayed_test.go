@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue_PushDelayed(t *testing.T) {
+	queue := New[int](3)
+	ctx := context.Background()
+	queue.PushDelayed(ctx, 3, time.Millisecond*60)
+	queue.PushDelayed(ctx, 1, time.Millisecond*20)
+	queue.PushDelayed(ctx, 2, time.Millisecond*40)
+	assert.EqualValues(t, 3, queue.DelayedCount())
+	assert.EqualValues(t, 0, queue.Count())
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	for _, expected := range []int{1, 2, 3} {
+		item, err := queue.PopWait(waitCtx)
+		require.NoError(t, err)
+		assert.Equal(t, expected, item)
+	}
+	assert.EqualValues(t, 0, queue.DelayedCount())
+}
+
+func TestQueue_PushDelayedFull(t *testing.T) {
+	queue := New[int](1, WithKey(func(item int) int {
+		return item
+	}))
+	queue.Push(1)
+	queue.PushDelayed(context.Background(), 2, time.Millisecond)
+	queue.PushDelayed(context.Background(), 3, time.Millisecond*2)
+
+	// due items are kept scheduled while the queue is full
+	time.Sleep(time.Millisecond * 30)
+	assert.EqualValues(t, 2, queue.DelayedCount())
+	require.True(t, queue.CancelDelayed(3))
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, expected := range []int{1, 2} {
+		item, err := queue.PopWait(waitCtx)
+		require.NoError(t, err)
+		assert.Equal(t, expected, item)
+	}
+	assert.EqualValues(t, 0, queue.DelayedCount())
+}
+
+func TestQueue_PushDelayedNoDelay(t *testing.T) {
+	queue := New[int](1)
+	queue.PushDelayed(context.Background(), 1, 0)
+
+	item, ok := queue.Pop()
+	require.True(t, ok)
+	assert.Equal(t, 1, item)
+}
+
+func TestQueue_PushDelayedDeduplication(t *testing.T) {
+	tests := []struct {
+		name   string
+		delays []time.Duration
+		want   time.Duration
+	}{
+		{
+			name:   "earlier replaces later",
+			delays: []time.Duration{time.Hour * 2, time.Hour},
+			want:   time.Hour,
+		},
+		{
+			name:   "later is ignored",
+			delays: []time.Duration{time.Hour, time.Hour * 2},
+			want:   time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := New[uint64](1, WithKey(func(item uint64) uint64 {
+				return item
+			}))
+			now := time.Now()
+			queue.delayed.now = func() time.Time {
+				return now
+			}
+
+			for _, delay := range tt.delays {
+				queue.PushDelayed(context.Background(), 1, delay)
+			}
+
+			assert.EqualValues(t, 1, queue.DelayedCount())
+			due, ok := queue.NextDue()
+			require.True(t, ok)
+			assert.Equal(t, now.Add(tt.want), due)
+			require.True(t, queue.CancelDelayed(uint64(1)))
+		})
+	}
+}
+
+func TestQueue_CancelDelayed(t *testing.T) {
+	queue := New[uint64](2, WithKey(func(item uint64) uint64 {
+		return item
+	}))
+	ctx := context.Background()
+	queue.PushDelayed(ctx, 1, time.Millisecond*20)
+	queue.PushDelayed(ctx, 2, time.Millisecond*40)
+
+	assert.True(t, queue.Scheduled(uint64(1)))
+	assert.True(t, queue.CancelDelayed(uint64(1)))
+	assert.False(t, queue.Scheduled(uint64(1)))
+	assert.False(t, queue.CancelDelayed(uint64(1)))
+	assert.False(t, queue.CancelDelayed(uint64(3)))
+	assert.EqualValues(t, 1, queue.DelayedCount())
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	item, err := queue.PopWait(waitCtx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, item)
+
+	_, ok := queue.NextDue()
+	assert.False(t, ok)
+}
+
+func TestQueue_PushDelayedImmediateCancelsScheduled(t *testing.T) {
+	queue := New[uint64](2, WithKey(func(item uint64) uint64 {
+		return item
+	}))
+	ctx := context.Background()
+	queue.PushDelayed(ctx, 1, time.Hour)
+	queue.PushDelayed(ctx, 1, 0)
+
+	assert.EqualValues(t, 0, queue.DelayedCount())
+	assert.EqualValues(t, 1, queue.Count())
+}
+
+func TestQueue_PushDelayedCanceled(t *testing.T) {
+	queue := New[int](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	queue.PushDelayed(ctx, 1, time.Millisecond*10)
+	cancel()
+
+	time.Sleep(time.Millisecond * 50)
+	assert.EqualValues(t, 0, queue.Count())
+	assert.EqualValues(t, 0, queue.DelayedCount())
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrLeaseReleased = errors.New("lease is already acked, nacked or expired")
+
+// Lease is an item received from the queue. The item is in flight until Ack or Nack,
+// if the queue has visibility timeout, not released item is returned to the queue after it
+type Lease[T any] struct {
+	queue *Queue[T]
+	item  T
+	// mutex is held while expired item is returned, so Ack can not see the lease released before it is returned
+	mutex    sync.Mutex
+	released bool
+}
+
+func (lease *Lease[T]) Item() T {
+	return lease.item
+}
+
+// Ack completes the item, its key is released if the queue has deduplication
+func (lease *Lease[T]) Ack() error {
+	if !lease.release() {
+		return ErrLeaseReleased
+	}
+
+	lease.queue.leases.cancel(lease)
+	lease.queue.release(lease.item)
+
+	return nil
+}
+
+// Nack returns the item to the queue after delay. Nack does not block and the item is not lost:
+// if the queue is full, the item waits among delayed items until the queue has space
+func (lease *Lease[T]) Nack(delay time.Duration) error {
+	if !lease.release() {
+		return ErrLeaseReleased
+	}
+
+	lease.queue.leases.cancel(lease)
+	lease.queue.release(lease.item)
+	if delay <= 0 && !errors.Is(lease.queue.pushDue(context.Background(), lease.item), ErrFull) {
+		return nil
+	}
+
+	lease.queue.delayed.schedule(context.Background(), lease.item, delay)
+
+	return nil
+}
+
+func (lease *Lease[T]) release() bool {
+	lease.mutex.Lock()
+	defer lease.mutex.Unlock()
+
+	if lease.released {
+		return false
+	}
+	lease.released = true
+
+	return true
+}
+
+// expire returns the item to the queue if the lease is not released within visibility timeout.
+// The lease is released only once the item is returned, if the queue is full, the lease stays active
+// and expires again once the scheduler retries
+func (lease *Lease[T]) expire() error {
+	lease.mutex.Lock()
+	defer lease.mutex.Unlock()
+
+	if lease.released {
+		return nil
+	}
+
+	// key of the item is still tracked, so it is returned bypassing deduplication
+	select {
+	case lease.queue.items <- lease.item:
+		lease.released = true
+		return nil
+	default:
+		return ErrFull
+	}
+}
+
+// WithVisibilityTimeout sets time after which not released lease expires, leases do not expire by default
+func WithVisibilityTimeout[T any](timeout time.Duration) Option[T] {
+	return func(queue *Queue[T]) {
+		queue.visibilityTimeout = timeout
+	}
+}
+
+func (queue *Queue[T]) lease(item T) *Lease[T] {
+	lease := &Lease[T]{queue: queue, item: item}
+	if queue.visibilityTimeout > 0 {
+		queue.leases.schedule(context.Background(), lease, queue.visibilityTimeout)
+	}
+
+	return lease
+}
+
+// Receive blocks until item is available or ctx is done
+func (queue *Queue[T]) Receive(ctx context.Context) (*Lease[T], error) {
+	item, err := queue.PopWait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queue.lease(item), nil
+}
+
+// ReceiveBatch blocks until at least one item is available or ctx is done.
+// After the first item it waits up to linger for the batch to be filled up to count items
+func (queue *Queue[T]) ReceiveBatch(ctx context.Context, count uint64, linger time.Duration) ([]*Lease[T], error) {
+	items, err := queue.PopBatchWait(ctx, count, linger)
+	if err != nil {
+		return nil, err
+	}
+
+	return queue.leaseBatch(items), nil
+}
+
+// TryReceiveBatch returns up to count available items without blocking
+func (queue *Queue[T]) TryReceiveBatch(count uint64) []*Lease[T] {
+	return queue.leaseBatch(queue.PopBatch(count))
+}
+
+func (queue *Queue[T]) leaseBatch(items []T) []*Lease[T] {
+	leases := make([]*Lease[T], 0, len(items))
+	for _, item := range items {
+		leases = append(leases, queue.lease(item))
+	}
+
+	return leases
+}
+
+// InFlightCount returns count of leases which expire if not released
+func (queue *Queue[T]) InFlightCount() uint64 {
+	return queue.leases.count()
+}
@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLease(t *testing.T) {
+	tests := []struct {
+		name    string
+		release func(lease *Lease[uint64]) error
+		count   uint64
+		delayed uint64
+		tracked bool
+	}{
+		{
+			name: "ack",
+			release: func(lease *Lease[uint64]) error {
+				return lease.Ack()
+			},
+		},
+		{
+			name: "nack",
+			release: func(lease *Lease[uint64]) error {
+				return lease.Nack(0)
+			},
+			count:   1,
+			tracked: true,
+		},
+		{
+			name: "nack delayed",
+			release: func(lease *Lease[uint64]) error {
+				return lease.Nack(time.Hour)
+			},
+			delayed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deduplicator := NewDeduplicator[uint64]()
+			queue := New(1, WithDeduplicator(deduplicator, identity), WithVisibilityTimeout[uint64](time.Hour))
+			queue.Push(1)
+
+			lease, err := queue.Receive(context.Background())
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, lease.Item())
+			assert.EqualValues(t, 1, queue.InFlightCount())
+			assert.True(t, deduplicator.Has(1))
+
+			require.NoError(t, tt.release(lease))
+			require.ErrorIs(t, lease.Ack(), ErrLeaseReleased)
+			require.ErrorIs(t, lease.Nack(0), ErrLeaseReleased)
+			assert.EqualValues(t, 0, queue.InFlightCount())
+			assert.Equal(t, tt.count, queue.Count())
+			assert.Equal(t, tt.delayed, queue.DelayedCount())
+			assert.Equal(t, tt.tracked, deduplicator.Has(1))
+			queue.CancelDelayed(uint64(1))
+		})
+	}
+}
+
+func TestLease_VisibilityTimeout(t *testing.T) {
+	queue := New(2, WithVisibilityTimeout[int](time.Millisecond*20))
+	queue.PushBatch([]int{1, 2})
+
+	leases, err := queue.ReceiveBatch(context.Background(), 2, 0)
+	require.NoError(t, err)
+	require.Len(t, leases, 2)
+	require.NoError(t, leases[0].Ack())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	lease, err := queue.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, lease.Item())
+	require.ErrorIs(t, leases[1].Ack(), ErrLeaseReleased)
+	require.NoError(t, lease.Ack())
+}
+
+func TestLease_VisibilityTimeoutFull(t *testing.T) {
+	queue := New(1, WithVisibilityTimeout[int](time.Millisecond*10))
+	queue.Push(1)
+
+	lease, err := queue.Receive(context.Background())
+	require.NoError(t, err)
+	queue.Push(2)
+
+	// expired lease waits until the queue has space
+	time.Sleep(time.Millisecond * 40)
+	assert.EqualValues(t, 1, queue.Count())
+
+	item, ok := queue.Pop()
+	require.True(t, ok)
+	assert.Equal(t, 2, item)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	item, err = queue.PopWait(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, item)
+	require.ErrorIs(t, lease.Ack(), ErrLeaseReleased)
+}
+
+func TestLease_AckExpiredFull(t *testing.T) {
+	queue := New(1, WithVisibilityTimeout[int](time.Millisecond))
+	queue.Push(1)
+
+	lease, err := queue.Receive(context.Background())
+	require.NoError(t, err)
+	queue.Push(2)
+
+	// expiration keeps failing while the queue is full, so the lease is still active
+	time.Sleep(time.Millisecond * 30)
+	require.NoError(t, lease.Ack())
+
+	item, ok := queue.Pop()
+	require.True(t, ok)
+	assert.Equal(t, 2, item)
+	time.Sleep(time.Millisecond * 30)
+	assert.EqualValues(t, 0, queue.Count())
+	assert.EqualValues(t, 0, queue.InFlightCount())
+}
+
+func TestLease_NackFull(t *testing.T) {
+	queue := New[int](1)
+	queue.Push(1)
+
+	lease, err := queue.Receive(context.Background())
+	require.NoError(t, err)
+	queue.Push(2)
+
+	// item is kept until the queue has space
+	require.NoError(t, lease.Nack(0))
+	assert.EqualValues(t, 1, queue.DelayedCount())
+	item, ok := queue.Pop()
+	require.True(t, ok)
+	assert.Equal(t, 2, item)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	item, err = queue.PopWait(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, item)
+}
+
+func TestLease_NoVisibilityTimeout(t *testing.T) {
+	queue := New[int](1)
+	queue.Push(1)
+
+	leases := queue.TryReceiveBatch(2)
+	require.Len(t, leases, 1)
+	assert.EqualValues(t, 0, queue.InFlightCount())
+	assert.Empty(t, queue.TryReceiveBatch(1))
+	require.NoError(t, leases[0].Nack(0))
+	assert.EqualValues(t, 1, queue.Count())
+}
+
+func TestQueue_ReceiveCanceled(t *testing.T) {
+	queue := New[int](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := queue.Receive(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = queue.ReceiveBatch(ctx, 1, 0)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type Lane[T any] struct {
+	Queue *Queue[T]
+	// Weight is a share of pops the lane gets while other lanes are not empty, 0 is treated as 1
+	Weight uint64
+}
+
+// Priority pops items from several lanes by smooth weighted round-robin,
+// so every not empty lane makes progress in proportion to its weight.
+// Items are pushed to the lane queues directly
+type Priority[T any] struct {
+	mutex   sync.Mutex
+	lanes   []*Lane[T]
+	current []int64
+}
+
+// NewPriority copies lanes, so the caller`s lanes are not changed and later changes of them are not applied
+func NewPriority[T any](lanes ...*Lane[T]) *Priority[T] {
+	copied := make([]*Lane[T], 0, len(lanes))
+	for _, lane := range lanes {
+		weight := lane.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		copied = append(copied, &Lane[T]{Queue: lane.Queue, Weight: weight})
+	}
+
+	return &Priority[T]{
+		lanes:   copied,
+		current: make([]int64, len(lanes)),
+	}
+}
+
+// next chooses the lane for the next pop among not empty lanes
+func (priority *Priority[T]) next() (*Lane[T], bool) {
+	priority.mutex.Lock()
+	defer priority.mutex.Unlock()
+
+	total := int64(0)
+	chosen := -1
+	for i, lane := range priority.lanes {
+		if lane.Queue.Count() == 0 {
+			continue
+		}
+
+		priority.current[i] += int64(lane.Weight)
+		total += int64(lane.Weight)
+		if chosen == -1 || priority.current[i] > priority.current[chosen] {
+			chosen = i
+		}
+	}
+	if chosen == -1 {
+		return nil, false
+	}
+
+	priority.current[chosen] -= total
+
+	return priority.lanes[chosen], true
+}
+
+func (priority *Priority[T]) Pop() (T, bool) {
+	for {
+		lane, ok := priority.next()
+		if !ok {
+			return *new(T), false
+		}
+
+		// lane may be drained concurrently, the choice is made again then
+		if item, ok := lane.Queue.Pop(); ok {
+			return item, true
+		}
+	}
+}
+
+func (priority *Priority[T]) PopBatch(count uint64) []T {
+	items := make([]T, 0, count)
+	for uint64(len(items)) < count {
+		item, ok := priority.Pop()
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// PopWait blocks until item is available in any lane or ctx is done
+func (priority *Priority[T]) PopWait(ctx context.Context) (T, error) {
+	item, _, err := priority.wait(ctx, nil)
+
+	return item, err
+}
+
+// PopBatchWait blocks until at least one item is available or ctx is done.
+// After the first item it waits up to linger for the batch to be filled up to count items
+func (priority *Priority[T]) PopBatchWait(ctx context.Context, count uint64, linger time.Duration) ([]T, error) {
+	if count == 0 {
+		return []T{}, nil
+	}
+
+	item, err := priority.PopWait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0, count)
+	items = append(items, item)
+	items = append(items, priority.PopBatch(count-1)...)
+	if uint64(len(items)) == count || linger <= 0 {
+		return items, nil
+	}
+
+	timer := time.NewTimer(linger)
+	defer timer.Stop()
+
+	for uint64(len(items)) < count {
+		item, ok, err := priority.wait(ctx, timer.C)
+		if err != nil || !ok {
+			// items are already taken from the queue, so they are returned anyway
+			return items, nil
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// wait returns false if timeout is reached before any item is available
+func (priority *Priority[T]) wait(ctx context.Context, timeout <-chan time.Time) (T, bool, error) {
+	if item, ok := priority.Pop(); ok {
+		return item, true, nil
+	}
+
+	cases := make([]reflect.SelectCase, 0, len(priority.lanes)+2)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timeout)})
+	for _, lane := range priority.lanes {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(lane.Queue.items)})
+	}
+
+	// all lanes are empty, so the first available item is taken regardless of weights
+	chosen, value, _ := reflect.Select(cases)
+	switch chosen {
+	case 0:
+		return *new(T), false, context.Cause(ctx)
+	case 1:
+		return *new(T), false, nil
+	default:
+		item, _ := value.Interface().(T)
+
+		return item, true, nil
+	}
+}
+
+func (priority *Priority[T]) Count() uint64 {
+	count := uint64(0)
+	for _, lane := range priority.lanes {
+		count += lane.Queue.Count()
+	}
+
+	return count
+}
@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriority_PopBatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		fresh []string
+		retry []string
+		count uint64
+		want  []string
+	}{
+		{
+			name:  "weighted",
+			fresh: []string{"f1", "f2", "f3", "f4", "f5", "f6"},
+			retry: []string{"r1", "r2", "r3"},
+			count: 8,
+			want:  []string{"f1", "f2", "r1", "f3", "f4", "f5", "r2", "f6"},
+		},
+		{
+			name:  "only retries",
+			retry: []string{"r1", "r2"},
+			count: 3,
+			want:  []string{"r1", "r2"},
+		},
+		{
+			name:  "retries after fresh",
+			fresh: []string{"f1"},
+			retry: []string{"r1", "r2"},
+			count: 3,
+			want:  []string{"f1", "r1", "r2"},
+		},
+		{
+			name: "empty",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fresh := New[string](10)
+			fresh.PushBatch(tt.fresh)
+			retry := New[string](10)
+			retry.PushBatch(tt.retry)
+			priority := NewPriority(&Lane[string]{Queue: fresh, Weight: 3}, &Lane[string]{Queue: retry})
+
+			assert.Equal(t, tt.want, priority.PopBatch(tt.count))
+			assert.EqualValues(t, uint64(len(tt.fresh)+len(tt.retry)-len(tt.want)), priority.Count())
+		})
+	}
+}
+
+func TestNewPriority_CopiesLanes(t *testing.T) {
+	fresh := New[string](10)
+	fresh.PushBatch([]string{"f1", "f2"})
+	retry := New[string](10)
+	retry.PushBatch([]string{"r1", "r2"})
+	freshLane := &Lane[string]{Queue: fresh, Weight: 3}
+	retryLane := &Lane[string]{Queue: retry}
+	priority := NewPriority(freshLane, retryLane)
+	assert.EqualValues(t, 0, retryLane.Weight)
+
+	// weights are taken at construction
+	freshLane.Weight = 1
+	assert.Equal(t, []string{"f1", "f2", "r1", "r2"}, priority.PopBatch(4))
+}
+
+func TestPriority_PopWait(t *testing.T) {
+	fresh := New[int](1)
+	retry := New[int](1)
+	priority := NewPriority(&Lane[int]{Queue: fresh, Weight: 3}, &Lane[int]{Queue: retry, Weight: 1})
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		retry.Push(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	item, err := priority.PopWait(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, item)
+}
+
+func TestPriority_PopWaitCanceled(t *testing.T) {
+	priority := NewPriority(&Lane[int]{Queue: New[int](1)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err := priority.PopWait(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPriority_PopBatchWait(t *testing.T) {
+	fresh := New[int](3)
+	retry := New[int](3)
+	priority := NewPriority(&Lane[int]{Queue: fresh, Weight: 2}, &Lane[int]{Queue: retry, Weight: 1})
+	fresh.Push(1)
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		retry.Push(2)
+		fresh.Push(3)
+	}()
+
+	items, err := priority.PopBatchWait(context.Background(), 3, time.Second)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{1, 2, 3}, items)
+
+	items, err = priority.PopBatchWait(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	fresh.Push(4)
+	start := time.Now()
+	items, err = priority.PopBatchWait(context.Background(), 3, time.Millisecond*20)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, items)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*20)
+}
@@ -2,50 +2,207 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var ErrFull = errors.New("queue is full")
+
 type Queue[T any] struct {
-	items chan T
+	items    chan T
+	overflow OverflowPolicy[T]
+	delayed  *delayed[T]
+	// reserve and release track keys of pending and in flight items if deduplication is enabled
+	reserve func(item T) bool
+	release func(item T)
+	// leases holds not released leases until visibility timeout
+	leases            *delayed[*Lease[T]]
+	visibilityTimeout time.Duration
 }
 
 type removeBatchFilter[T any] func(items []T) error
 
-func New[T any](size uint64) *Queue[T] {
-	return &Queue[T]{
-		items: make(chan T, size),
+// OverflowPolicy handles item which TryPush can`t put to the full queue
+type OverflowPolicy[T any] func(queue *Queue[T], item T) error
+
+type Option[T any] func(queue *Queue[T])
+
+// WithOverflowPolicy sets TryPush overflow policy, Reject is used by default
+func WithOverflowPolicy[T any](policy OverflowPolicy[T]) Option[T] {
+	return func(queue *Queue[T]) {
+		queue.overflow = policy
+	}
+}
+
+// WithKey enables deduplication and cancellation of delayed items by key
+func WithKey[T any, K comparable](key func(item T) K) Option[T] {
+	return func(queue *Queue[T]) {
+		queue.delayed.key = func(item T) any {
+			return key(item)
+		}
+	}
+}
+
+// WithDeduplicator makes the push a no-op while the item with the same key is pending or in flight.
+// Key is released by Ack, delayed items are deduplicated by the key as well
+func WithDeduplicator[T any, K comparable](deduplicator *Deduplicator[K], key func(item T) K) Option[T] {
+	return func(queue *Queue[T]) {
+		WithKey(key)(queue)
+		queue.reserve = func(item T) bool {
+			return deduplicator.reserve(key(item))
+		}
+		queue.release = func(item T) {
+			deduplicator.Release(key(item))
+		}
+	}
+}
+
+// Reject returns ErrFull
+func Reject[T any]() OverflowPolicy[T] {
+	return func(queue *Queue[T], item T) error {
+		return ErrFull
+	}
+}
+
+// DropOldest removes the oldest items until the item fits, onDrop is called for every removed item if not nil
+func DropOldest[T any](onDrop func(item T)) OverflowPolicy[T] {
+	return func(queue *Queue[T], item T) error {
+		if !queue.reserve(item) {
+			return nil
+		}
+
+		for {
+			select {
+			case queue.items <- item:
+				return nil
+			default:
+			}
+
+			select {
+			case dropped := <-queue.items:
+				queue.release(dropped)
+				if onDrop != nil {
+					onDrop(dropped)
+				}
+			default:
+				// queue was drained concurrently
+			}
+		}
 	}
 }
 
+// Spill passes the item to spill, e.g. to keep it in persistent storage until the queue has space
+func Spill[T any](spill func(item T) error) OverflowPolicy[T] {
+	return func(queue *Queue[T], item T) error {
+		return spill(item)
+	}
+}
+
+func New[T any](size uint64, options ...Option[T]) *Queue[T] {
+	queue := &Queue[T]{
+		items:    make(chan T, size),
+		overflow: Reject[T](),
+		reserve: func(item T) bool {
+			return true
+		},
+		release: func(item T) {},
+	}
+	queue.delayed = newDelayed(queue.pushDue)
+	queue.leases = newDelayed(func(ctx context.Context, lease *Lease[T]) error {
+		return lease.expire()
+	})
+	queue.leases.key = func(lease *Lease[T]) any {
+		return lease
+	}
+	for _, option := range options {
+		option(queue)
+	}
+
+	return queue
+}
+
+// Push blocks until the queue has space, consider TryPush or PushContext
 func (queue *Queue[T]) Push(item T) {
+	if !queue.reserve(item) {
+		return
+	}
+
 	queue.items <- item
 }
 
+// TryPush does not block, overflow policy is applied if the queue is full
+func (queue *Queue[T]) TryPush(item T) error {
+	if !queue.reserve(item) {
+		return nil
+	}
+
+	select {
+	case queue.items <- item:
+		return nil
+	default:
+		queue.release(item)
+		return queue.overflow(queue, item)
+	}
+}
+
+// PushContext blocks until the queue has space or ctx is done
+func (queue *Queue[T]) PushContext(ctx context.Context, item T) error {
+	if !queue.reserve(item) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		queue.release(item)
+		return context.Cause(ctx)
+	case queue.items <- item:
+		return nil
+	}
+}
+
+// pushDue puts the due delayed item without blocking the scheduler, overflow policy is not applied
+func (queue *Queue[T]) pushDue(ctx context.Context, item T) error {
+	if !queue.reserve(item) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		queue.release(item)
+		return context.Cause(ctx)
+	case queue.items <- item:
+		return nil
+	default:
+		queue.release(item)
+		return ErrFull
+	}
+}
+
 func (queue *Queue[T]) PushBatch(items []T) {
 	for _, item := range items {
-		queue.items <- item
+		queue.Push(item)
 	}
 }
 
 func (queue *Queue[T]) PushChannel(items <-chan T) {
 	for item := range items {
-		queue.items <- item
+		queue.Push(item)
 	}
 }
 
+// PushDelayed puts the item to the queue after delay, push is cancelled if ctx is done.
+// If the queue has a key, the item with the same key is scheduled once at the earliest due time
+// and immediate push cancels the scheduled one
 func (queue *Queue[T]) PushDelayed(ctx context.Context, item T, delay time.Duration) {
 	if delay > 0 {
-		go func() {
-			select {
-			case <-ctx.Done():
-				// push cancelled if context cancelled
-			case <-time.After(delay):
-				queue.items <- item
-			}
-		}()
-	} else {
-		queue.items <- item
+		queue.delayed.schedule(ctx, item, delay)
+		return
+	}
+
+	if queue.delayed.key != nil {
+		queue.delayed.cancel(queue.delayed.key(item))
 	}
+	queue.PushContext(ctx, item)
 }
 
 func (queue *Queue[T]) PushBatchDelayed(ctx context.Context, items []T, delay time.Duration) {
@@ -83,17 +240,110 @@ func (queue *Queue[T]) PopBatch(count uint64) []T {
 	return items
 }
 
+// PopWait blocks until item is available or ctx is done
+func (queue *Queue[T]) PopWait(ctx context.Context) (T, error) {
+	select {
+	case <-ctx.Done():
+		return *new(T), context.Cause(ctx)
+	case item := <-queue.items:
+		return item, nil
+	}
+}
+
+// PopBatchWait blocks until at least one item is available or ctx is done.
+// After the first item it waits up to linger for the batch to be filled up to count items
+func (queue *Queue[T]) PopBatchWait(ctx context.Context, count uint64, linger time.Duration) ([]T, error) {
+	if count == 0 {
+		return []T{}, nil
+	}
+
+	item, err := queue.PopWait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0, count)
+	items = append(items, item)
+	items = append(items, queue.PopBatch(count-1)...)
+	if uint64(len(items)) == count || linger <= 0 {
+		return items, nil
+	}
+
+	timer := time.NewTimer(linger)
+	defer timer.Stop()
+
+	for uint64(len(items)) < count {
+		select {
+		case <-ctx.Done():
+			// items are already taken from the queue, so they are returned anyway
+			return items, nil
+		case <-timer.C:
+			return items, nil
+		case item := <-queue.items:
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// RemoveBatch removes up to count items if filter succeeds, otherwise items are returned to the queue
 func (queue *Queue[T]) RemoveBatch(count uint64, filter removeBatchFilter[T]) error {
-	items := queue.PopBatch(count)
+	leases := queue.TryReceiveBatch(count)
+	items := make([]T, 0, len(leases))
+	for _, lease := range leases {
+		items = append(items, lease.Item())
+	}
+
 	if err := filter(items); err != nil {
-		queue.PushBatch(items)
+		for _, lease := range leases {
+			lease.Nack(0)
+		}
 
 		return err
 	}
 
+	for _, lease := range leases {
+		lease.Ack()
+	}
+
 	return nil
 }
 
 func (queue *Queue[T]) Count() uint64 {
 	return uint64(len(queue.items))
 }
+
+// CancelDelayed removes the scheduled item with the key, it reports whether the item was scheduled
+func (queue *Queue[T]) CancelDelayed(key any) bool {
+	return queue.delayed.cancel(key)
+}
+
+// Scheduled reports whether the item with the key is waiting to be pushed, it is always false without WithKey
+func (queue *Queue[T]) Scheduled(key any) bool {
+	return queue.delayed.scheduled(key)
+}
+
+// NextDue returns due time of the earliest scheduled item
+func (queue *Queue[T]) NextDue() (time.Time, bool) {
+	return queue.delayed.next()
+}
+
+// DelayedCount returns count of scheduled items
+func (queue *Queue[T]) DelayedCount() uint64 {
+	return queue.delayed.count()
+}
+
+// Ack releases the key of the item taken from the queue, so the item with the same key may be pushed again
+func (queue *Queue[T]) Ack(item T) {
+	queue.release(item)
+}
+
+func (queue *Queue[T]) Cap() uint64 {
+	return uint64(cap(queue.items))
+}
+
+// Full reports whether the next push would overflow the queue
+func (queue *Queue[T]) Full() bool {
+	return queue.Count() >= queue.Cap()
+}
@@ -1,9 +1,12 @@
 package queue
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -70,3 +73,174 @@ func TestQueue(t *testing.T) {
 		})
 	}
 }
+
+func TestQueue_PopWait(t *testing.T) {
+	queue := New[int](1)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		queue.Push(1)
+	}()
+
+	item, err := queue.PopWait(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, item)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err = queue.PopWait(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestQueue_PopBatchWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		count  uint64
+		linger time.Duration
+		push   func(queue *Queue[int])
+		want   []int
+	}{
+		{
+			name:   "full batch available",
+			count:  2,
+			linger: time.Hour,
+			push: func(queue *Queue[int]) {
+				queue.PushBatch([]int{1, 2, 3})
+			},
+			want: []int{1, 2},
+		},
+		{
+			name:   "no linger",
+			count:  3,
+			linger: 0,
+			push: func(queue *Queue[int]) {
+				queue.Push(1)
+				go func() {
+					time.Sleep(time.Millisecond * 50)
+					queue.Push(2)
+				}()
+			},
+			want: []int{1},
+		},
+		{
+			name:   "batch filled while lingering",
+			count:  2,
+			linger: time.Hour,
+			push: func(queue *Queue[int]) {
+				go func() {
+					queue.Push(1)
+					time.Sleep(time.Millisecond * 10)
+					queue.Push(2)
+				}()
+			},
+			want: []int{1, 2},
+		},
+		{
+			name:   "linger expired",
+			count:  3,
+			linger: time.Millisecond * 10,
+			push: func(queue *Queue[int]) {
+				queue.PushBatch([]int{1, 2})
+			},
+			want: []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := New[int](3)
+			tt.push(queue)
+
+			items, err := queue.PopBatchWait(context.Background(), tt.count, tt.linger)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, items)
+		})
+	}
+}
+
+func TestQueue_PopBatchWaitCanceled(t *testing.T) {
+	queue := New[int](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items, err := queue.PopBatchWait(ctx, 10, time.Hour)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, items)
+}
+
+func TestQueue_TryPush(t *testing.T) {
+	spillErr := errors.New("spill error")
+	tests := []struct {
+		name    string
+		policy  func(overflowed *[]int) OverflowPolicy[int]
+		err     error
+		want    []int
+		dropped []int
+	}{
+		{
+			name: "reject",
+			policy: func(overflowed *[]int) OverflowPolicy[int] {
+				return Reject[int]()
+			},
+			err:     ErrFull,
+			want:    []int{1, 2},
+			dropped: []int{},
+		},
+		{
+			name: "drop oldest",
+			policy: func(overflowed *[]int) OverflowPolicy[int] {
+				return DropOldest(func(item int) {
+					*overflowed = append(*overflowed, item)
+				})
+			},
+			want:    []int{2, 3},
+			dropped: []int{1},
+		},
+		{
+			name: "spill",
+			policy: func(overflowed *[]int) OverflowPolicy[int] {
+				return Spill(func(item int) error {
+					*overflowed = append(*overflowed, item)
+					return spillErr
+				})
+			},
+			err:     spillErr,
+			want:    []int{1, 2},
+			dropped: []int{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overflowed := make([]int, 0)
+			queue := New[int](2, WithOverflowPolicy(tt.policy(&overflowed)))
+			require.NoError(t, queue.TryPush(1))
+			require.NoError(t, queue.TryPush(2))
+			assert.True(t, queue.Full())
+
+			err := queue.TryPush(3)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, queue.PopBatch(2))
+			assert.Equal(t, tt.dropped, overflowed)
+		})
+	}
+}
+
+func TestQueue_PushContext(t *testing.T) {
+	queue := New[int](1)
+	require.NoError(t, queue.PushContext(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	require.ErrorIs(t, queue.PushContext(ctx, 2), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		queue.Pop()
+	}()
+	require.NoError(t, queue.PushContext(context.Background(), 3))
+	item, ok := queue.Pop()
+	require.True(t, ok)
+	assert.Equal(t, 3, item)
+}
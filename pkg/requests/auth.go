@@ -9,3 +9,8 @@ type Register struct {
 	Login    string `json:"login" valid:"required,stringlength(3|32),matches(^[0-9A-Za-z_-]+$)"`
 	Password string `json:"password" valid:"required,stringlength(8|64)"`
 }
+
+type ChangePassword struct {
+	OldPassword string `json:"old_password" valid:"required,minstringlength(1)"`
+	NewPassword string `json:"new_password" valid:"required,stringlength(8|64)"`
+}
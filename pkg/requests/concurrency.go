@@ -0,0 +1,5 @@
+package requests
+
+type Concurrency struct {
+	Concurrency uint64 `json:"concurrency" valid:"required,range(1|1000)"`
+}
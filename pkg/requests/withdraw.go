@@ -1,6 +1,8 @@
 package requests
 
+import "github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+
 type Withdraw struct {
-	Order uint64  `json:"order,string" valid:"required,luhn"`
-	Sum   float64 `json:"sum" valid:"required,positive"`
+	Order uint64       `json:"order,string" valid:"required,luhn"`
+	Sum   money.Amount `json:"sum" valid:"required,positive"`
 }
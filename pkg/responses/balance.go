@@ -1,6 +1,8 @@
 package responses
 
+import "github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+
 type Balance struct {
-	Current   float64 `json:"current"`
-	Withdrawn float64 `json:"withdrawn"`
+	Current   money.Amount `json:"current"`
+	Withdrawn money.Amount `json:"withdrawn"`
 }
@@ -0,0 +1,7 @@
+package responses
+
+type Concurrency struct {
+	Processor   string `json:"processor"`
+	Concurrency uint64 `json:"concurrency"`
+	InFlight    uint64 `json:"in_flight"`
+}
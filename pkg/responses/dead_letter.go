@@ -0,0 +1,18 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
+)
+
+type DeadLetter struct {
+	ID        uint64        `json:"id"`
+	Order     uint64        `json:"order,string"`
+	Source    string        `json:"source"`
+	Status    string        `json:"status,omitempty"`
+	Accrual   *money.Amount `json:"accrual,omitempty"`
+	Attempts  uint64        `json:"attempts"`
+	Reason    string        `json:"reason"`
+	CreatedAt time.Time     `json:"created_at"`
+}
@@ -2,11 +2,13 @@ package responses
 
 import (
 	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 )
 
 type Order struct {
-	Number     uint64    `json:"number,string"`
-	Status     string    `json:"status"`
-	Accrual    *float64  `json:"accrual,omitempty"`
-	UploadedAt time.Time `json:"uploaded_at"`
+	Number     uint64        `json:"number,string"`
+	Status     string        `json:"status"`
+	Accrual    *money.Amount `json:"accrual,omitempty"`
+	UploadedAt time.Time     `json:"uploaded_at"`
 }
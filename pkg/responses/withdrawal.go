@@ -2,10 +2,12 @@ package responses
 
 import (
 	"time"
+
+	"github.com/m1khal3v/gophermart-loyalty-service/pkg/gorm/types/money"
 )
 
 type Withdrawal struct {
-	Order       uint64    `json:"order,string"`
-	Sum         float64   `json:"sum"`
-	ProcessedAt time.Time `json:"processed_at"`
+	Order       uint64       `json:"order,string"`
+	Sum         money.Amount `json:"sum"`
+	ProcessedAt time.Time    `json:"processed_at"`
 }
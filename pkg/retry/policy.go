@@ -0,0 +1,80 @@
+package retry
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy returns delay before the next attempt, attempt starts from 0, previous is the last delay
+type Policy interface {
+	Delay(attempt uint64, previous time.Duration) time.Duration
+}
+
+type PolicyFunc func(attempt uint64, previous time.Duration) time.Duration
+
+func (function PolicyFunc) Delay(attempt uint64, previous time.Duration) time.Duration {
+	return function(attempt, previous)
+}
+
+// Exponential multiplies baseDelay by multiplier on every attempt up to maxDelay
+func Exponential(baseDelay, maxDelay time.Duration, multiplier uint64) Policy {
+	return PolicyFunc(func(attempt uint64, previous time.Duration) time.Duration {
+		return calculateDelay(baseDelay, maxDelay, attempt, multiplier)
+	})
+}
+
+// FullJitter chooses random delay between 0 and delay of the policy
+func FullJitter(policy Policy) Policy {
+	return PolicyFunc(func(attempt uint64, previous time.Duration) time.Duration {
+		delay := policy.Delay(attempt, previous)
+		if delay <= 0 {
+			return 0
+		}
+
+		return rand.N(delay + 1)
+	})
+}
+
+// DecorrelatedJitter chooses random delay between baseDelay and triple previous delay up to maxDelay
+func DecorrelatedJitter(baseDelay, maxDelay time.Duration) Policy {
+	return PolicyFunc(func(attempt uint64, previous time.Duration) time.Duration {
+		upper := max(previous*3, baseDelay)
+		delay := baseDelay + rand.N(upper-baseDelay+1)
+
+		return min(delay, maxDelay)
+	})
+}
+
+// pow saturates at math.MaxUint64 instead of overflow
+func pow(x, y uint64) uint64 {
+	if y == 0 {
+		return 1
+	}
+
+	if y == 1 {
+		return x
+	}
+
+	result := x
+	for i := uint64(2); i <= y; i++ {
+		if x != 0 && result > math.MaxUint64/x {
+			return math.MaxUint64
+		}
+		result *= x
+	}
+	return result
+}
+
+func calculateDelay(baseDelay time.Duration, maxDelay time.Duration, attempt uint64, multiplier uint64) time.Duration {
+	if attempt == 0 || baseDelay <= 0 {
+		return min(baseDelay, maxDelay)
+	}
+
+	factor := pow(multiplier, attempt)
+	if factor > uint64(maxDelay/baseDelay) {
+		return maxDelay
+	}
+
+	return min(baseDelay*time.Duration(factor), maxDelay)
+}
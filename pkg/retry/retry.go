@@ -1,58 +1,159 @@
 package retry
 
 import (
+	"context"
+	"errors"
 	"time"
 )
 
-func Retry(
-	baseDelay,
-	maxDelay time.Duration,
-	retries,
-	multiplier uint64,
-	function func() error,
-	filter func(err error) bool,
-) error {
-	var err error
-	retry := uint64(0)
+type config struct {
+	policy         Policy
+	retries        uint64
+	maxElapsedTime time.Duration
+	filter         func(err error) bool
+	delayFromError func(err error) (time.Duration, bool)
+	onRetry        func(attempt uint64, err error, delay time.Duration)
+	onGiveUp       func(attempts uint64, err error)
+}
+
+type Option func(config *config)
 
-	for {
-		if retry > retries {
+// WithPolicy sets delay policy, FullJitter(Exponential(time.Second, 5*time.Second, 2)) is used by default
+func WithPolicy(policy Policy) Option {
+	return func(config *config) {
+		config.policy = policy
+	}
+}
+
+// WithRetries sets max count of retries after the first attempt, 4 is used by default
+func WithRetries(retries uint64) Option {
+	return func(config *config) {
+		config.retries = retries
+	}
+}
+
+// WithMaxElapsedTime stops retrying if the next attempt would start later than maxElapsedTime after the first one
+func WithMaxElapsedTime(maxElapsedTime time.Duration) Option {
+	return func(config *config) {
+		config.maxElapsedTime = maxElapsedTime
+	}
+}
+
+// WithFilter sets function which reports whether the error is retryable, all errors are retryable by default
+func WithFilter(filter func(err error) bool) Option {
+	return func(config *config) {
+		config.filter = filter
+	}
+}
+
+// WithDelayFromError overrides delay of the policy, e.g. by server provided Retry-After
+func WithDelayFromError(delayFromError func(err error) (time.Duration, bool)) Option {
+	return func(config *config) {
+		config.delayFromError = delayFromError
+	}
+}
+
+// WithOnRetry sets function called before waiting for the next attempt
+func WithOnRetry(onRetry func(attempt uint64, err error, delay time.Duration)) Option {
+	return func(config *config) {
+		config.onRetry = onRetry
+	}
+}
+
+// WithOnGiveUp sets function called if retryable error is returned after the last attempt
+func WithOnGiveUp(onGiveUp func(attempts uint64, err error)) Option {
+	return func(config *config) {
+		config.onGiveUp = onGiveUp
+	}
+}
+
+func newConfig(options ...Option) *config {
+	config := &config{
+		policy:  FullJitter(Exponential(time.Second, 5*time.Second, 2)),
+		retries: 4,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	return config
+}
+
+// RetryContext calls function until it succeeds, returns not retryable error or retries are exhausted.
+// Waiting for the next attempt is interrupted if ctx is done, then both errors are returned
+func RetryContext(ctx context.Context, function func(ctx context.Context) error, options ...Option) error {
+	config := newConfig(options...)
+	start := time.Now()
+	delay := time.Duration(0)
+
+	for attempt := uint64(0); ; attempt++ {
+		err := function(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if config.filter != nil && !config.filter(err) {
 			return err
 		}
 
-		if err = function(); err == nil {
-			return nil
+		delay = config.policy.Delay(attempt, delay)
+		if config.delayFromError != nil {
+			if errorDelay, ok := config.delayFromError(err); ok {
+				delay = max(errorDelay, 0)
+			}
 		}
 
-		if filter != nil && !filter(err) {
+		if attempt >= config.retries ||
+			(config.maxElapsedTime > 0 && time.Since(start)+delay > config.maxElapsedTime) {
+			if config.onGiveUp != nil {
+				config.onGiveUp(attempt+1, err)
+			}
+
 			return err
 		}
 
-		time.Sleep(calculateDelay(baseDelay, maxDelay, retry, multiplier))
-		retry++
+		if config.onRetry != nil {
+			config.onRetry(attempt, err, delay)
+		}
+
+		if waitErr := wait(ctx, delay); waitErr != nil {
+			return errors.Join(err, context.Cause(ctx))
+		}
 	}
 }
 
-func pow(x, y uint64) uint64 {
-	if y == 0 {
-		return 1
-	}
+// Retry is RetryContext without jitter and cancellation
+func Retry(
+	baseDelay,
+	maxDelay time.Duration,
+	retries,
+	multiplier uint64,
+	function func() error,
+	filter func(err error) bool,
+) error {
+	return RetryContext(
+		context.Background(),
+		func(ctx context.Context) error {
+			return function()
+		},
+		WithPolicy(Exponential(baseDelay, maxDelay, multiplier)),
+		WithRetries(retries),
+		WithFilter(filter),
+	)
+}
 
-	if y == 1 {
-		return x
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
 	}
 
-	result := x
-	for i := uint64(2); i <= y; i++ {
-		result *= x
-	}
-	return result
-}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
 
-func calculateDelay(baseDelay time.Duration, maxDelay time.Duration, attempt uint64, multiplier uint64) time.Duration {
-	if attempt == 0 {
-		return min(baseDelay, maxDelay)
-	} else {
-		return min(baseDelay*time.Duration(pow(multiplier, attempt)), maxDelay)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
@@ -1,11 +1,13 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_pow(t *testing.T) {
@@ -205,3 +207,127 @@ func TestRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestRetryContext(t *testing.T) {
+	someErr := errors.New("some error")
+	retryAfterErr := errors.New("retry after")
+	tests := []struct {
+		name         string
+		errs         []error
+		options      []Option
+		wantAttempts uint64
+		wantRetries  []time.Duration
+		wantGiveUp   bool
+		wantErr      error
+	}{
+		{
+			name:         "ok after retry",
+			errs:         []error{someErr, nil},
+			options:      []Option{WithPolicy(Exponential(time.Millisecond, time.Millisecond*10, 2))},
+			wantAttempts: 2,
+			wantRetries:  []time.Duration{time.Millisecond},
+		},
+		{
+			name:         "retries exhausted",
+			errs:         []error{someErr, someErr, someErr},
+			options:      []Option{WithPolicy(Exponential(time.Millisecond, time.Millisecond*10, 2)), WithRetries(2)},
+			wantAttempts: 3,
+			wantRetries:  []time.Duration{time.Millisecond, time.Millisecond * 2},
+			wantGiveUp:   true,
+			wantErr:      someErr,
+		},
+		{
+			name: "delay from error",
+			errs: []error{retryAfterErr, nil},
+			options: []Option{
+				WithPolicy(Exponential(time.Hour, time.Hour, 2)),
+				WithDelayFromError(func(err error) (time.Duration, bool) {
+					return time.Millisecond * 3, errors.Is(err, retryAfterErr)
+				}),
+			},
+			wantAttempts: 2,
+			wantRetries:  []time.Duration{time.Millisecond * 3},
+		},
+		{
+			name: "max elapsed time",
+			errs: []error{someErr},
+			options: []Option{
+				WithPolicy(Exponential(time.Hour, time.Hour, 2)),
+				WithMaxElapsedTime(time.Minute),
+			},
+			wantAttempts: 1,
+			wantGiveUp:   true,
+			wantErr:      someErr,
+		},
+		{
+			name: "not retryable",
+			errs: []error{someErr},
+			options: []Option{WithFilter(func(err error) bool {
+				return false
+			})},
+			wantAttempts: 1,
+			wantErr:      someErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := uint64(0)
+			retries := make([]time.Duration, 0)
+			giveUp := false
+			options := append(
+				tt.options,
+				WithOnRetry(func(attempt uint64, err error, delay time.Duration) {
+					retries = append(retries, delay)
+				}),
+				WithOnGiveUp(func(attempts uint64, err error) {
+					giveUp = true
+				}),
+			)
+
+			err := RetryContext(context.Background(), func(ctx context.Context) error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			}, options...)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.ElementsMatch(t, tt.wantRetries, retries)
+			assert.Equal(t, tt.wantGiveUp, giveUp)
+		})
+	}
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	someErr := errors.New("some error")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	start := time.Now()
+	err := RetryContext(ctx, func(ctx context.Context) error {
+		return someErr
+	}, WithPolicy(Exponential(time.Hour, time.Hour, 2)))
+
+	require.ErrorIs(t, err, someErr)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestJitter(t *testing.T) {
+	fullJitter := FullJitter(Exponential(time.Second, time.Second*5, 2))
+	decorrelatedJitter := DecorrelatedJitter(time.Second, time.Second*5)
+	previous := time.Duration(0)
+	for attempt := uint64(0); attempt < 100; attempt++ {
+		delay := fullJitter.Delay(attempt, 0)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, calculateDelay(time.Second, time.Second*5, attempt, 2))
+
+		previous = decorrelatedJitter.Delay(attempt, previous)
+		assert.GreaterOrEqual(t, previous, time.Second)
+		assert.LessOrEqual(t, previous, time.Second*5)
+	}
+}
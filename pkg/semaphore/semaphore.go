@@ -1,30 +1,142 @@
 package semaphore
 
-import "context"
+import (
+	"container/list"
+	"context"
+	"sync"
+)
 
+type waiter struct {
+	weight uint64
+	ready  chan struct{}
+}
+
+// Semaphore is a weighted semaphore, waiters are served in FIFO order,
+// so a heavy waiter is not starved by light ones
 type Semaphore struct {
-	channel chan struct{}
+	mutex   sync.Mutex
+	size    uint64
+	current uint64
+	waiters list.List
 }
 
-func New(max uint64) *Semaphore {
-	if max == 0 {
-		panic("max cannot be 0")
+func New(size uint64) *Semaphore {
+	if size == 0 {
+		panic("size cannot be 0")
 	}
 
 	return &Semaphore{
-		channel: make(chan struct{}, max),
+		size: size,
 	}
 }
 
-func (semaphore *Semaphore) Acquire(ctx context.Context) error {
+// Acquire blocks until weight is available or ctx is done.
+// Weight greater than size waits until the semaphore is resized
+func (semaphore *Semaphore) Acquire(ctx context.Context, weight uint64) error {
+	semaphore.mutex.Lock()
+	if semaphore.waiters.Len() == 0 && semaphore.current+weight <= semaphore.size {
+		semaphore.current += weight
+		semaphore.mutex.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	element := semaphore.waiters.PushBack(waiter{weight: weight, ready: ready})
+	semaphore.mutex.Unlock()
+
 	select {
 	case <-ctx.Done():
+		semaphore.mutex.Lock()
+		defer semaphore.mutex.Unlock()
+
+		select {
+		case <-ready:
+			// acquired concurrently with cancellation, weight is returned
+			semaphore.current -= weight
+		default:
+			front := semaphore.waiters.Front() == element
+			semaphore.waiters.Remove(element)
+			if !front {
+				return context.Cause(ctx)
+			}
+		}
+		semaphore.notify()
+
 		return context.Cause(ctx)
-	case semaphore.channel <- struct{}{}:
+	case <-ready:
 		return nil
 	}
 }
 
-func (semaphore *Semaphore) Release() {
-	<-semaphore.channel
+// TryAcquire acquires weight without blocking and reports whether it succeeded
+func (semaphore *Semaphore) TryAcquire(weight uint64) bool {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+
+	if semaphore.waiters.Len() == 0 && semaphore.current+weight <= semaphore.size {
+		semaphore.current += weight
+		return true
+	}
+
+	return false
+}
+
+func (semaphore *Semaphore) Release(weight uint64) {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+
+	if weight > semaphore.current {
+		panic("released more than acquired")
+	}
+	semaphore.current -= weight
+	semaphore.notify()
+}
+
+// Resize changes size of the semaphore, acquired weight over the new size is not revoked
+// and new acquisitions wait until it is released
+func (semaphore *Semaphore) Resize(size uint64) {
+	if size == 0 {
+		panic("size cannot be 0")
+	}
+
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+
+	semaphore.size = size
+	semaphore.notify()
+}
+
+func (semaphore *Semaphore) Size() uint64 {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+
+	return semaphore.size
+}
+
+// Current returns acquired weight
+func (semaphore *Semaphore) Current() uint64 {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+
+	return semaphore.current
+}
+
+// notify must be called with the mutex held
+func (semaphore *Semaphore) notify() {
+	for {
+		element := semaphore.waiters.Front()
+		if element == nil {
+			return
+		}
+
+		waiter := element.Value.(waiter)
+		if semaphore.current+waiter.weight > semaphore.size {
+			// waiters are served in order
+			return
+		}
+
+		semaphore.current += waiter.weight
+		semaphore.waiters.Remove(element)
+		close(waiter.ready)
+	}
 }
@@ -3,6 +3,7 @@ package semaphore
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -11,16 +12,16 @@ import (
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name      string
-		max       uint64
+		size      uint64
 		wantPanic bool
 	}{
 		{
 			name: "valid",
-			max:  10,
+			size: 10,
 		},
 		{
 			name:      "invalid",
-			max:       0,
+			size:      0,
 			wantPanic: true,
 		},
 	}
@@ -28,11 +29,12 @@ func TestNew(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.wantPanic {
 				assert.Panics(t, func() {
-					New(tt.max)
+					New(tt.size)
 				})
 			} else {
-				semaphore := New(tt.max)
-				assert.Len(t, semaphore.channel, 0)
+				semaphore := New(tt.size)
+				assert.Equal(t, tt.size, semaphore.Size())
+				assert.EqualValues(t, 0, semaphore.Current())
 			}
 		})
 	}
@@ -41,10 +43,89 @@ func TestNew(t *testing.T) {
 func TestSemaphore(t *testing.T) {
 	semaphore := New(1)
 	ctx := context.Background()
-	require.NoError(t, semaphore.Acquire(ctx))
+	require.NoError(t, semaphore.Acquire(ctx, 1))
 	cancelCtx, cancel := context.WithCancel(ctx)
 	cancel()
-	require.Error(t, semaphore.Acquire(cancelCtx))
-	semaphore.Release()
-	require.NoError(t, semaphore.Acquire(ctx))
+	require.Error(t, semaphore.Acquire(cancelCtx, 1))
+	semaphore.Release(1)
+	require.NoError(t, semaphore.Acquire(ctx, 1))
+}
+
+func TestSemaphore_Weighted(t *testing.T) {
+	semaphore := New(10)
+	require.True(t, semaphore.TryAcquire(7))
+	assert.False(t, semaphore.TryAcquire(4))
+	require.True(t, semaphore.TryAcquire(3))
+	assert.EqualValues(t, 10, semaphore.Current())
+
+	acquired := make(chan struct{})
+	go func() {
+		require.NoError(t, semaphore.Acquire(context.Background(), 5))
+		close(acquired)
+	}()
+
+	semaphore.Release(3)
+	select {
+	case <-acquired:
+		t.Fatal("acquired before enough weight is released")
+	case <-time.After(time.Millisecond * 20):
+	}
+
+	// waiter is served first
+	assert.False(t, semaphore.TryAcquire(1))
+	semaphore.Release(2)
+	<-acquired
+	assert.EqualValues(t, 10, semaphore.Current())
+
+	assert.Panics(t, func() {
+		semaphore.Release(11)
+	})
+}
+
+func TestSemaphore_Resize(t *testing.T) {
+	semaphore := New(1)
+	require.NoError(t, semaphore.Acquire(context.Background(), 1))
+
+	acquired := make(chan struct{})
+	go func() {
+		require.NoError(t, semaphore.Acquire(context.Background(), 2))
+		close(acquired)
+	}()
+
+	time.Sleep(time.Millisecond * 10)
+	semaphore.Resize(3)
+	<-acquired
+	assert.EqualValues(t, 3, semaphore.Current())
+
+	semaphore.Resize(1)
+	assert.False(t, semaphore.TryAcquire(1))
+	semaphore.Release(3)
+	assert.True(t, semaphore.TryAcquire(1))
+
+	assert.Panics(t, func() {
+		semaphore.Resize(0)
+	})
+}
+
+func TestSemaphore_AcquireCanceledUnblocksNext(t *testing.T) {
+	semaphore := New(2)
+	require.True(t, semaphore.TryAcquire(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	heavy := make(chan error)
+	go func() {
+		heavy <- semaphore.Acquire(ctx, 2)
+	}()
+	time.Sleep(time.Millisecond * 10)
+
+	light := make(chan error)
+	go func() {
+		light <- semaphore.Acquire(context.Background(), 1)
+	}()
+	time.Sleep(time.Millisecond * 10)
+
+	cancel()
+	require.ErrorIs(t, <-heavy, context.Canceled)
+	require.NoError(t, <-light)
+	assert.EqualValues(t, 2, semaphore.Current())
 }
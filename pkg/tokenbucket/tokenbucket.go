@@ -0,0 +1,141 @@
+package tokenbucket
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket rate limiter: tokens are refilled at Limit per Period up to Burst,
+// every call takes one token or waits for it. Waiters are served in order of arrival.
+// Zero limit means no limit
+type Bucket struct {
+	mutex sync.Mutex
+	// tokens become negative when waiters reserve future tokens
+	tokens   float64
+	interval time.Duration
+	burst    float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func New(limit uint64, period time.Duration, burst uint64) *Bucket {
+	bucket := &Bucket{now: time.Now}
+	bucket.last = bucket.now()
+	bucket.setLimit(limit, period, burst)
+	bucket.tokens = bucket.burst
+
+	return bucket
+}
+
+// SetLimit changes the rate. Already accumulated tokens are kept up to the new burst
+func (bucket *Bucket) SetLimit(limit uint64, period time.Duration, burst uint64) {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	bucket.refill(bucket.now())
+	bucket.setLimit(limit, period, burst)
+	bucket.tokens = min(bucket.tokens, bucket.burst)
+}
+
+// Limit returns current count of tokens per period. Zero means no limit
+func (bucket *Bucket) Limit(period time.Duration) uint64 {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	if bucket.interval == 0 {
+		return 0
+	}
+
+	return uint64(period / bucket.interval)
+}
+
+// Pause drops accumulated tokens and stops refilling until the specified time
+func (bucket *Bucket) Pause(until time.Time) {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	bucket.refill(bucket.now())
+	bucket.tokens = min(bucket.tokens, 0)
+	if until.After(bucket.last) {
+		bucket.last = until
+	}
+}
+
+// Wait blocks until token is available or context is done
+func (bucket *Bucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	delay := bucket.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		bucket.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes token and returns how long caller must wait before using it
+func (bucket *Bucket) reserve() time.Duration {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	if bucket.interval == 0 {
+		return 0
+	}
+
+	now := bucket.now()
+	bucket.refill(now)
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+
+	return bucket.last.Sub(now) + time.Duration(-bucket.tokens*float64(bucket.interval))
+}
+
+// cancel returns token taken by reserve
+func (bucket *Bucket) cancel() {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	if bucket.interval == 0 {
+		return
+	}
+
+	bucket.tokens = min(bucket.tokens+1, bucket.burst)
+}
+
+func (bucket *Bucket) refill(now time.Time) {
+	if !now.After(bucket.last) {
+		return
+	}
+
+	if bucket.interval > 0 {
+		bucket.tokens = min(bucket.burst, bucket.tokens+float64(now.Sub(bucket.last))/float64(bucket.interval))
+	}
+	bucket.last = now
+}
+
+func (bucket *Bucket) setLimit(limit uint64, period time.Duration, burst uint64) {
+	if limit == 0 || period <= 0 {
+		bucket.interval = 0
+		bucket.burst = 0
+		bucket.tokens = 0
+
+		return
+	}
+
+	bucket.interval = max(period/time.Duration(limit), 1)
+	bucket.burst = float64(max(burst, 1))
+}
@@ -0,0 +1,88 @@
+package tokenbucket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBucket(limit uint64, period time.Duration, burst uint64) (*Bucket, *time.Time) {
+	now := time.Now()
+	bucket := New(limit, period, burst)
+	bucket.now = func() time.Time {
+		return now
+	}
+	bucket.last = now
+
+	return bucket, &now
+}
+
+func TestBucket_reserve(t *testing.T) {
+	bucket, now := newTestBucket(60, time.Minute, 2)
+
+	// burst is available immediately
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+
+	// next waiters are queued one interval after another
+	assert.Equal(t, time.Second, bucket.reserve())
+	assert.Equal(t, time.Second*2, bucket.reserve())
+
+	// refill covers reserved tokens first
+	*now = now.Add(time.Second * 3)
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, time.Second, bucket.reserve())
+
+	// tokens never exceed burst
+	*now = now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, time.Second, bucket.reserve())
+}
+
+func TestBucket_Pause(t *testing.T) {
+	bucket, now := newTestBucket(60, time.Minute, 5)
+
+	bucket.Pause(now.Add(time.Second * 10))
+	assert.Equal(t, time.Second*11, bucket.reserve())
+
+	// pause in the past does nothing
+	bucket.Pause(now.Add(-time.Second))
+	assert.Equal(t, time.Second*12, bucket.reserve())
+}
+
+func TestBucket_SetLimit(t *testing.T) {
+	bucket, _ := newTestBucket(0, time.Minute, 0)
+	for range 100 {
+		assert.Equal(t, time.Duration(0), bucket.reserve())
+	}
+	assert.EqualValues(t, 0, bucket.Limit(time.Minute))
+
+	bucket.SetLimit(30, time.Minute, 1)
+	assert.EqualValues(t, 30, bucket.Limit(time.Minute))
+	assert.Equal(t, time.Second*2, bucket.reserve())
+	assert.Equal(t, time.Second*4, bucket.reserve())
+
+	bucket.SetLimit(0, time.Minute, 1)
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+}
+
+func TestBucket_Wait(t *testing.T) {
+	bucket := New(1, time.Millisecond*50, 1)
+	require.NoError(t, bucket.Wait(context.Background()))
+
+	start := time.Now()
+	require.NoError(t, bucket.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*40)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	require.ErrorIs(t, bucket.Wait(ctx), context.DeadlineExceeded)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, bucket.Wait(canceled), context.Canceled)
+}
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"reflect"
 	"strconv"
 
 	"github.com/asaskevich/govalidator"
@@ -59,6 +60,21 @@ func IsPositive(value any) bool {
 		}
 
 		return float > 0
+	default:
+		return isPositiveKind(value)
+	}
+}
+
+// isPositiveKind handles named numeric types (e.g. money.Amount)
+func isPositiveKind(value any) bool {
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflected.Uint() > 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflected.Int() > 0
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float() > 0
 	default:
 		return false
 	}
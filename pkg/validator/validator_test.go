@@ -65,6 +65,9 @@ func TestIsLuhn(t *testing.T) {
 	}
 }
 
+type namedUint uint64
+type namedInt int64
+
 func TestIsPositive(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -111,6 +114,26 @@ func TestIsPositive(t *testing.T) {
 			value: -123.123,
 			want:  false,
 		},
+		{
+			name:  "valid named uint",
+			value: namedUint(123),
+			want:  true,
+		},
+		{
+			name:  "invalid named uint",
+			value: namedUint(0),
+			want:  false,
+		},
+		{
+			name:  "invalid named int",
+			value: namedInt(-123),
+			want:  false,
+		},
+		{
+			name:  "unsupported type",
+			value: struct{}{},
+			want:  false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {